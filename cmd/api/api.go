@@ -1,18 +1,40 @@
 package api
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
+	"time"
+	"todo/configs"
+	"todo/services/assignment"
+	"todo/services/attachment"
+	"todo/services/board"
+	"todo/services/checklist"
+	"todo/services/comment"
+	"todo/services/customfield"
+	"todo/services/history"
+	"todo/services/idempotency"
+	"todo/services/notification"
+	"todo/services/reminder"
+	"todo/services/share"
 	"todo/services/task"
+	"todo/services/template"
 	"todo/services/user"
+	"todo/services/workflow"
+	"todo/services/workspace"
+	"todo/storage"
+	"todo/types"
+	"todo/utils"
 
 	"github.com/gorilla/mux"
 )
 
 type APIServer struct {
-	addr string
-	db   *sql.DB
+	addr   string
+	db     *sql.DB
+	memory bool
 }
 
 func NewAPIServer(addr string, db *sql.DB) *APIServer {
@@ -22,19 +44,163 @@ func NewAPIServer(addr string, db *sql.DB) *APIServer {
 	}
 }
 
+// WithMemoryStores keeps users and tasks in memory instead of the database, for demos.
+func (s *APIServer) WithMemoryStores() *APIServer {
+	s.memory = true
+	return s
+}
+
 func (s *APIServer) Run() error {
 	router := mux.NewRouter()
+	router.Use(utils.WithRequestID)
+	router.Use(utils.WithRequestTimeout(time.Duration(configs.Envs.RequestTimeoutInSeconds) * time.Second))
 	subrouter := router.PathPrefix("/api/v1").Subrouter()
 
-	userStore := user.NewStore(s.db)
-	userHandler := user.NewHandler(userStore)
+	keyStore := idempotency.NewStore(s.db)
+	keyPurger := idempotency.NewPurger(keyStore, time.Hour)
+	go keyPurger.Run(context.Background())
+
+	var assignmentStore interface {
+		types.AssigneeStore
+		types.WatcherStore
+	} = assignment.NewStore(s.db)
+	var workflowStore types.WorkflowStore = workflow.NewStore(s.db)
+	var fieldStore types.CustomFieldStore = customfield.NewStore(s.db)
+
+	var userStore types.UserStore = user.NewStore(s.db)
+	var taskStore types.TaskStore = task.NewStore(s.db)
+	if s.memory {
+		// workspace members and others still join the users table
+		memoryUsers := user.NewMemoryStore()
+		memoryUsers.OnCreate(user.NewStore(s.db).CopyUser)
+
+		// reminders, watchers and workspaces join the tasks table, and the stores that change
+		// tasks in the database pass their changes on to the memory
+		memoryTasks := task.NewMemoryStore()
+		memoryTasks.UseWIPLimits(board.NewStore(s.db))
+
+		userStore, taskStore = memoryUsers, newDemoTasks(memoryTasks, task.NewStore(s.db))
+		assignmentStore = demoAssignments{Store: assignment.NewStore(s.db), tasks: memoryTasks}
+		workflowStore = demoWorkflows{Store: workflow.NewStore(s.db), tasks: memoryTasks}
+		fieldStore = demoFields{Store: customfield.NewStore(s.db), tasks: memoryTasks}
+	}
+
+	userHandler := user.NewHandler(userStore, keyStore)
 	userHandler.RegisterRoutes(subrouter)
 
-	taskStore := task.NewStore(s.db)
-	taskHandler := task.NewHandler(taskStore, userStore)
+	notificationStore := notification.NewStore(s.db)
+	notificationHandler := notification.NewHandler(notificationStore, userStore)
+	notificationHandler.RegisterRoutes(subrouter)
+
+	channels := map[string]notification.Channel{
+		notification.ChannelInApp:   notification.NewInAppChannel(notificationStore),
+		notification.ChannelWebhook: notification.NewWebhookChannel(time.Duration(configs.Envs.WebhookTimeoutInSeconds) * time.Second),
+	}
+	if configs.Envs.SMTPHost != "" {
+		channels[notification.ChannelEmail] = notification.NewEmailChannel(notification.SMTPConfig{
+			Host:     configs.Envs.SMTPHost,
+			Port:     configs.Envs.SMTPPort,
+			User:     configs.Envs.SMTPUser,
+			Password: configs.Envs.SMTPPassword,
+			From:     configs.Envs.SMTPFrom,
+		})
+	}
+
+	notifier := notification.NewNotifier(assignmentStore, channels)
+
+	workspaceStore := workspace.NewStore(s.db)
+	workspaceHandler := workspace.NewHandler(workspaceStore, userStore, channels[notification.ChannelEmail],
+		time.Duration(configs.Envs.InvitationTTLInHours)*time.Hour)
+	workspaceHandler.RegisterRoutes(subrouter)
+
+	workflowHandler := workflow.NewHandler(workflowStore, userStore)
+	workflowHandler.RegisterRoutes(subrouter)
+
+	fieldHandler := customfield.NewHandler(fieldStore, userStore)
+	fieldHandler.RegisterRoutes(subrouter)
+
+	eventStore := history.NewStore(s.db)
+	wipStore := board.NewStore(s.db)
+	taskHandler := task.NewHandler(taskStore, userStore, eventStore, wipStore, workflowStore, fieldStore, notifier, workspaceStore, keyStore)
 	taskHandler.RegisterRoutes(subrouter)
 
+	boardHandler := board.NewHandler(wipStore, taskStore, workflowStore, userStore, workspaceStore)
+	boardHandler.RegisterRoutes(subrouter)
+
+	historyHandler := history.NewHandler(eventStore, taskStore, workflowStore, fieldStore, userStore, notifier, workspaceStore)
+	historyHandler.RegisterRoutes(subrouter)
+
+	assignmentHandler := assignment.NewHandler(assignmentStore, assignmentStore, taskStore, eventStore, userStore, notifier, workspaceStore)
+	assignmentHandler.RegisterRoutes(subrouter)
+
+	commentStore := comment.NewStore(s.db)
+	commentHandler := comment.NewHandler(commentStore, taskStore, userStore, notifier, workspaceStore)
+	commentHandler.RegisterRoutes(subrouter)
+
+	checklistStore := checklist.NewStore(s.db)
+	checklistHandler := checklist.NewHandler(checklistStore, taskStore, workflowStore, userStore, eventStore, notifier, workspaceStore)
+	checklistHandler.RegisterRoutes(subrouter)
+
+	shareStore := share.NewStore(s.db)
+	shareHandler := share.NewHandler(shareStore, taskStore, checklistStore, commentStore, userStore, workspaceStore, notifier, []byte(configs.Envs.JWTSecret))
+	shareHandler.RegisterRoutes(subrouter)
+
+	templateStore := template.NewStore(s.db)
+	templateHandler := template.NewHandler(templateStore, taskStore, checklistStore, workflowStore, fieldStore, eventStore, userStore, workspaceStore, notifier)
+	templateHandler.RegisterRoutes(subrouter)
+
+	blobStore, err := newBlobStore()
+	if err != nil {
+		return err
+	}
+
+	attachmentStore := attachment.NewStore(s.db)
+	attachmentHandler := attachment.NewHandler(attachmentStore, blobStore, taskStore, userStore, workspaceStore, attachment.Limits{
+		MaxSize:      configs.Envs.AttachmentMaxBytes,
+		AllowedTypes: configs.Envs.AttachmentAllowedTypes,
+	})
+	attachmentHandler.RegisterRoutes(subrouter)
+
+	purgeAttachments := attachment.PurgeHook(attachmentStore, blobStore)
+	taskHandler.OnPurge(purgeAttachments)
+
+	purger := task.NewPurger(
+		taskStore,
+		time.Duration(configs.Envs.TrashRetentionInDays)*24*time.Hour,
+		time.Duration(configs.Envs.TrashPurgeInSeconds)*time.Second,
+		purgeAttachments,
+	)
+	go purger.Run(context.Background())
+
+	rebalancer := task.NewRebalancer(taskStore, time.Duration(configs.Envs.RebalanceInSeconds)*time.Second)
+	go rebalancer.Run(context.Background())
+
+	reminderStore := reminder.NewStore(s.db)
+	reminderHandler := reminder.NewHandler(reminderStore, taskStore, userStore, workspaceStore)
+	reminderHandler.RegisterRoutes(subrouter)
+
+	scheduler := reminder.NewScheduler(reminderStore, channels, time.Duration(configs.Envs.ReminderPollInSeconds)*time.Second)
+	go scheduler.Run(context.Background())
+
 	log.Println("Listening on", s.addr)
 
 	return http.ListenAndServe(s.addr, router)
 }
+
+func newBlobStore() (types.BlobStore, error) {
+	switch configs.Envs.BlobStore {
+	case "local":
+		return storage.NewLocalStore(configs.Envs.BlobLocalDir)
+	case "s3":
+		return storage.NewS3Store(context.Background(), storage.S3Config{
+			Endpoint:  configs.Envs.S3Endpoint,
+			AccessKey: configs.Envs.S3AccessKey,
+			SecretKey: configs.Envs.S3SecretKey,
+			Bucket:    configs.Envs.S3Bucket,
+			Region:    configs.Envs.S3Region,
+			UseSSL:    configs.Envs.S3UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob store: %s", configs.Envs.BlobStore)
+	}
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"todo/services/assignment"
+	"todo/services/customfield"
+	"todo/services/task"
+	"todo/services/workflow"
+	"todo/types"
+	"todo/utils"
+)
+
+// demoTasks keeps the tasks of the demo mode in memory and copies every write into the database,
+// where reminders, watchers, workspaces and the other tables joining tasks expect them. The copy
+// runs once the memory store released its lock, a failed copy fails the call but the write stays.
+type demoTasks struct {
+	*task.MemoryStore
+	db *task.Store
+
+	// copyMu orders the copies, each one reads the task as it is by then so the last one wins
+	copyMu sync.Mutex
+}
+
+func newDemoTasks(memory *task.MemoryStore, db *task.Store) *demoTasks {
+	return &demoTasks{MemoryStore: memory, db: db}
+}
+
+func (s *demoTasks) CreateTask(ctx context.Context, payload types.CreateTaskPayload) (*types.Task, error) {
+	t, err := s.MemoryStore.CreateTask(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, s.copy(ctx, t.ID)
+}
+
+func (s *demoTasks) CreateTasks(ctx context.Context, payloads []types.CreateTaskPayload) ([]types.Task, error) {
+	tasks, err := s.MemoryStore.CreateTasks(ctx, payloads)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+
+	return tasks, s.copy(ctx, ids...)
+}
+
+func (s *demoTasks) UpdateTask(ctx context.Context, taskID, version int, payload types.UpdateTaskPayload) (int64, error) {
+	return s.copied(ctx, taskID)(s.MemoryStore.UpdateTask(ctx, taskID, version, payload))
+}
+
+func (s *demoTasks) PatchTask(ctx context.Context, taskID, version int, document types.TaskDocument, changed []string) (int64, error) {
+	return s.copied(ctx, taskID)(s.MemoryStore.PatchTask(ctx, taskID, version, document, changed))
+}
+
+func (s *demoTasks) MoveTask(ctx context.Context, taskID, version int, status, position string) (int64, error) {
+	return s.copied(ctx, taskID)(s.MemoryStore.MoveTask(ctx, taskID, version, status, position))
+}
+
+func (s *demoTasks) DeleteTask(ctx context.Context, taskID int) (int64, error) {
+	return s.copied(ctx, taskID)(s.MemoryStore.DeleteTask(ctx, taskID))
+}
+
+func (s *demoTasks) RestoreTask(ctx context.Context, taskID int) (int64, error) {
+	return s.copied(ctx, taskID)(s.MemoryStore.RestoreTask(ctx, taskID))
+}
+
+func (s *demoTasks) PurgeTask(ctx context.Context, taskID int) (int64, error) {
+	return s.copied(ctx, taskID)(s.MemoryStore.PurgeTask(ctx, taskID))
+}
+
+// BulkUpdateTasks copies the tasks whose change was applied.
+func (s *demoTasks) BulkUpdateTasks(ctx context.Context, changes []types.BulkTaskChange, allOrNothing bool) ([]error, error) {
+	itemErrs, err := s.MemoryStore.BulkUpdateTasks(ctx, changes, allOrNothing)
+	if err != nil {
+		return itemErrs, err
+	}
+
+	var ids []int
+	for i, change := range changes {
+		if itemErrs[i] == nil {
+			ids = append(ids, change.TaskID)
+		}
+	}
+
+	return itemErrs, s.copy(ctx, ids...)
+}
+
+// RebalanceColumn copies every task of the column, each may have got a new position.
+func (s *demoTasks) RebalanceColumn(ctx context.Context, column types.BoardColumn) error {
+	if err := s.MemoryStore.RebalanceColumn(ctx, column); err != nil {
+		return err
+	}
+
+	n, err := s.MemoryStore.CountColumnTasks(ctx, column)
+	if err != nil || n == 0 {
+		return err
+	}
+	tasks, _, err := s.MemoryStore.GetColumnTasks(ctx, column, utils.PaginationParams{Page: 1, Limit: n, SortBy: "id"})
+	if err != nil {
+		return err
+	}
+
+	ids := make([]int, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+
+	return s.copy(ctx, ids...)
+}
+
+// copied returns the result of a write to a single task once the task was copied, a write
+// that changed nothing is not.
+func (s *demoTasks) copied(ctx context.Context, taskID int) func(int64, error) (int64, error) {
+	return func(n int64, err error) (int64, error) {
+		if err != nil || n == 0 {
+			return n, err
+		}
+
+		return n, s.copy(ctx, taskID)
+	}
+}
+
+// copy writes the tasks into the database as the memory holds them, purged ones are purged there
+// too. The write is done by now, so the copy goes on when the caller stops waiting.
+func (s *demoTasks) copy(ctx context.Context, taskIDs ...int) error {
+	ctx = context.WithoutCancel(ctx)
+
+	s.copyMu.Lock()
+	defer s.copyMu.Unlock()
+
+	for _, id := range taskIDs {
+		t, err := s.MemoryStore.GetStoredTask(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if t == nil {
+			_, err = s.db.PurgeTask(ctx, id)
+		} else {
+			err = s.db.CopyTask(ctx, *t)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// demoWorkflows passes renamed statuses on to the tasks in memory, the database renames its own.
+type demoWorkflows struct {
+	*workflow.Store
+	tasks *task.MemoryStore
+}
+
+func (s demoWorkflows) UpdateStatus(ctx context.Context, userID int, name string, status types.TaskStatus) error {
+	if err := s.Store.UpdateStatus(ctx, userID, name, status); err != nil || status.Name == name {
+		return err
+	}
+
+	return s.tasks.RenameStatus(context.WithoutCancel(ctx), userID, name, status.Name)
+}
+
+// demoFields drops the values of deleted custom fields from the tasks in memory too.
+type demoFields struct {
+	*customfield.Store
+	tasks *task.MemoryStore
+}
+
+func (s demoFields) DeleteCustomField(ctx context.Context, userID int, key string) (int64, error) {
+	n, err := s.Store.DeleteCustomField(ctx, userID, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return n, s.tasks.RemoveCustomField(context.WithoutCancel(ctx), userID, key)
+}
+
+// demoAssignments passes assignees and watchers on to the tasks in memory, which report them.
+type demoAssignments struct {
+	*assignment.Store
+	tasks *task.MemoryStore
+}
+
+func (s demoAssignments) AddAssignee(ctx context.Context, taskID, userID int) error {
+	if err := s.Store.AddAssignee(ctx, taskID, userID); err != nil {
+		return err
+	}
+
+	return s.tasks.SetAssigned(context.WithoutCancel(ctx), taskID, userID, true)
+}
+
+func (s demoAssignments) RemoveAssignee(ctx context.Context, taskID, userID int) (int64, error) {
+	n, err := s.Store.RemoveAssignee(ctx, taskID, userID)
+	if err != nil || n == 0 {
+		return n, err
+	}
+
+	return n, s.tasks.SetAssigned(context.WithoutCancel(ctx), taskID, userID, false)
+}
+
+func (s demoAssignments) SetWatcher(ctx context.Context, watcher types.Watcher) error {
+	if err := s.Store.SetWatcher(ctx, watcher); err != nil {
+		return err
+	}
+
+	return s.tasks.SetWatching(context.WithoutCancel(ctx), watcher.TaskID, watcher.UserID, true)
+}
+
+func (s demoAssignments) RemoveWatcher(ctx context.Context, taskID, userID int) (int64, error) {
+	n, err := s.Store.RemoveWatcher(ctx, taskID, userID)
+	if err != nil || n == 0 {
+		return n, err
+	}
+
+	return n, s.tasks.SetWatching(context.WithoutCancel(ctx), taskID, userID, false)
+}
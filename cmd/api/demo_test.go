@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"todo/services/assignment"
+	"todo/services/board"
+	"todo/services/customfield"
+	"todo/services/storetest"
+	"todo/services/task"
+	"todo/services/workflow"
+	"todo/services/workspace"
+	"todo/types"
+)
+
+func TestDemoTasks(t *testing.T) {
+	storetest.TestTaskStore(t, func(t *testing.T) storetest.TaskFixture {
+		db := storetest.SQLite(t)
+		userIDs, workspaceIDs := storetest.Seed(t, db)
+		limits := board.NewStore(db)
+
+		memory := task.NewMemoryStore()
+		memory.UseWIPLimits(limits)
+
+		return storetest.TaskFixture{Store: newDemoTasks(memory, task.NewStore(db)), Limits: limits, UserIDs: userIDs, WorkspaceIDs: workspaceIDs}
+	})
+}
+
+func TestDemoMirror(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.SQLite(t)
+	userIDs, workspaceIDs := storetest.Seed(t, db)
+	owner, other := userIDs[0], userIDs[1]
+
+	sqlTasks := task.NewStore(db)
+	memory := task.NewMemoryStore()
+	store := newDemoTasks(memory, sqlTasks)
+
+	workflows := demoWorkflows{Store: workflow.NewStore(db), tasks: memory}
+	fields := demoFields{Store: customfield.NewStore(db), tasks: memory}
+	assignments := demoAssignments{Store: assignment.NewStore(db), tasks: memory}
+
+	// same fails unless the database holds the task as the memory does
+	same := func(taskID int) *types.Task {
+		t.Helper()
+
+		want, err := store.GetTaskByID(ctx, taskID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := sqlTasks.GetTaskByID(ctx, taskID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// timestamps the database did not set itself come back in another location
+		if !got.CreatedAt.Equal(want.CreatedAt) {
+			t.Errorf("created at %v in the database, %v in memory", got.CreatedAt, want.CreatedAt)
+		}
+		got.CreatedAt, got.UpdatedAt = want.CreatedAt, want.UpdatedAt
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("database holds %+v, memory %+v", got, want)
+		}
+
+		return want
+	}
+
+	description := ""
+	created, err := store.CreateTask(ctx, types.CreateTaskPayload{
+		UserID: &owner, Title: "task", Description: &description, Priority: 2, CreatorID: owner, WorkspaceID: workspaceIDs[0],
+		CustomFields: map[string]any{"points": 3}, AssigneeIDs: []int{other}, Labels: []string{"a"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	same(created.ID)
+
+	if n, err := workspace.NewStore(db).CountWorkspaceTasks(ctx, workspaceIDs[0]); err != nil || n != 1 {
+		t.Errorf("CountWorkspaceTasks() = %d, %v, want 1", n, err)
+	}
+	if watchers, err := assignments.GetWatchers(ctx, created.ID); err != nil || len(watchers) != 2 {
+		t.Errorf("GetWatchers() = %+v, %v, want the creator and the assignee", watchers, err)
+	}
+
+	if _, err := store.MoveTask(ctx, created.ID, created.Version, "pending", "m"); err != nil {
+		t.Fatal(err)
+	}
+	if got := same(created.ID); got.Position != "m" {
+		t.Errorf("position after the move = %q, want m", got.Position)
+	}
+
+	if err := workflows.UpdateStatus(ctx, owner, "pending", types.TaskStatus{Name: "waiting", Category: workflow.CategoryTodo}); err != nil {
+		t.Fatal(err)
+	}
+	if got := same(created.ID); got.Status != "waiting" {
+		t.Errorf("status after the rename = %q, want waiting", got.Status)
+	}
+
+	if _, err := fields.CreateCustomField(ctx, types.CustomField{UserID: owner, Key: "points", Name: "Points", Type: customfield.TypeNumber}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fields.DeleteCustomField(ctx, owner, "points"); err != nil {
+		t.Fatal(err)
+	}
+	if got := same(created.ID); len(got.CustomFields) != 0 {
+		t.Errorf("custom fields after the deletion = %v, want none", got.CustomFields)
+	}
+
+	if _, err := assignments.RemoveAssignee(ctx, created.ID, other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := assignments.RemoveWatcher(ctx, created.ID, other); err != nil {
+		t.Fatal(err)
+	}
+	if got := same(created.ID); len(got.AssigneeIDs) != 0 || !slices.Equal(got.WatcherIDs, []int{owner}) {
+		t.Errorf("got assignees %v and watchers %v, want none and the creator", got.AssigneeIDs, got.WatcherIDs)
+	}
+	if err := assignments.AddAssignee(ctx, created.ID, other); err != nil {
+		t.Fatal(err)
+	}
+	if err := assignments.SetWatcher(ctx, types.Watcher{TaskID: created.ID, UserID: owner, Channel: "email", Target: "me@example.org"}); err != nil {
+		t.Fatal(err)
+	}
+	got := same(created.ID)
+	if !slices.Equal(got.AssigneeIDs, []int{other}) || len(got.WatcherIDs) != 2 {
+		t.Errorf("got assignees %v and watchers %v, want the assignee watching", got.AssigneeIDs, got.WatcherIDs)
+	}
+
+	// a copy of the task after another write keeps how the watchers are notified
+	if _, err := store.MoveTask(ctx, created.ID, got.Version, "waiting", "n"); err != nil {
+		t.Fatal(err)
+	}
+	watchers, err := assignments.GetWatchers(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range watchers {
+		if w.UserID == owner && w.Target != "me@example.org" {
+			t.Errorf("target of the owner = %q, the copy has to keep it", w.Target)
+		}
+	}
+
+	if _, err := store.DeleteTask(ctx, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sqlTasks.GetTaskByID(ctx, created.ID); !errors.Is(err, types.ErrTaskNotFound) {
+		t.Errorf("trashed task in the database: %v, want %v", err, types.ErrTaskNotFound)
+	}
+	if _, err := store.PurgeTask(ctx, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := sqlTasks.GetTaskWorkspaceID(ctx, created.ID); err != nil || id != 0 {
+		t.Errorf("purged task in the database: %d, %v", id, err)
+	}
+}
+
+func TestDemoCopyAfterCancel(t *testing.T) {
+	db := storetest.SQLite(t)
+	userIDs, workspaceIDs := storetest.Seed(t, db)
+	sqlTasks := task.NewStore(db)
+	store := newDemoTasks(task.NewMemoryStore(), sqlTasks)
+
+	description := ""
+	created, err := store.CreateTask(context.Background(), types.CreateTaskPayload{
+		UserID: &userIDs[0], Title: "task", Description: &description, Priority: 2, WorkspaceID: workspaceIDs[0],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the caller went away between the write and the copy
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err = store.copied(ctx, created.ID)(func() (int64, error) {
+		n, err := store.MemoryStore.MoveTask(ctx, created.ID, created.Version, "done", "n")
+		cancel()
+		return n, err
+	}())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := sqlTasks.GetTaskByID(context.Background(), created.ID); err != nil || got.Status != "done" {
+		t.Errorf("database holds %+v, %v, want the moved task", got, err)
+	}
+}
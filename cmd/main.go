@@ -2,34 +2,39 @@ package main
 
 import (
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"todo/cmd/api"
 	"todo/configs"
 	"todo/db"
-
-	"github.com/go-sql-driver/mysql"
 )
 
 func main() {
-	cfg := mysql.Config{
-		User:                 configs.Envs.DBUser,
-		Passwd:               configs.Envs.DBPassword,
-		Addr:                 configs.Envs.DBAddress,
-		DBName:               configs.Envs.DBName,
-		Net:                  "tcp",
-		AllowNativePasswords: true,
-		ParseTime:            true,
+	store := flag.String("store", "sql", "where users and tasks are kept, sql or memory (lost on exit)")
+	flag.Parse()
+
+	var database *sql.DB
+	var err error
+	switch *store {
+	case "sql":
+		database, err = db.NewStorage(configs.Envs)
+	case "memory":
+		// the other features still need tables, they get a throwaway sqlite database
+		database, err = db.NewDemoStorage()
+	default:
+		err = fmt.Errorf("unknown store %q, use sql or memory", *store)
 	}
-
-	db, err := db.NewMySQLStorage(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	initStorage(db)
+	initStorage(database)
 
-	server := api.NewAPIServer(fmt.Sprintf(":%s", configs.Envs.Port), db)
+	server := api.NewAPIServer(fmt.Sprintf(":%s", configs.Envs.Port), database)
+	if *store == "memory" {
+		server.WithMemoryStores()
+	}
 	if err := server.Run(); err != nil {
 		log.Fatal(err)
 	}
@@ -1,42 +1,37 @@
 package main
 
 import (
+	"database/sql"
 	"log"
 	"os"
 	"todo/configs"
 	"todo/db"
+	"todo/db/dialect"
 
-	// _ "github.com/go-sql-driver/mysql" // mysql driver
-	mysqlDriver "github.com/go-sql-driver/mysql"
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	mysqlMigrate "github.com/golang-migrate/migrate/v4/database/mysql"
+	postgresMigrate "github.com/golang-migrate/migrate/v4/database/postgres"
+	sqliteMigrate "github.com/golang-migrate/migrate/v4/database/sqlite"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
 func main() {
-	cfg := mysqlDriver.Config{
-		User:                 configs.Envs.DBUser,
-		Passwd:               configs.Envs.DBPassword,
-		Addr:                 configs.Envs.DBAddress,
-		DBName:               configs.Envs.DBName,
-		Net:                  "tcp",
-		AllowNativePasswords: true,
-		ParseTime:            true,
-	}
-
-	db, err := db.NewMySQLStorage(cfg)
+	db, err := db.NewStorage(configs.Envs)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	driver, err := mysqlMigrate.WithInstance(db, &mysqlMigrate.Config{})
+	name := dialect.Of(db)
+	driver, err := migrationDriver(name, db)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// every dialect has its own migrations, a change to the schema is added to each directory
 	m, err := migrate.NewWithDatabaseInstance(
-		"file://cmd/migrate/migrations",
-		"mysql",
+		"file://cmd/migrate/migrations/"+string(name),
+		string(name),
 		driver,
 	)
 	if err != nil {
@@ -59,3 +54,14 @@ func main() {
 	}
 
 }
+
+func migrationDriver(name dialect.Dialect, db *sql.DB) (database.Driver, error) {
+	switch name {
+	case dialect.Postgres:
+		return postgresMigrate.WithInstance(db, &postgresMigrate.Config{})
+	case dialect.SQLite:
+		return sqliteMigrate.WithInstance(db, &sqliteMigrate.Config{})
+	}
+
+	return mysqlMigrate.WithInstance(db, &mysqlMigrate.Config{})
+}
@@ -0,0 +1,8 @@
+// Package migrations embeds the schema migrations, one directory per dialect, so that
+// the server and the tests can create a database without the migrate CLI.
+package migrations
+
+import "embed"
+
+//go:embed mysql postgres sqlite
+var FS embed.FS
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS task_reminders (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `task_id` INT UNSIGNED NOT NULL,
+  `user_id` INT UNSIGNED NOT NULL,
+  `remind_at` DATETIME DEFAULT NULL,
+  `offset_minutes` INT UNSIGNED DEFAULT NULL,
+  `channel` ENUM('email', 'webhook', 'in_app') NOT NULL,
+  `target` VARCHAR(2048) NOT NULL DEFAULT '',
+  `status` ENUM('pending', 'sent', 'failed') NOT NULL DEFAULT 'pending',
+  `attempts` INT UNSIGNED NOT NULL DEFAULT 0,
+  `last_error` TEXT DEFAULT NULL,
+  `sent_at` DATETIME DEFAULT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY (`status`, `remind_at`),
+  FOREIGN KEY (`task_id`) REFERENCES tasks(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`user_id`) REFERENCES users(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS notifications (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `user_id` INT UNSIGNED NOT NULL,
+  `task_id` INT UNSIGNED DEFAULT NULL,
+  `message` TEXT NOT NULL,
+  `read_at` DATETIME DEFAULT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY (`user_id`, `read_at`),
+  FOREIGN KEY (`user_id`) REFERENCES users(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`task_id`) REFERENCES tasks(`id`) ON DELETE SET NULL
+);
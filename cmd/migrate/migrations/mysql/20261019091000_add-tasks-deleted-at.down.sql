@@ -0,0 +1,3 @@
+ALTER TABLE tasks
+  DROP KEY `deleted_at`,
+  DROP COLUMN `deleted_at`;
@@ -0,0 +1,3 @@
+ALTER TABLE tasks
+  ADD COLUMN `deleted_at` DATETIME DEFAULT NULL,
+  ADD KEY (`deleted_at`);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS task_events (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `task_id` INT UNSIGNED NOT NULL,
+  `actor_id` INT UNSIGNED DEFAULT NULL,
+  `action` ENUM('create', 'update', 'delete', 'restore', 'revert') NOT NULL,
+  `changes` JSON NOT NULL,
+  `snapshot` JSON NOT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY (`task_id`, `created_at`),
+  FOREIGN KEY (`task_id`) REFERENCES tasks(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`actor_id`) REFERENCES users(`id`) ON DELETE SET NULL
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS task_comments (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `task_id` INT UNSIGNED NOT NULL,
+  `author_id` INT UNSIGNED DEFAULT NULL,
+  `body` TEXT NOT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  `deleted_at` DATETIME DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY (`task_id`, `deleted_at`),
+  FOREIGN KEY (`task_id`) REFERENCES tasks(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`author_id`) REFERENCES users(`id`) ON DELETE SET NULL
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS task_comment_revisions (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `comment_id` INT UNSIGNED NOT NULL,
+  `editor_id` INT UNSIGNED DEFAULT NULL,
+  `body` TEXT NOT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  FOREIGN KEY (`comment_id`) REFERENCES task_comments(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`editor_id`) REFERENCES users(`id`) ON DELETE SET NULL
+);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS task_attachments (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `task_id` INT UNSIGNED NOT NULL,
+  `uploader_id` INT UNSIGNED DEFAULT NULL,
+  `file_name` VARCHAR(255) NOT NULL,
+  `content_type` VARCHAR(255) NOT NULL,
+  `size` BIGINT UNSIGNED NOT NULL,
+  `storage_key` VARCHAR(512) NOT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY (`storage_key`),
+  FOREIGN KEY (`task_id`) REFERENCES tasks(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`uploader_id`) REFERENCES users(`id`) ON DELETE SET NULL
+);
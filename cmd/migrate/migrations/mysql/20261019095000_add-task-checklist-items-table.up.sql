@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS task_checklist_items (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `task_id` INT UNSIGNED NOT NULL,
+  `text` VARCHAR(500) NOT NULL,
+  `done` BOOLEAN NOT NULL DEFAULT FALSE,
+  `position` INT UNSIGNED NOT NULL DEFAULT 0,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY (`task_id`, `position`),
+  FOREIGN KEY (`task_id`) REFERENCES tasks(`id`) ON DELETE CASCADE
+);
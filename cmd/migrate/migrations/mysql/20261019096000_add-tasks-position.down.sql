@@ -0,0 +1,3 @@
+ALTER TABLE tasks
+  DROP KEY `board_column`,
+  DROP COLUMN `position`;
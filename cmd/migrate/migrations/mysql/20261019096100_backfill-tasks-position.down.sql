@@ -0,0 +1 @@
+UPDATE tasks SET `position` = '';
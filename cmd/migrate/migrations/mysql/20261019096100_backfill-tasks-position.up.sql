@@ -0,0 +1,2 @@
+-- fixed width base 36 ids keep the creation order, the trailing V avoids keys ending in the zero digit
+UPDATE tasks SET `position` = CONCAT(LPAD(CONV(`id`, 10, 36), 8, '0'), 'V') WHERE `position` = '';
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS board_wip_limits (
+  `user_id` INT UNSIGNED NOT NULL,
+  `status` ENUM('pending', 'in_progress', 'completed') NOT NULL,
+  `max_tasks` INT UNSIGNED NOT NULL,
+  `mode` ENUM('reject', 'warn') NOT NULL DEFAULT 'reject',
+  PRIMARY KEY (`user_id`, `status`),
+  FOREIGN KEY (`user_id`) REFERENCES users(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS task_statuses (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `user_id` INT UNSIGNED DEFAULT NULL,
+  `name` VARCHAR(64) NOT NULL,
+  `category` ENUM('todo', 'doing', 'done') NOT NULL,
+  `color` CHAR(7) NOT NULL DEFAULT '#9e9e9e',
+  `sort_order` INT NOT NULL DEFAULT 0,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY (`user_id`, `name`),
+  FOREIGN KEY (`user_id`) REFERENCES users(`id`) ON DELETE CASCADE
+);
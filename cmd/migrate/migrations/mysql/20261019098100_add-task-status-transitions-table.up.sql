@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS task_status_transitions (
+  `from_status_id` INT UNSIGNED NOT NULL,
+  `to_status_id` INT UNSIGNED NOT NULL,
+  PRIMARY KEY (`from_status_id`, `to_status_id`),
+  FOREIGN KEY (`from_status_id`) REFERENCES task_statuses(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`to_status_id`) REFERENCES task_statuses(`id`) ON DELETE CASCADE
+);
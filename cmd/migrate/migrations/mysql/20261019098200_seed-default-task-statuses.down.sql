@@ -0,0 +1 @@
+DELETE FROM task_statuses WHERE `user_id` IS NULL AND `name` IN ('pending', 'in_progress', 'completed');
@@ -0,0 +1,5 @@
+-- the former ENUM values become the default workflow shared by every user without a custom one
+INSERT INTO task_statuses (`user_id`, `name`, `category`, `color`, `sort_order`) VALUES
+  (NULL, 'pending', 'todo', '#9e9e9e', 0),
+  (NULL, 'in_progress', 'doing', '#2196f3', 1),
+  (NULL, 'completed', 'done', '#4caf50', 2);
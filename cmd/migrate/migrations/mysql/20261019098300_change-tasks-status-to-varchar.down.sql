@@ -0,0 +1 @@
+ALTER TABLE tasks MODIFY `status` ENUM('pending', 'in_progress', 'completed') NOT NULL DEFAULT 'pending';
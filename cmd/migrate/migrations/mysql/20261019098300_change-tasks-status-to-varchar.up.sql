@@ -0,0 +1 @@
+ALTER TABLE tasks MODIFY `status` VARCHAR(64) NOT NULL DEFAULT 'pending';
@@ -0,0 +1 @@
+ALTER TABLE board_wip_limits MODIFY `status` ENUM('pending', 'in_progress', 'completed') NOT NULL;
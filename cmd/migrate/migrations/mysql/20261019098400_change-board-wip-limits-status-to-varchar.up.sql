@@ -0,0 +1 @@
+ALTER TABLE board_wip_limits MODIFY `status` VARCHAR(64) NOT NULL;
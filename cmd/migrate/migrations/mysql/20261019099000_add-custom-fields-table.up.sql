@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS custom_fields (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `user_id` INT UNSIGNED NOT NULL,
+  `field_key` VARCHAR(64) NOT NULL,
+  `name` VARCHAR(255) NOT NULL,
+  `type` ENUM('text', 'number', 'date', 'select', 'multi_select', 'checkbox') NOT NULL,
+  `options` JSON DEFAULT NULL,
+  `required` BOOLEAN NOT NULL DEFAULT FALSE,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY (`user_id`, `field_key`),
+  FOREIGN KEY (`user_id`) REFERENCES users(`id`) ON DELETE CASCADE
+);
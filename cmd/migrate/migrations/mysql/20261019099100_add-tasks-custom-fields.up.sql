@@ -0,0 +1 @@
+ALTER TABLE tasks ADD COLUMN `custom_fields` JSON DEFAULT NULL;
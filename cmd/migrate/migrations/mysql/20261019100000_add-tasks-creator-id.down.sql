@@ -0,0 +1 @@
+ALTER TABLE tasks DROP FOREIGN KEY `fk_tasks_creator_id`, DROP COLUMN `creator_id`;
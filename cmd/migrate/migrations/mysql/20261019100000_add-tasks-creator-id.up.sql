@@ -0,0 +1,3 @@
+ALTER TABLE tasks
+  ADD COLUMN `creator_id` INT UNSIGNED DEFAULT NULL,
+  ADD CONSTRAINT `fk_tasks_creator_id` FOREIGN KEY (`creator_id`) REFERENCES users(`id`) ON DELETE SET NULL;
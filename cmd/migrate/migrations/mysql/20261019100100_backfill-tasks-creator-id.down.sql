@@ -0,0 +1 @@
+UPDATE tasks SET creator_id = NULL;
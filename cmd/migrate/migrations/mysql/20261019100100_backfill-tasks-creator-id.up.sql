@@ -0,0 +1,4 @@
+UPDATE tasks t
+JOIN task_events e ON e.task_id = t.id AND e.action = 'create'
+SET t.creator_id = e.actor_id
+WHERE t.creator_id IS NULL;
@@ -0,0 +1,2 @@
+INSERT IGNORE INTO task_assignees (task_id, user_id)
+SELECT id, user_id FROM tasks WHERE user_id IS NOT NULL;
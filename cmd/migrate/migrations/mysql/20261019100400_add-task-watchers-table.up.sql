@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS task_watchers (
+  `task_id` INT UNSIGNED NOT NULL,
+  `user_id` INT UNSIGNED NOT NULL,
+  `channel` ENUM('email', 'webhook', 'in_app') NOT NULL DEFAULT 'in_app',
+  `target` VARCHAR(2048) NOT NULL DEFAULT '',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`task_id`, `user_id`),
+  KEY (`user_id`),
+  FOREIGN KEY (`task_id`) REFERENCES tasks(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`user_id`) REFERENCES users(`id`) ON DELETE CASCADE
+);
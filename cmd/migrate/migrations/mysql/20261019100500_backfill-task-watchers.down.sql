@@ -0,0 +1 @@
+DELETE FROM task_watchers;
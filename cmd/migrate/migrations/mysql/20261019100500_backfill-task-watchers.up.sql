@@ -0,0 +1,2 @@
+INSERT IGNORE INTO task_watchers (task_id, user_id)
+SELECT task_id, user_id FROM task_assignees;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS workspace_members (
+  `workspace_id` INT UNSIGNED NOT NULL,
+  `user_id` INT UNSIGNED NOT NULL,
+  `role` ENUM('owner', 'admin', 'member', 'guest') NOT NULL DEFAULT 'member',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`workspace_id`, `user_id`),
+  KEY (`user_id`),
+  FOREIGN KEY (`workspace_id`) REFERENCES workspaces(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`user_id`) REFERENCES users(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS workspace_invitations (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` INT UNSIGNED NOT NULL,
+  `email` VARCHAR(255) NOT NULL,
+  `role` ENUM('admin', 'member', 'guest') NOT NULL DEFAULT 'member',
+  `token_hash` CHAR(64) NOT NULL,
+  `invited_by` INT UNSIGNED DEFAULT NULL,
+  `expires_at` DATETIME NOT NULL,
+  `accepted_at` DATETIME DEFAULT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY (`token_hash`),
+  FOREIGN KEY (`workspace_id`) REFERENCES workspaces(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`invited_by`) REFERENCES users(`id`) ON DELETE SET NULL
+);
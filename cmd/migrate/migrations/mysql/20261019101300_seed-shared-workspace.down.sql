@@ -0,0 +1 @@
+DELETE FROM workspaces WHERE id = 1;
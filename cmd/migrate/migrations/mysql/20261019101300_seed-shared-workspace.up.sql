@@ -0,0 +1,3 @@
+INSERT INTO workspaces (`id`, `name`)
+SELECT 1, 'Shared' FROM DUAL
+WHERE EXISTS (SELECT 1 FROM users) OR EXISTS (SELECT 1 FROM tasks);
@@ -0,0 +1 @@
+DELETE FROM workspace_members WHERE workspace_id = 1;
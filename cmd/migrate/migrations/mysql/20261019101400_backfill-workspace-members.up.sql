@@ -0,0 +1,5 @@
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT 1, u.id, IF(u.id = m.first_id, 'owner', 'member')
+FROM users u
+JOIN (SELECT MIN(id) AS first_id FROM users) m
+WHERE EXISTS (SELECT 1 FROM workspaces WHERE id = 1);
@@ -0,0 +1 @@
+ALTER TABLE tasks DROP FOREIGN KEY `fk_tasks_workspace_id`, DROP COLUMN `workspace_id`;
@@ -0,0 +1,3 @@
+ALTER TABLE tasks
+  ADD COLUMN `workspace_id` INT UNSIGNED DEFAULT NULL,
+  ADD CONSTRAINT `fk_tasks_workspace_id` FOREIGN KEY (`workspace_id`) REFERENCES workspaces(`id`);
@@ -0,0 +1 @@
+UPDATE tasks SET workspace_id = NULL;
@@ -0,0 +1 @@
+UPDATE tasks SET workspace_id = 1 WHERE workspace_id IS NULL;
@@ -0,0 +1 @@
+ALTER TABLE tasks MODIFY `workspace_id` INT UNSIGNED DEFAULT NULL;
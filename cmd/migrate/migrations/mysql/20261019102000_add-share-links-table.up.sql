@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS share_links (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `task_id` INT UNSIGNED NOT NULL,
+  `permission` ENUM('read', 'comment') NOT NULL DEFAULT 'read',
+  `password_hash` VARCHAR(255) DEFAULT NULL,
+  `expires_at` DATETIME DEFAULT NULL,
+  `revoked_at` DATETIME DEFAULT NULL,
+  `created_by` INT UNSIGNED DEFAULT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY (`task_id`),
+  FOREIGN KEY (`task_id`) REFERENCES tasks(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`created_by`) REFERENCES users(`id`) ON DELETE SET NULL
+);
@@ -0,0 +1 @@
+ALTER TABLE task_comments DROP COLUMN `guest_name`;
@@ -0,0 +1 @@
+ALTER TABLE task_comments ADD COLUMN `guest_name` VARCHAR(100) DEFAULT NULL AFTER `author_id`;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS task_labels (
+  `task_id` INT UNSIGNED NOT NULL,
+  `label` VARCHAR(50) NOT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`task_id`, `label`),
+  KEY (`label`),
+  FOREIGN KEY (`task_id`) REFERENCES tasks(`id`) ON DELETE CASCADE
+);
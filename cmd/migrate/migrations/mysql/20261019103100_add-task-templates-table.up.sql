@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS task_templates (
+  `id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+  `workspace_id` INT UNSIGNED NOT NULL,
+  `name` VARCHAR(255) NOT NULL,
+  `tasks` JSON NOT NULL,
+  `created_by` INT UNSIGNED DEFAULT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  FOREIGN KEY (`workspace_id`) REFERENCES workspaces(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`created_by`) REFERENCES users(`id`) ON DELETE SET NULL
+);
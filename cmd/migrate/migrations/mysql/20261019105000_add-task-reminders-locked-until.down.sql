@@ -0,0 +1,2 @@
+ALTER TABLE task_reminders
+  DROP COLUMN `locked_until`;
@@ -0,0 +1,2 @@
+ALTER TABLE task_reminders
+  ADD COLUMN `locked_until` DATETIME DEFAULT NULL;
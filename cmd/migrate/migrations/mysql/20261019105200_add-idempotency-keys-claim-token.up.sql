@@ -0,0 +1,2 @@
+ALTER TABLE idempotency_keys
+  ADD COLUMN `claim_token` CHAR(64) NOT NULL DEFAULT '';
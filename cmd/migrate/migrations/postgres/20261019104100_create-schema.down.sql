@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS idempotency_keys, task_templates, task_labels, share_links, workspace_invitations,
+  workspace_members, task_watchers, task_assignees, custom_fields, task_status_transitions, task_statuses,
+  board_wip_limits, task_checklist_items, task_attachments, task_comment_revisions, task_comments,
+  task_events, notifications, task_reminders, tasks, workspaces, users;
+
+DROP FUNCTION IF EXISTS set_updated_at();
@@ -0,0 +1 @@
+ALTER TABLE task_reminders DROP COLUMN locked_until;
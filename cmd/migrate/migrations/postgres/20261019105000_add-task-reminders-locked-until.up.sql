@@ -0,0 +1 @@
+ALTER TABLE task_reminders ADD COLUMN locked_until TIMESTAMP DEFAULT NULL;
@@ -0,0 +1,22 @@
+DROP TABLE IF EXISTS idempotency_keys;
+DROP TABLE IF EXISTS task_templates;
+DROP TABLE IF EXISTS task_labels;
+DROP TABLE IF EXISTS share_links;
+DROP TABLE IF EXISTS workspace_invitations;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS task_watchers;
+DROP TABLE IF EXISTS task_assignees;
+DROP TABLE IF EXISTS custom_fields;
+DROP TABLE IF EXISTS task_status_transitions;
+DROP TABLE IF EXISTS task_statuses;
+DROP TABLE IF EXISTS board_wip_limits;
+DROP TABLE IF EXISTS task_checklist_items;
+DROP TABLE IF EXISTS task_attachments;
+DROP TABLE IF EXISTS task_comment_revisions;
+DROP TABLE IF EXISTS task_comments;
+DROP TABLE IF EXISTS task_events;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS task_reminders;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS workspaces;
+DROP TABLE IF EXISTS users;
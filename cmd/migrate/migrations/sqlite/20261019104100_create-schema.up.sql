@@ -0,0 +1,259 @@
+-- the schema of the mysql migrations up to this version, later changes get a migration of their own
+-- times are stored as text in UTC and JSON as text, the triggers replace ON UPDATE CURRENT_TIMESTAMP
+
+CREATE TABLE IF NOT EXISTS users (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  firstName VARCHAR(255) NOT NULL,
+  lastName VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NOT NULL UNIQUE,
+  password VARCHAR(255) NOT NULL,
+  createdAt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS workspaces (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(255) NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS tasks (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL,
+  title VARCHAR(255) NOT NULL,
+  description TEXT NOT NULL,
+  status VARCHAR(64) NOT NULL DEFAULT 'pending',
+  priority SMALLINT NOT NULL CHECK (priority IN (1, 2, 3)),
+  due_date DATETIME DEFAULT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME DEFAULT NULL,
+  checklist_auto_complete BOOLEAN NOT NULL DEFAULT FALSE,
+  position VARCHAR(255) NOT NULL DEFAULT '',
+  custom_fields TEXT DEFAULT NULL,
+  creator_id INTEGER DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL,
+  workspace_id INTEGER NOT NULL REFERENCES workspaces(id),
+  version INTEGER NOT NULL DEFAULT 1
+);
+CREATE INDEX tasks_deleted_at ON tasks (deleted_at);
+CREATE INDEX board_column ON tasks (status, user_id, position);
+CREATE TRIGGER tasks_updated_at AFTER UPDATE ON tasks FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+  UPDATE tasks SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+CREATE TABLE IF NOT EXISTS task_reminders (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  remind_at DATETIME DEFAULT NULL,
+  offset_minutes INTEGER DEFAULT NULL,
+  channel VARCHAR(16) NOT NULL CHECK (channel IN ('email', 'webhook', 'in_app')),
+  target VARCHAR(2048) NOT NULL DEFAULT '',
+  status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT DEFAULT NULL,
+  sent_at DATETIME DEFAULT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX task_reminders_status_remind_at ON task_reminders (status, remind_at);
+
+CREATE TABLE IF NOT EXISTS notifications (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  task_id INTEGER DEFAULT NULL REFERENCES tasks(id) ON DELETE SET NULL,
+  message TEXT NOT NULL,
+  read_at DATETIME DEFAULT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX notifications_user_id_read_at ON notifications (user_id, read_at);
+
+CREATE TABLE IF NOT EXISTS task_events (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  actor_id INTEGER DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL,
+  action VARCHAR(16) NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore', 'revert')),
+  changes TEXT NOT NULL,
+  snapshot TEXT NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX task_events_task_id_created_at ON task_events (task_id, created_at);
+
+CREATE TABLE IF NOT EXISTS task_comments (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  author_id INTEGER DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL,
+  guest_name VARCHAR(100) DEFAULT NULL,
+  body TEXT NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at DATETIME DEFAULT NULL
+);
+CREATE INDEX task_comments_task_id_deleted_at ON task_comments (task_id, deleted_at);
+CREATE TRIGGER task_comments_updated_at AFTER UPDATE ON task_comments FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+  UPDATE task_comments SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+CREATE TABLE IF NOT EXISTS task_comment_revisions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  comment_id INTEGER NOT NULL REFERENCES task_comments(id) ON DELETE CASCADE,
+  editor_id INTEGER DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL,
+  body TEXT NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS task_attachments (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  uploader_id INTEGER DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL,
+  file_name VARCHAR(255) NOT NULL,
+  content_type VARCHAR(255) NOT NULL,
+  size BIGINT NOT NULL,
+  storage_key VARCHAR(512) NOT NULL UNIQUE,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS task_checklist_items (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  text VARCHAR(500) NOT NULL,
+  done BOOLEAN NOT NULL DEFAULT FALSE,
+  position INTEGER NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX task_checklist_items_task_id_position ON task_checklist_items (task_id, position);
+CREATE TRIGGER task_checklist_items_updated_at AFTER UPDATE ON task_checklist_items FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+  UPDATE task_checklist_items SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+CREATE TABLE IF NOT EXISTS board_wip_limits (
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  status VARCHAR(64) NOT NULL,
+  max_tasks INTEGER NOT NULL,
+  mode VARCHAR(16) NOT NULL DEFAULT 'reject' CHECK (mode IN ('reject', 'warn')),
+  PRIMARY KEY (user_id, status)
+);
+
+CREATE TABLE IF NOT EXISTS task_statuses (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER DEFAULT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name VARCHAR(64) NOT NULL,
+  category VARCHAR(16) NOT NULL CHECK (category IN ('todo', 'doing', 'done')),
+  color CHAR(7) NOT NULL DEFAULT '#9e9e9e',
+  sort_order INTEGER NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS task_status_transitions (
+  from_status_id INTEGER NOT NULL REFERENCES task_statuses(id) ON DELETE CASCADE,
+  to_status_id INTEGER NOT NULL REFERENCES task_statuses(id) ON DELETE CASCADE,
+  PRIMARY KEY (from_status_id, to_status_id)
+);
+
+-- the default workflow shared by every user without a custom one
+INSERT INTO task_statuses (user_id, name, category, color, sort_order) VALUES
+  (NULL, 'pending', 'todo', '#9e9e9e', 0),
+  (NULL, 'in_progress', 'doing', '#2196f3', 1),
+  (NULL, 'completed', 'done', '#4caf50', 2);
+
+CREATE TABLE IF NOT EXISTS custom_fields (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  field_key VARCHAR(64) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  type VARCHAR(16) NOT NULL CHECK (type IN ('text', 'number', 'date', 'select', 'multi_select', 'checkbox')),
+  options TEXT DEFAULT NULL,
+  required BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (user_id, field_key)
+);
+
+CREATE TABLE IF NOT EXISTS task_assignees (
+  task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (task_id, user_id)
+);
+CREATE INDEX task_assignees_user_id ON task_assignees (user_id);
+
+CREATE TABLE IF NOT EXISTS task_watchers (
+  task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  channel VARCHAR(16) NOT NULL DEFAULT 'in_app' CHECK (channel IN ('email', 'webhook', 'in_app')),
+  target VARCHAR(2048) NOT NULL DEFAULT '',
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (task_id, user_id)
+);
+CREATE INDEX task_watchers_user_id ON task_watchers (user_id);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+  workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role VARCHAR(16) NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'admin', 'member', 'guest')),
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (workspace_id, user_id)
+);
+CREATE INDEX workspace_members_user_id ON workspace_members (user_id);
+
+CREATE TABLE IF NOT EXISTS workspace_invitations (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+  email VARCHAR(255) NOT NULL,
+  role VARCHAR(16) NOT NULL DEFAULT 'member' CHECK (role IN ('admin', 'member', 'guest')),
+  token_hash CHAR(64) NOT NULL UNIQUE,
+  invited_by INTEGER DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL,
+  expires_at DATETIME NOT NULL,
+  accepted_at DATETIME DEFAULT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS share_links (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  permission VARCHAR(16) NOT NULL DEFAULT 'read' CHECK (permission IN ('read', 'comment')),
+  password_hash VARCHAR(255) DEFAULT NULL,
+  expires_at DATETIME DEFAULT NULL,
+  revoked_at DATETIME DEFAULT NULL,
+  created_by INTEGER DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX share_links_task_id ON share_links (task_id);
+
+CREATE TABLE IF NOT EXISTS task_labels (
+  task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  label VARCHAR(50) NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (task_id, label)
+);
+CREATE INDEX task_labels_label ON task_labels (label);
+
+CREATE TABLE IF NOT EXISTS task_templates (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  tasks TEXT NOT NULL,
+  created_by INTEGER DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TRIGGER task_templates_updated_at AFTER UPDATE ON task_templates FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+  UPDATE task_templates SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL DEFAULT 0,
+  idempotency_key VARCHAR(255) NOT NULL,
+  request_hash CHAR(64) NOT NULL,
+  status_code SMALLINT NOT NULL DEFAULT 0,
+  headers TEXT DEFAULT NULL,
+  body BLOB,
+  expires_at DATETIME NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (user_id, idempotency_key)
+);
+CREATE INDEX idempotency_keys_expires_at ON idempotency_keys (expires_at);
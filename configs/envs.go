@@ -5,17 +5,47 @@ import (
 	"github.com/joho/godotenv"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
-	PublicHost             string
-	Port                   string
-	DBUser                 string
-	DBPassword             string
-	DBAddress              string
-	DBName                 string
-	JWTSecret              string
-	JWTExpirationInSeconds int64
+	PublicHost               string
+	Port                     string
+	DBDriver                 string
+	DBUser                   string
+	DBPassword               string
+	DBAddress                string
+	DBName                   string
+	DBSSLMode                string
+	DBPath                   string
+	JWTSecret                string
+	JWTExpirationInSeconds   int64
+	SMTPHost                 string
+	SMTPPort                 string
+	SMTPUser                 string
+	SMTPPassword             string
+	SMTPFrom                 string
+	ReminderPollInSeconds    int64
+	WebhookTimeoutInSeconds  int64
+	TrashRetentionInDays     int64
+	TrashPurgeInSeconds      int64
+	RebalanceInSeconds       int64
+	BlobStore                string
+	BlobLocalDir             string
+	S3Endpoint               string
+	S3AccessKey              string
+	S3SecretKey              string
+	S3Bucket                 string
+	S3Region                 string
+	S3UseSSL                 bool
+	AttachmentMaxBytes       int64
+	AttachmentAllowedTypes   []string
+	InvitationTTLInHours     int64
+	IdempotencyTTLInHours    int64
+	IdempotencyLockInSeconds int64
+	RequestMaxBytes          int64
+	RequestTimeoutInSeconds  int64
+	DBQueryTimeoutInSeconds  int64
 }
 
 var Envs = initConfig()
@@ -24,14 +54,47 @@ func initConfig() Config {
 	godotenv.Load()
 
 	return Config{
-		PublicHost:             getEnv("PUBLIC_HOST", "http://localhost"),
-		Port:                   getEnv("PORT", "8080"),
-		DBUser:                 getEnv("DB_USER", "root"),
-		DBPassword:             getEnv("DB_PASSWORD", "mypassword"),
-		DBAddress:              fmt.Sprintf("%s:%s", getEnv("DB_HOST", "127.0.0.1"), getEnv("DB_PORT", "3306")),
-		DBName:                 getEnv("DB_NAME", "ecom"),
-		JWTSecret:              getEnv("JWT_SECRET", "secretkey"),
-		JWTExpirationInSeconds: getEnvAsInt("JWT_EXPIRATION_IN_SECONDS", 3600*24*7),
+		PublicHost:              getEnv("PUBLIC_HOST", "http://localhost"),
+		Port:                    getEnv("PORT", "8080"),
+		DBDriver:                getEnv("DB_DRIVER", "mysql"),
+		DBUser:                  getEnv("DB_USER", "root"),
+		DBPassword:              getEnv("DB_PASSWORD", "mypassword"),
+		DBAddress:               fmt.Sprintf("%s:%s", getEnv("DB_HOST", "127.0.0.1"), getEnv("DB_PORT", "3306")),
+		DBName:                  getEnv("DB_NAME", "ecom"),
+		DBSSLMode:               getEnv("DB_SSL_MODE", "disable"),
+		DBPath:                  getEnv("DB_PATH", "data/todo.db"),
+		JWTSecret:               getEnv("JWT_SECRET", "secretkey"),
+		JWTExpirationInSeconds:  getEnvAsInt("JWT_EXPIRATION_IN_SECONDS", 3600*24*7),
+		SMTPHost:                getEnv("SMTP_HOST", ""),
+		SMTPPort:                getEnv("SMTP_PORT", "587"),
+		SMTPUser:                getEnv("SMTP_USER", ""),
+		SMTPPassword:            getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:                getEnv("SMTP_FROM", "todolist@localhost"),
+		ReminderPollInSeconds:   getEnvAsInt("REMINDER_POLL_IN_SECONDS", 30),
+		WebhookTimeoutInSeconds: getEnvAsInt("WEBHOOK_TIMEOUT_IN_SECONDS", 10),
+		TrashRetentionInDays:    getEnvAsInt("TRASH_RETENTION_IN_DAYS", 30),
+		TrashPurgeInSeconds:     getEnvAsInt("TRASH_PURGE_IN_SECONDS", 3600),
+		RebalanceInSeconds:      getEnvAsInt("REBALANCE_IN_SECONDS", 600),
+		BlobStore:               getEnv("BLOB_STORE", "local"),
+		BlobLocalDir:            getEnv("BLOB_LOCAL_DIR", "data/attachments"),
+		S3Endpoint:              getEnv("S3_ENDPOINT", "127.0.0.1:9000"),
+		S3AccessKey:             getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:             getEnv("S3_SECRET_KEY", ""),
+		S3Bucket:                getEnv("S3_BUCKET", "todolist"),
+		S3Region:                getEnv("S3_REGION", "us-east-1"),
+		S3UseSSL:                getEnvAsBool("S3_USE_SSL", false),
+		AttachmentMaxBytes:      getEnvAsInt("ATTACHMENT_MAX_BYTES", 10<<20),
+		AttachmentAllowedTypes: getEnvAsList("ATTACHMENT_ALLOWED_TYPES", []string{
+			"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain",
+		}),
+		InvitationTTLInHours:     getEnvAsInt("INVITATION_TTL_IN_HOURS", 72),
+		IdempotencyTTLInHours:    getEnvAsInt("IDEMPOTENCY_TTL_IN_HOURS", 24),
+		IdempotencyLockInSeconds: getEnvAsInt("IDEMPOTENCY_LOCK_IN_SECONDS", 60),
+		RequestMaxBytes:          getEnvAsInt("REQUEST_MAX_BYTES", 1<<20),
+
+		// a store call, a transaction included, gets the query timeout within the request's
+		RequestTimeoutInSeconds: getEnvAsInt("REQUEST_TIMEOUT_IN_SECONDS", 30),
+		DBQueryTimeoutInSeconds: getEnvAsInt("DB_QUERY_TIMEOUT_IN_SECONDS", 5),
 	}
 }
 
@@ -56,3 +119,32 @@ func getEnvAsInt(key string, fallback int64) int64 {
 
 	return fallback
 }
+
+func getEnvAsBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fallback
+		}
+
+		return b
+	}
+
+	return fallback
+}
+
+// comma separated, blank entries are dropped
+func getEnvAsList(key string, fallback []string) []string {
+	if value, ok := os.LookupEnv(key); ok {
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+
+		return list
+	}
+
+	return fallback
+}
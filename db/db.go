@@ -2,11 +2,58 @@ package db
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"todo/cmd/migrate/migrations"
+	"todo/configs"
+	"todo/db/dialect"
 
 	"github.com/go-sql-driver/mysql"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	mysqlMigrate "github.com/golang-migrate/migrate/v4/database/mysql"
+	postgresMigrate "github.com/golang-migrate/migrate/v4/database/postgres"
+	sqliteMigrate "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
+// NewStorage opens the database selected by DB_DRIVER.
+func NewStorage(cfg configs.Config) (*sql.DB, error) {
+	driver, err := dialect.Parse(cfg.DBDriver)
+	if err != nil {
+		return nil, err
+	}
+
+	switch driver {
+	case dialect.Postgres:
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(cfg.DBUser, cfg.DBPassword),
+			Host:     cfg.DBAddress,
+			Path:     cfg.DBName,
+			RawQuery: url.Values{"sslmode": {cfg.DBSSLMode}}.Encode(),
+		}
+		return NewPostgresStorage(dsn.String())
+	case dialect.SQLite:
+		return NewSQLiteStorage(cfg.DBPath)
+	}
+
+	return NewMySQLStorage(mysql.Config{
+		User:                 cfg.DBUser,
+		Passwd:               cfg.DBPassword,
+		Addr:                 cfg.DBAddress,
+		DBName:               cfg.DBName,
+		Net:                  "tcp",
+		AllowNativePasswords: true,
+		ParseTime:            true,
+	})
+}
+
 func NewMySQLStorage(cfg mysql.Config) (*sql.DB, error) {
 	db, err := sql.Open("mysql", cfg.FormatDSN())
 	if err != nil {
@@ -15,3 +62,89 @@ func NewMySQLStorage(cfg mysql.Config) (*sql.DB, error) {
 
 	return db, nil
 }
+
+func NewPostgresStorage(dsn string) (*sql.DB, error) {
+	return sql.Open("postgres", dsn)
+}
+
+// NewSQLiteStorage opens the database file at path and creates it if needed. Foreign keys are
+// enforced and writers wait for each other instead of failing with SQLITE_BUSY.
+func NewSQLiteStorage(path string) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	return sql.Open("sqlite", sqliteDSN(path)+"&_pragma=foreign_keys(1)")
+}
+
+// NewDemoStorage opens a migrated SQLite database in a new temporary directory for the
+// in-memory store mode. Foreign keys are off, the rows refer to users and tasks that are
+// kept in memory.
+func NewDemoStorage() (*sql.DB, error) {
+	dir, err := os.MkdirTemp("", "todo-demo-")
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", sqliteDSN(filepath.Join(dir, "todo.db")))
+	if err != nil {
+		return nil, err
+	}
+
+	return db, MigrateSQLite(db)
+}
+
+// MigrateSQLite brings a SQLite database to the latest schema with the embedded migrations.
+func MigrateSQLite(db *sql.DB) error {
+	driver, err := sqliteMigrate.WithInstance(db, &sqliteMigrate.Config{})
+	if err != nil {
+		return err
+	}
+
+	return migrateUp(dialect.SQLite, driver)
+}
+
+// MigrateMySQL is MigrateSQLite for MySQL, the connection has to allow multiple statements.
+func MigrateMySQL(db *sql.DB) error {
+	driver, err := mysqlMigrate.WithInstance(db, &mysqlMigrate.Config{})
+	if err != nil {
+		return err
+	}
+
+	return migrateUp(dialect.MySQL, driver)
+}
+
+// MigratePostgres is MigrateSQLite for PostgreSQL.
+func MigratePostgres(db *sql.DB) error {
+	driver, err := postgresMigrate.WithInstance(db, &postgresMigrate.Config{})
+	if err != nil {
+		return err
+	}
+
+	return migrateUp(dialect.Postgres, driver)
+}
+
+func migrateUp(name dialect.Dialect, driver database.Driver) error {
+	source, err := iofs.New(migrations.FS, string(name))
+	if err != nil {
+		return err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, string(name), driver)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return err
+	}
+
+	return nil
+}
+
+// sqliteDSN starts every transaction with the write lock. A deferred transaction that reads
+// before it writes fails with SQLITE_BUSY_SNAPSHOT when another connection wrote in between,
+// busy_timeout cannot wait that out.
+func sqliteDSN(path string) string {
+	return fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite&_txlock=immediate", path)
+}
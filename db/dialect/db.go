@@ -0,0 +1,164 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"todo/configs"
+	"todo/errs"
+)
+
+// DB runs queries written with ? placeholders on any of the supported databases.
+// The Context variants report a context that ended with errs.FromContext.
+type DB struct {
+	*sql.DB
+	dialect Dialect
+	timeout time.Duration
+}
+
+// Tx is the transaction of a DB, it rebinds queries the same way.
+type Tx struct {
+	*sql.Tx
+	dialect Dialect
+}
+
+// Row is a *sql.Row whose Scan reports the end of its context like the other calls.
+type Row struct {
+	*sql.Row
+	ctx context.Context
+}
+
+func Wrap(db *sql.DB) *DB {
+	return &DB{
+		DB:      db,
+		dialect: Of(db),
+		timeout: time.Duration(configs.Envs.DBQueryTimeoutInSeconds) * time.Second,
+	}
+}
+
+func (db *DB) Dialect() Dialect {
+	return db.dialect
+}
+
+// Timeout bounds a store call by DB_QUERY_TIMEOUT_IN_SECONDS, a call that runs past it
+// fails with errs.ErrQueryTimeout. 0 leaves the call to the deadline of ctx.
+func (db *DB) Timeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeoutCause(ctx, db.timeout, errs.ErrQueryTimeout)
+}
+
+func (db *DB) Exec(query string, args ...any) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+func (db *DB) Query(query string, args ...any) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+func (db *DB) QueryRow(query string, args ...any) *Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	result, err := db.DB.ExecContext(ctx, db.dialect.Rebind(query), db.dialect.bindArgs(args)...)
+	return result, errs.FromContext(ctx, err)
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	rows, err := db.DB.QueryContext(ctx, db.dialect.Rebind(query), db.dialect.bindArgs(args)...)
+	return rows, errs.FromContext(ctx, err)
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+	return &Row{Row: db.DB.QueryRowContext(ctx, db.dialect.Rebind(query), db.dialect.bindArgs(args)...), ctx: ctx}
+}
+
+// Insert runs an INSERT and returns the id of the new row.
+func (db *DB) Insert(query string, args ...any) (int64, error) {
+	return db.InsertContext(context.Background(), query, args...)
+}
+
+func (db *DB) InsertContext(ctx context.Context, query string, args ...any) (int64, error) {
+	return insert(ctx, db, db.dialect, query, args)
+}
+
+func (db *DB) Begin() (*Tx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+// BeginTx starts a transaction that is rolled back when ctx is done.
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
+
+	return &Tx{Tx: tx, dialect: db.dialect}, nil
+}
+
+func (tx *Tx) Dialect() Dialect {
+	return tx.dialect
+}
+
+func (tx *Tx) Exec(query string, args ...any) (sql.Result, error) {
+	return tx.ExecContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) Query(query string, args ...any) (*sql.Rows, error) {
+	return tx.QueryContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) QueryRow(query string, args ...any) *Row {
+	return tx.QueryRowContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	result, err := tx.Tx.ExecContext(ctx, tx.dialect.Rebind(query), tx.dialect.bindArgs(args)...)
+	return result, errs.FromContext(ctx, err)
+}
+
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	rows, err := tx.Tx.QueryContext(ctx, tx.dialect.Rebind(query), tx.dialect.bindArgs(args)...)
+	return rows, errs.FromContext(ctx, err)
+}
+
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+	return &Row{Row: tx.Tx.QueryRowContext(ctx, tx.dialect.Rebind(query), tx.dialect.bindArgs(args)...), ctx: ctx}
+}
+
+// Insert runs an INSERT and returns the id of the new row.
+func (tx *Tx) Insert(query string, args ...any) (int64, error) {
+	return tx.InsertContext(context.Background(), query, args...)
+}
+
+func (tx *Tx) InsertContext(ctx context.Context, query string, args ...any) (int64, error) {
+	return insert(ctx, tx, tx.dialect, query, args)
+}
+
+func (r *Row) Scan(dest ...any) error {
+	return errs.FromContext(r.ctx, r.Row.Scan(dest...))
+}
+
+type execQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *Row
+}
+
+// insert reads the id with RETURNING on PostgreSQL, which has no LastInsertId.
+func insert(ctx context.Context, q execQuerier, d Dialect, query string, args []any) (int64, error) {
+	if d == Postgres {
+		var id int64
+		err := q.QueryRowContext(ctx, query+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
@@ -0,0 +1,196 @@
+// Package dialect lets the stores run on MySQL, PostgreSQL and SQLite. Queries are written
+// with ? placeholders and MySQL quoting, DB and Tx rebind them for the connected database
+// and Dialect builds the few clauses that have no common syntax.
+package dialect
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+)
+
+type Dialect string
+
+const (
+	MySQL    Dialect = "mysql"
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// Parse checks a configured driver name.
+func Parse(name string) (Dialect, error) {
+	switch d := Dialect(name); d {
+	case MySQL, Postgres, SQLite:
+		return d, nil
+	}
+
+	return "", fmt.Errorf("unknown database driver %q, use %s, %s or %s", name, MySQL, Postgres, SQLite)
+}
+
+// Of tells the dialect from the driver the connection was opened with.
+func Of(db *sql.DB) Dialect {
+	switch db.Driver().(type) {
+	case *pq.Driver:
+		return Postgres
+	case *sqlite.Driver:
+		return SQLite
+	}
+
+	return MySQL
+}
+
+// Rebind turns the ? placeholders into $1, $2, ... and backtick quoted identifiers into double
+// quoted ones for PostgreSQL. Question marks and backticks inside string literals are kept.
+func (d Dialect) Rebind(query string) string {
+	if d != Postgres || !strings.ContainsAny(query, "?`") {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	inString := false
+	for _, c := range query {
+		switch {
+		case c == '\'':
+			inString = !inString
+		case inString:
+		case c == '?':
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		case c == '`':
+			c = '"'
+		}
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
+
+// bindArgs stores times in UTC on SQLite, which compares them as text.
+func (d Dialect) bindArgs(args []any) []any {
+	if d != SQLite {
+		return args
+	}
+
+	bound := make([]any, len(args))
+	for i, arg := range args {
+		bound[i] = arg
+		switch t := arg.(type) {
+		case time.Time:
+			bound[i] = t.UTC()
+		case *time.Time:
+			if t != nil {
+				bound[i] = t.UTC()
+			}
+		}
+	}
+
+	return bound
+}
+
+// NullSafeEqual compares column with a placeholder, two NULLs are equal.
+func (d Dialect) NullSafeEqual(column string) string {
+	switch d {
+	case Postgres:
+		return column + " IS NOT DISTINCT FROM ?"
+	case SQLite:
+		return column + " IS ?"
+	}
+
+	return column + " <=> ?"
+}
+
+// InsertIgnore prefixes into, e.g. "INTO t (a, b) VALUES (?, ?)", so that rows that would
+// violate a unique key are skipped.
+func (d Dialect) InsertIgnore(into string) string {
+	switch d {
+	case Postgres:
+		return "INSERT " + into + " ON CONFLICT DO NOTHING"
+	case SQLite:
+		return "INSERT OR IGNORE " + into
+	}
+
+	return "INSERT IGNORE " + into
+}
+
+// Upsert adds to insert the clause that updates columns when a row with the same key exists.
+func (d Dialect) Upsert(insert string, key []string, columns ...string) string {
+	set := make([]string, len(columns))
+	for i, column := range columns {
+		if d == MySQL {
+			set[i] = fmt.Sprintf("%s = VALUES(%s)", column, column)
+		} else {
+			set[i] = fmt.Sprintf("%s = excluded.%s", column, column)
+		}
+	}
+
+	if d == MySQL {
+		return insert + " ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+	}
+
+	return insert + " ON CONFLICT (" + strings.Join(key, ", ") + ") DO UPDATE SET " + strings.Join(set, ", ")
+}
+
+// Lock returns a locking clause like "FOR UPDATE", SQLite locks the whole database on
+// write and has none.
+func (d Dialect) Lock(clause string) string {
+	if d == SQLite {
+		return ""
+	}
+
+	return " " + clause
+}
+
+// JSONArrayAgg aggregates expr into a JSON array. It is NULL for no rows except on SQLite,
+// where it is [].
+func (d Dialect) JSONArrayAgg(expr string) string {
+	switch d {
+	case Postgres:
+		return "json_agg(" + expr + ")"
+	case SQLite:
+		return "json_group_array(" + expr + ")"
+	}
+
+	return "JSON_ARRAYAGG(" + expr + ")"
+}
+
+// SecondsAgo is the current time less a number of seconds given by a placeholder.
+func (d Dialect) SecondsAgo() string {
+	switch d {
+	case Postgres:
+		return "NOW() - make_interval(secs => ?)"
+	case SQLite:
+		return "datetime('now', '-' || ? || ' seconds')"
+	}
+
+	return "NOW() - INTERVAL ? SECOND"
+}
+
+// SecondsFromNow is the current time plus a number of seconds given by a placeholder.
+func (d Dialect) SecondsFromNow() string {
+	switch d {
+	case Postgres:
+		return "NOW() + make_interval(secs => ?)"
+	case SQLite:
+		return "datetime('now', '+' || ? || ' seconds')"
+	}
+
+	return "NOW() + INTERVAL ? SECOND"
+}
+
+// MinusMinutes subtracts the minutes in the integer expression minutes from the time in expr.
+func (d Dialect) MinusMinutes(expr, minutes string) string {
+	switch d {
+	case Postgres:
+		return fmt.Sprintf("%s - %s * INTERVAL '1 minute'", expr, minutes)
+	case SQLite:
+		return fmt.Sprintf("datetime(%s, '-' || %s || ' minutes')", expr, minutes)
+	}
+
+	return fmt.Sprintf("%s - INTERVAL %s MINUTE", expr, minutes)
+}
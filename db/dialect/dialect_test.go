@@ -0,0 +1,62 @@
+package dialect
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		query   string
+		want    string
+	}{
+		{MySQL, "SELECT `key` FROM t WHERE a = ? AND b = ?", "SELECT `key` FROM t WHERE a = ? AND b = ?"},
+		{SQLite, "SELECT * FROM t WHERE a = ?", "SELECT * FROM t WHERE a = ?"},
+		{Postgres, "SELECT `key` FROM t WHERE a = ? AND b = ?", `SELECT "key" FROM t WHERE a = $1 AND b = $2`},
+		{Postgres, "SELECT '?`' FROM t WHERE a = ?", "SELECT '?`' FROM t WHERE a = $1"},
+		{Postgres, "SELECT 'it''s ?' WHERE a = ?", "SELECT 'it''s ?' WHERE a = $1"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dialect.Rebind(tt.query); got != tt.want {
+			t.Errorf("%s.Rebind(%q) = %q, want %q", tt.dialect, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestInsertIgnore(t *testing.T) {
+	into := "INTO t (a, b) VALUES (?, ?)"
+	tests := map[Dialect]string{
+		MySQL:    "INSERT IGNORE INTO t (a, b) VALUES (?, ?)",
+		Postgres: "INSERT INTO t (a, b) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		SQLite:   "INSERT OR IGNORE INTO t (a, b) VALUES (?, ?)",
+	}
+
+	for d, want := range tests {
+		if got := d.InsertIgnore(into); got != want {
+			t.Errorf("%s.InsertIgnore() = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	insert := "INSERT INTO t (a, b, c) VALUES (?, ?, ?)"
+	tests := map[Dialect]string{
+		MySQL:    insert + " ON DUPLICATE KEY UPDATE b = VALUES(b), c = VALUES(c)",
+		Postgres: insert + " ON CONFLICT (a) DO UPDATE SET b = excluded.b, c = excluded.c",
+		SQLite:   insert + " ON CONFLICT (a) DO UPDATE SET b = excluded.b, c = excluded.c",
+	}
+
+	for d, want := range tests {
+		if got := d.Upsert(insert, []string{"a"}, "b", "c"); got != want {
+			t.Errorf("%s.Upsert() = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	if d, err := Parse("postgres"); err != nil || d != Postgres {
+		t.Errorf("Parse(postgres) = %q, %v", d, err)
+	}
+	if _, err := Parse("oracle"); err == nil {
+		t.Error("Parse(oracle) should fail")
+	}
+}
@@ -0,0 +1,88 @@
+// Package errs holds the domain errors of the API. Each carries the HTTP status
+// and the stable code utils.WriteError puts into the problem details.
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrQueryTimeout and ErrRequestTimeout end store calls whose context is done. The first is the
+// cause of a call that ran past its own deadline, the second of a request that ran out of time,
+// a client that went away gets it too.
+var (
+	ErrQueryTimeout   = New(http.StatusGatewayTimeout, "query_timeout", "the database did not answer in time")
+	ErrRequestTimeout = New(http.StatusServiceUnavailable, "request_timeout", "the request took too long to handle")
+)
+
+type Error struct {
+	Status  int
+	Code    string // machine-readable, clients may rely on it
+	Message string
+	Fields  []FieldError // the invalid fields of a validation error
+}
+
+// FieldError describes one failed validation rule, Field is the JSON path of the value.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func NotFound(code, message string) *Error {
+	return New(http.StatusNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(http.StatusConflict, code, message)
+}
+
+func Validation(code, message string) *Error {
+	return New(http.StatusBadRequest, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(http.StatusForbidden, code, message)
+}
+
+// Errorf returns a copy of e with a more specific message, the status and code stay.
+func (e *Error) Errorf(format string, args ...any) *Error {
+	return New(e.Status, e.Code, fmt.Sprintf(format, args...))
+}
+
+// Is lets errors.Is match copies made with Errorf by their code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// FromContext returns err, or the cause of ctx once ctx is done. Causes that are no domain
+// error, like a canceled request, become ErrRequestTimeout.
+func FromContext(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+
+	var e *Error
+	if errors.As(context.Cause(ctx), &e) {
+		return e
+	}
+
+	return ErrRequestTimeout
+}
+
+// IsTimeout reports whether err ended a call that ran out of time, see FromContext.
+func IsTimeout(err error) bool {
+	return errors.Is(err, ErrQueryTimeout) || errors.Is(err, ErrRequestTimeout)
+}
@@ -0,0 +1,308 @@
+package assignment
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"todo/errs"
+	"todo/services/auth"
+	"todo/services/history"
+	"todo/services/notification"
+	"todo/services/workspace"
+	"todo/types"
+	"todo/utils"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	errNotAssigned    = errs.NotFound("assignee_not_found", "user is not assigned to this task")
+	errNotWatching    = errs.NotFound("watcher_not_found", "user is not watching this task")
+	errManageWatchers = errs.Forbidden("permission_denied", "only the task owner or creator can manage other watchers")
+	errWatcherTarget  = errs.Forbidden("permission_denied", "only the watcher can choose where their notifications are sent")
+)
+
+type Handler struct {
+	assignees      types.AssigneeStore
+	watchers       types.WatcherStore
+	taskStore      types.TaskStore
+	eventStore     types.TaskEventStore
+	userStore      types.UserStore
+	notifier       *notification.Notifier
+	workspaceStore types.WorkspaceStore
+}
+
+func NewHandler(assignees types.AssigneeStore, watchers types.WatcherStore, taskStore types.TaskStore, eventStore types.TaskEventStore, userStore types.UserStore, notifier *notification.Notifier, workspaceStore types.WorkspaceStore) *Handler {
+	return &Handler{assignees: assignees, watchers: watchers, taskStore: taskStore, eventStore: eventStore, userStore: userStore, notifier: notifier, workspaceStore: workspaceStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/tasks/{task_id}/assignees", auth.WithJWTAuth(workspace.WithWorkspace(h.handleGetAssignees, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{task_id}/assignees", auth.WithJWTAuth(workspace.WithWorkspace(h.handleAssign, h.workspaceStore), h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/{task_id}/assignees/{user_id}", auth.WithJWTAuth(workspace.WithWorkspace(h.handleUnassign, h.workspaceStore), h.userStore)).Methods(http.MethodDelete)
+	router.HandleFunc("/tasks/{task_id}/watchers", auth.WithJWTAuth(workspace.WithWorkspace(h.handleGetWatchers, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{task_id}/watchers", auth.WithJWTAuth(workspace.WithWorkspace(h.handleWatch, h.workspaceStore), h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/{task_id}/watchers/{user_id}", auth.WithJWTAuth(workspace.WithWorkspace(h.handleUnwatch, h.workspaceStore), h.userStore)).Methods(http.MethodDelete)
+}
+
+func (h *Handler) handleGetAssignees(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	users, err := h.assignees.GetAssignees(r.Context(), task.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get assignees: %v", err))
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, users)
+}
+
+func (h *Handler) handleAssign(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	var payload types.AssignPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	if !h.checkMember(w, r, task, payload.UserID) {
+		return
+	}
+
+	if err := h.assignees.AddAssignee(r.Context(), task.ID, payload.UserID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.recordEvent(r, task)
+
+	users, err := h.assignees.GetAssignees(r.Context(), task.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, users)
+}
+
+func (h *Handler) handleUnassign(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.Atoi(mux.Vars(r)["user_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid user ID"))
+		return
+	}
+
+	rowsAffected, err := h.assignees.RemoveAssignee(r.Context(), task.ID, userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if rowsAffected == 0 {
+		utils.WriteError(w, http.StatusNotFound, errNotAssigned.Errorf("user %d is not assigned to this task", userID))
+		return
+	}
+
+	h.recordEvent(r, task)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleGetWatchers(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	watchers, err := h.watchers.GetWatchers(r.Context(), task.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get watchers: %v", err))
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, watchers)
+}
+
+// handleWatch subscribes the requesting user, or another user when the requester owns or created the task.
+// Only the watcher may send their notifications to a webhook or another address.
+func (h *Handler) handleWatch(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	var payload types.WatchPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	requesterID := auth.GetUserIDFromContext(r.Context())
+	userID := requesterID
+	if payload.UserID != nil {
+		userID = *payload.UserID
+	}
+	if !h.mayManage(r, task, userID) {
+		utils.WriteError(w, http.StatusForbidden, errManageWatchers)
+		return
+	}
+
+	if !h.checkMember(w, r, task, userID) {
+		return
+	}
+	u, err := h.userStore.GetUserByID(r.Context(), userID)
+	if errors.Is(err, types.ErrUserNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if payload.Channel == "" {
+		payload.Channel = notification.ChannelInApp
+	}
+	// only the watcher chooses where their notifications go, anyone else
+	// subscribes them in the app or at their own address
+	if userID != requesterID {
+		switch {
+		case payload.Channel == notification.ChannelInApp:
+		case payload.Channel == notification.ChannelEmail && (payload.Target == "" || strings.EqualFold(payload.Target, u.Email)):
+		default:
+			utils.WriteError(w, http.StatusForbidden, errWatcherTarget)
+			return
+		}
+	}
+	switch payload.Channel {
+	case notification.ChannelEmail:
+		// default to the watcher's own address
+		if payload.Target == "" {
+			payload.Target = u.Email
+		}
+		if err := utils.Validate.Var(payload.Target, "email"); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, notification.ErrInvalidTarget.Errorf("invalid email target"))
+			return
+		}
+	case notification.ChannelWebhook:
+		if err := notification.CheckWebhookURL(payload.Target); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, notification.ErrInvalidTarget.Errorf("invalid webhook target: %v", err))
+			return
+		}
+	case notification.ChannelInApp:
+		payload.Target = ""
+	}
+
+	err = h.watchers.SetWatcher(r.Context(), types.Watcher{
+		TaskID:  task.ID,
+		UserID:  userID,
+		Channel: payload.Channel,
+		Target:  payload.Target,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	watchers, err := h.watchers.GetWatchers(r.Context(), task.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, watchers)
+}
+
+func (h *Handler) handleUnwatch(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.Atoi(mux.Vars(r)["user_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid user ID"))
+		return
+	}
+	if !h.mayManage(r, task, userID) {
+		utils.WriteError(w, http.StatusForbidden, errManageWatchers)
+		return
+	}
+
+	rowsAffected, err := h.watchers.RemoveWatcher(r.Context(), task.ID, userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if rowsAffected == 0 {
+		utils.WriteError(w, http.StatusNotFound, errNotWatching.Errorf("user %d is not watching this task", userID))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// mayManage allows changing one's own subscription, and anyone's for the task owner and creator.
+func (h *Handler) mayManage(r *http.Request, task *types.Task, userID int) bool {
+	requesterID := auth.GetUserIDFromContext(r.Context())
+	if requesterID == userID {
+		return true
+	}
+
+	return (task.UserID != nil && *task.UserID == requesterID) ||
+		(task.CreatorID != nil && *task.CreatorID == requesterID)
+}
+
+// checkMember writes a 404 unless the user belongs to the task's workspace.
+func (h *Handler) checkMember(w http.ResponseWriter, r *http.Request, task *types.Task, userID int) bool {
+	member, err := h.workspaceStore.GetMember(r.Context(), task.WorkspaceID, userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	if member == nil {
+		utils.WriteError(w, http.StatusNotFound, workspace.ErrNotMember.Errorf("user %d not found in this workspace", userID))
+		return false
+	}
+
+	return true
+}
+
+// recordEvent stores the assignee change in the task history and tells the watchers about it.
+func (h *Handler) recordEvent(r *http.Request, before *types.Task) {
+	after, err := h.taskStore.GetTaskByID(r.Context(), before.ID)
+	if err != nil {
+		log.Printf("failed to reload task %d: %v", before.ID, err)
+		return
+	}
+
+	event := history.NewTaskEvent(history.ActionUpdate, auth.GetUserIDFromContext(r.Context()), before, after)
+	if err := h.eventStore.CreateTaskEvent(r.Context(), event); err != nil {
+		log.Printf("failed to record task event: %v", err)
+	}
+	h.notifier.NotifyTaskEvent(event)
+}
@@ -0,0 +1,90 @@
+package assignment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"todo/services/auth"
+	"todo/services/storetest"
+	"todo/services/task"
+	"todo/services/user"
+	"todo/services/workspace"
+	"todo/types"
+
+	"github.com/gorilla/mux"
+)
+
+// memberStore makes everyone a member of every workspace.
+type memberStore struct {
+	types.WorkspaceStore
+}
+
+func (memberStore) GetMember(ctx context.Context, workspaceID, userID int) (*types.WorkspaceMember, error) {
+	return &types.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: workspace.RoleMember}, nil
+}
+
+func TestHandleWatchTargets(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.SQLite(t)
+	userIDs, workspaceIDs := storetest.Seed(t, db)
+	owner, other := userIDs[0], userIDs[1]
+
+	tasks := task.NewStore(db)
+	description := ""
+	tk, err := tasks.CreateTask(context.Background(), types.CreateTaskPayload{
+		UserID: &owner, Title: "task", Description: &description, Priority: 2, CreatorID: owner, WorkspaceID: workspaceIDs[0],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(db)
+	h := NewHandler(store, store, tasks, nil, user.NewStore(db), nil, memberStore{})
+
+	tests := []struct {
+		name       string
+		requester  int
+		payload    types.WatchPayload
+		wantStatus int
+		wantTarget string
+	}{
+		{"own webhook", other, types.WatchPayload{Channel: "webhook", Target: "https://example.com/hook"}, http.StatusOK, "https://example.com/hook"},
+		{"own other address", other, types.WatchPayload{Channel: "email", Target: "me@example.org"}, http.StatusOK, "me@example.org"},
+		{"someone else in the app", owner, types.WatchPayload{UserID: &other}, http.StatusOK, ""},
+		{"someone else at their address", owner, types.WatchPayload{UserID: &other, Channel: "email"}, http.StatusOK, "seed1@example.com"},
+		{"someone else at another address", owner, types.WatchPayload{UserID: &other, Channel: "email", Target: "attacker@example.org"}, http.StatusForbidden, ""},
+		{"someone else to a webhook", owner, types.WatchPayload{UserID: &other, Channel: "webhook", Target: "https://example.com/hook"}, http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.payload)
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set(workspace.Header, strconv.Itoa(tk.WorkspaceID))
+			r = mux.SetURLVars(r, map[string]string{"task_id": strconv.Itoa(tk.ID)})
+			r = r.WithContext(context.WithValue(r.Context(), auth.UserKey, tt.requester))
+			w := httptest.NewRecorder()
+			workspace.WithWorkspace(h.handleWatch, memberStore{})(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("got %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			watchers, err := store.GetWatchers(ctx, tk.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, watcher := range watchers {
+				if watcher.UserID == other && watcher.Target != tt.wantTarget {
+					t.Errorf("target = %q, want %q", watcher.Target, tt.wantTarget)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package assignment
+
+import (
+	"context"
+	"database/sql"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/types"
+)
+
+type Store struct {
+	db *dialect.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: dialect.Wrap(db)}
+}
+
+func (s *Store) GetAssignees(ctx context.Context, taskID int) ([]types.User, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT u.id, u.firstName, u.lastName, u.email, u.createdAt
+	FROM task_assignees a
+	JOIN users u ON u.id = a.user_id
+	WHERE a.task_id = ?
+	ORDER BY a.created_at, u.id`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []types.User{}
+	for rows.Next() {
+		var u types.User
+		if err := rows.Scan(&u.ID, &u.FirstName, &u.LastName, &u.Email, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+
+	return users, errs.FromContext(ctx, rows.Err())
+}
+
+// AddAssignee is a no-op for existing assignees, new ones start watching the task in-app.
+func (s *Store) AddAssignee(ctx context.Context, taskID, userID int) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, tx.Dialect().InsertIgnore("INTO task_assignees (task_id, user_id) VALUES (?, ?)"), taskID, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, tx.Dialect().InsertIgnore("INTO task_watchers (task_id, user_id) VALUES (?, ?)"), taskID, userID); err != nil {
+		return err
+	}
+
+	return errs.FromContext(ctx, tx.Commit())
+}
+
+func (s *Store) RemoveAssignee(ctx context.Context, taskID, userID int) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM task_assignees WHERE task_id = ? AND user_id = ?", taskID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (s *Store) GetWatchers(ctx context.Context, taskID int) ([]types.Watcher, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT task_id, user_id, channel, target, created_at FROM task_watchers WHERE task_id = ? ORDER BY created_at, user_id",
+		taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	watchers := []types.Watcher{}
+	for rows.Next() {
+		var w types.Watcher
+		if err := rows.Scan(&w.TaskID, &w.UserID, &w.Channel, &w.Target, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		watchers = append(watchers, w)
+	}
+
+	return watchers, errs.FromContext(ctx, rows.Err())
+}
+
+// SetWatcher starts watching or changes how an existing watcher is notified.
+func (s *Store) SetWatcher(ctx context.Context, watcher types.Watcher) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, s.db.Dialect().Upsert(
+		"INSERT INTO task_watchers (task_id, user_id, channel, target) VALUES (?, ?, ?, ?)",
+		[]string{"task_id", "user_id"}, "channel", "target"),
+		watcher.TaskID, watcher.UserID, watcher.Channel, watcher.Target)
+
+	return err
+}
+
+func (s *Store) RemoveWatcher(ctx context.Context, taskID, userID int) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM task_watchers WHERE task_id = ? AND user_id = ?", taskID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
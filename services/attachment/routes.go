@@ -0,0 +1,271 @@
+package attachment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"todo/errs"
+	"todo/services/auth"
+	"todo/services/workspace"
+	"todo/types"
+	"todo/utils"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gorilla/mux"
+)
+
+// multipartOverhead is the slack allowed on top of the file size for boundaries and part headers.
+const multipartOverhead = 1 << 20
+
+var (
+	errFileTooLarge   = errs.New(http.StatusRequestEntityTooLarge, "file_too_large", "file exceeds the size limit")
+	errTypeNotAllowed = errs.New(http.StatusUnsupportedMediaType, "file_type_not_allowed", "file type is not allowed")
+	errInvalidUpload  = errs.Validation("invalid_upload", "invalid upload")
+)
+
+type Limits struct {
+	MaxSize      int64
+	AllowedTypes []string
+}
+
+type Handler struct {
+	store          types.AttachmentStore
+	blobs          types.BlobStore
+	taskStore      types.TaskStore
+	userStore      types.UserStore
+	limits         Limits
+	workspaceStore types.WorkspaceStore
+}
+
+func NewHandler(store types.AttachmentStore, blobs types.BlobStore, taskStore types.TaskStore, userStore types.UserStore, workspaceStore types.WorkspaceStore, limits Limits) *Handler {
+	return &Handler{store: store, blobs: blobs, taskStore: taskStore, userStore: userStore, workspaceStore: workspaceStore, limits: limits}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/tasks/{task_id}/attachments", auth.WithJWTAuth(workspace.WithWorkspace(h.handleGetAttachments, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{task_id}/attachments", auth.WithJWTAuth(workspace.WithWorkspace(h.handleUpload, h.workspaceStore), h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/{task_id}/attachments/{attachment_id}", auth.WithJWTAuth(workspace.WithWorkspace(h.handleDownload, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{task_id}/attachments/{attachment_id}", auth.WithJWTAuth(workspace.WithWorkspace(h.handleDeleteAttachment, h.workspaceStore), h.userStore)).Methods(http.MethodDelete)
+}
+
+// PurgeHook removes the blobs and rows of every attachment of a task, it is meant to run before the task is purged.
+func PurgeHook(store types.AttachmentStore, blobs types.BlobStore) func(ctx context.Context, taskID int) error {
+	return func(ctx context.Context, taskID int) error {
+		attachments, err := store.GetAttachmentsByTaskID(ctx, taskID)
+		if err != nil {
+			return err
+		}
+
+		for _, a := range attachments {
+			if err := blobs.Delete(ctx, a.StorageKey); err != nil {
+				return err
+			}
+			if _, err := store.DeleteAttachment(ctx, a.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func (h *Handler) handleGetAttachments(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.store.GetAttachmentsByTaskID(r.Context(), task.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get attachments: %v", err))
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, attachments)
+}
+
+func (h *Handler) handleUpload(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.limits.MaxSize+multipartOverhead)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, errFileTooLarge.Errorf("file exceeds the limit of %d bytes", h.limits.MaxSize))
+			return
+		}
+		utils.WriteError(w, http.StatusBadRequest, errInvalidUpload.Errorf("invalid multipart form: %v", err))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	files := r.MultipartForm.File["file"]
+	if len(files) != 1 {
+		utils.WriteError(w, http.StatusBadRequest, errInvalidUpload.Errorf("expected exactly one file in the \"file\" field"))
+		return
+	}
+
+	header := files[0]
+	if header.Size > h.limits.MaxSize {
+		utils.WriteError(w, http.StatusRequestEntityTooLarge, errFileTooLarge.Errorf("file exceeds the limit of %d bytes", h.limits.MaxSize))
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, errInvalidUpload.Errorf("unreadable file: %v", err))
+		return
+	}
+	defer file.Close()
+
+	// sniff the content instead of trusting the client supplied type
+	mtype, err := mimetype.DetectReader(file)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, errInvalidUpload.Errorf("unreadable file: %v", err))
+		return
+	}
+	if !h.isAllowed(mtype) {
+		utils.WriteError(w, http.StatusUnsupportedMediaType, errTypeNotAllowed.Errorf("file type %s is not allowed", mtype.String()))
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	key, err := newStorageKey(task.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.blobs.Put(r.Context(), key, file, header.Size, mtype.String()); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to store file: %v", err))
+		return
+	}
+
+	uploaderID := auth.GetUserIDFromContext(r.Context())
+	attachmentID, err := h.store.CreateAttachment(r.Context(), types.Attachment{
+		TaskID:      task.ID,
+		UploaderID:  &uploaderID,
+		FileName:    filepath.Base(header.Filename),
+		ContentType: mtype.String(),
+		Size:        header.Size,
+		StorageKey:  key,
+	})
+	if err != nil {
+		if err := h.blobs.Delete(context.Background(), key); err != nil {
+			log.Printf("failed to remove orphaned blob %s: %v", key, err)
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	created, err := h.store.GetAttachmentByID(r.Context(), attachmentID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, created)
+}
+
+// handleDownload streams the blob, http.ServeContent takes care of Range and conditional requests.
+func (h *Handler) handleDownload(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := h.getAttachment(w, r)
+	if !ok {
+		return
+	}
+
+	blob, err := h.blobs.Get(r.Context(), attachment.StorageKey)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to read file: %v", err))
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(w, r, attachment.FileName, attachment.CreatedAt, blob)
+}
+
+func (h *Handler) handleDeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	attachment, ok := h.getAttachment(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.blobs.Delete(r.Context(), attachment.StorageKey); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete file: %v", err))
+		return
+	}
+
+	if _, err := h.store.DeleteAttachment(r.Context(), attachment.ID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete attachment: %v", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) isAllowed(mtype *mimetype.MIME) bool {
+	for _, allowed := range h.limits.AllowedTypes {
+		if mtype.Is(allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getAttachment resolves both route variables and makes sure the attachment belongs to the task.
+func (h *Handler) getAttachment(w http.ResponseWriter, r *http.Request) (*types.Attachment, bool) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return nil, false
+	}
+
+	attachmentID, err := strconv.Atoi(mux.Vars(r)["attachment_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid attachment ID"))
+		return nil, false
+	}
+
+	attachment, err := h.store.GetAttachmentByID(r.Context(), attachmentID)
+	if errors.Is(err, ErrAttachmentNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return nil, false
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	if attachment.TaskID != task.ID {
+		utils.WriteError(w, http.StatusNotFound, ErrAttachmentNotFound)
+		return nil, false
+	}
+
+	return attachment, true
+}
+
+func newStorageKey(taskID int) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("tasks/%d/%s", taskID, hex.EncodeToString(b)), nil
+}
@@ -0,0 +1,290 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"todo/errs"
+	"todo/services/auth"
+	"todo/services/storetest"
+	"todo/services/task"
+	"todo/services/workspace"
+	"todo/storage"
+	"todo/types"
+
+	"github.com/gorilla/mux"
+)
+
+// memberStore makes everyone a member of every workspace.
+type memberStore struct {
+	types.WorkspaceStore
+}
+
+func (memberStore) GetMember(ctx context.Context, workspaceID, userID int) (*types.WorkspaceMember, error) {
+	return &types.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: workspace.RoleMember}, nil
+}
+
+// failingStore fails to look up attachments.
+type failingStore struct {
+	types.AttachmentStore
+	err error
+}
+
+func (s failingStore) GetAttachmentByID(ctx context.Context, attachmentID int) (*types.Attachment, error) {
+	return nil, s.err
+}
+
+var testLimits = Limits{MaxSize: 64, AllowedTypes: []string{"text/plain"}}
+
+type fixture struct {
+	db    *sql.DB
+	store *Store
+	blobs *storage.LocalStore
+	task  *types.Task
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+
+	db := storetest.SQLite(t)
+	blobs, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fixture{db: db, store: NewStore(db), blobs: blobs, task: newTask(t, db)}
+}
+
+func (f fixture) handler(store types.AttachmentStore) *Handler {
+	return NewHandler(store, f.blobs, task.NewStore(f.db), nil, memberStore{}, testLimits)
+}
+
+func newTask(t *testing.T, db *sql.DB) *types.Task {
+	t.Helper()
+
+	userIDs, workspaceIDs := storetest.Seed(t, db)
+	description := ""
+	created, err := task.NewStore(db).CreateTask(context.Background(), types.CreateTaskPayload{
+		UserID:      &userIDs[0],
+		Title:       "Report",
+		Description: &description,
+		Priority:    2,
+		WorkspaceID: workspaceIDs[0],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return created
+}
+
+// serve runs the handler as the task owner, attachmentID 0 leaves the variable out.
+func serve(handlerFunc http.HandlerFunc, r *http.Request, tk *types.Task, attachmentID int) *httptest.ResponseRecorder {
+	vars := map[string]string{"task_id": strconv.Itoa(tk.ID)}
+	if attachmentID != 0 {
+		vars["attachment_id"] = strconv.Itoa(attachmentID)
+	}
+
+	r.Header.Set(workspace.Header, strconv.Itoa(tk.WorkspaceID))
+	r = mux.SetURLVars(r, vars)
+	r = r.WithContext(context.WithValue(r.Context(), auth.UserKey, *tk.UserID))
+	w := httptest.NewRecorder()
+	workspace.WithWorkspace(handlerFunc, memberStore{})(w, r)
+
+	return w
+}
+
+func uploadRequest(t *testing.T, fileName string, content []byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	form.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/", &body)
+	r.Header.Set("Content-Type", form.FormDataContentType())
+
+	return r
+}
+
+// upload stores the content through the handler and returns the attachment as stored, storage key included.
+func (f fixture) upload(t *testing.T, tk *types.Task, content string) *types.Attachment {
+	t.Helper()
+
+	h := f.handler(f.store)
+	w := serve(h.handleUpload, uploadRequest(t, "notes.txt", []byte(content)), tk, 0)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("upload: %d %s", w.Code, w.Body)
+	}
+
+	var created types.Attachment
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := f.store.GetAttachmentByID(context.Background(), created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return stored
+}
+
+func TestHandleUploadAndDownload(t *testing.T) {
+	f := newFixture(t)
+	h := f.handler(f.store)
+
+	created := f.upload(t, f.task, "hello world")
+	if created.TaskID != f.task.ID || created.FileName != "notes.txt" || created.Size != 11 {
+		t.Errorf("created = %+v", created)
+	}
+	if _, err := f.blobs.Get(context.Background(), created.StorageKey); err != nil {
+		t.Errorf("blob: %v", err)
+	}
+	if !strings.HasPrefix(created.ContentType, "text/plain") {
+		t.Errorf("content type = %q, want the sniffed text/plain", created.ContentType)
+	}
+
+	w := serve(h.handleDownload, httptest.NewRequest(http.MethodGet, "/", nil), f.task, created.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("download: %d %s", w.Code, w.Body)
+	}
+	if w.Body.String() != "hello world" {
+		t.Errorf("body = %q", w.Body)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename=notes.txt` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Range", "bytes=6-")
+	w = serve(h.handleDownload, r, f.task, created.ID)
+	if w.Code != http.StatusPartialContent || w.Body.String() != "world" {
+		t.Errorf("range download = %d %q, want 206 \"world\"", w.Code, w.Body)
+	}
+}
+
+func TestHandleUploadLimits(t *testing.T) {
+	f := newFixture(t)
+	h := f.handler(f.store)
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+
+	tests := []struct {
+		name       string
+		fileName   string
+		content    []byte
+		wantStatus int
+	}{
+		{"type not allowed", "notes.txt", png, http.StatusUnsupportedMediaType},
+		{"too large", "notes.txt", bytes.Repeat([]byte("a"), int(testLimits.MaxSize)+1), http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h.handleUpload, uploadRequest(t, tt.fileName, tt.content), f.task, 0)
+			if w.Code != tt.wantStatus {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+		})
+	}
+
+	attachments, err := f.store.GetAttachmentsByTaskID(context.Background(), f.task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attachments) != 0 {
+		t.Errorf("attachments = %v, want none stored", attachments)
+	}
+}
+
+func TestHandleDeleteAttachment(t *testing.T) {
+	f := newFixture(t)
+	h := f.handler(f.store)
+	created := f.upload(t, f.task, "hello world")
+
+	if w := serve(h.handleDeleteAttachment, httptest.NewRequest(http.MethodDelete, "/", nil), f.task, created.ID); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d %s", w.Code, w.Body)
+	}
+
+	if _, err := f.store.GetAttachmentByID(context.Background(), created.ID); !errors.Is(err, ErrAttachmentNotFound) {
+		t.Errorf("GetAttachmentByID error = %v, want ErrAttachmentNotFound", err)
+	}
+	if _, err := f.blobs.Get(context.Background(), created.StorageKey); err == nil {
+		t.Error("the blob was kept")
+	}
+}
+
+func TestPurgeHook(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+	first := f.upload(t, f.task, "first")
+	second := f.upload(t, f.task, "second")
+
+	if err := PurgeHook(f.store, f.blobs)(ctx, f.task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	attachments, err := f.store.GetAttachmentsByTaskID(ctx, f.task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attachments) != 0 {
+		t.Errorf("attachments = %v, want all purged", attachments)
+	}
+	for _, a := range []*types.Attachment{first, second} {
+		if blob, err := f.blobs.Get(ctx, a.StorageKey); err == nil {
+			content, _ := io.ReadAll(blob)
+			blob.Close()
+			t.Errorf("blob %s = %q, want it removed", a.StorageKey, content)
+		}
+	}
+}
+
+func TestHandleDownloadErrors(t *testing.T) {
+	f := newFixture(t)
+	description := ""
+	other, err := task.NewStore(f.db).CreateTask(context.Background(), types.CreateTaskPayload{
+		UserID: f.task.UserID, Title: "other", Description: &description, Priority: 2, WorkspaceID: f.task.WorkspaceID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign := f.upload(t, other, "not this task's")
+
+	tests := []struct {
+		name         string
+		store        types.AttachmentStore
+		attachmentID int
+		wantStatus   int
+	}{
+		{"not found", failingStore{err: ErrAttachmentNotFound}, 1, http.StatusNotFound},
+		{"timeout", failingStore{err: errs.ErrQueryTimeout}, 1, http.StatusGatewayTimeout},
+		{"database error", failingStore{err: errors.New("driver: bad connection")}, 1, http.StatusInternalServerError},
+		{"of another task", f.store, foreign.ID, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := f.handler(tt.store)
+			w := serve(h.handleDownload, httptest.NewRequest(http.MethodGet, "/", nil), f.task, tt.attachmentID)
+			if w.Code != tt.wantStatus {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+		})
+	}
+}
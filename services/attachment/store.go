@@ -0,0 +1,119 @@
+package attachment
+
+import (
+	"context"
+	"database/sql"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/types"
+)
+
+var ErrAttachmentNotFound = errs.NotFound("attachment_not_found", "attachment not found")
+
+type Store struct {
+	db *dialect.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: dialect.Wrap(db)}
+}
+
+func (s *Store) GetAttachmentByID(ctx context.Context, attachmentID int) (*types.Attachment, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, task_id, uploader_id, file_name, content_type, size, storage_key, created_at FROM task_attachments WHERE id = ?",
+		attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	a := new(types.Attachment)
+	for rows.Next() {
+		a, err = scanRowsIntoAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
+
+	if a.ID == 0 {
+		return nil, ErrAttachmentNotFound
+	}
+
+	return a, nil
+}
+
+func (s *Store) GetAttachmentsByTaskID(ctx context.Context, taskID int) ([]types.Attachment, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, task_id, uploader_id, file_name, content_type, size, storage_key, created_at FROM task_attachments WHERE task_id = ? ORDER BY id",
+		taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []types.Attachment{}
+	for rows.Next() {
+		a, err := scanRowsIntoAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, *a)
+	}
+
+	return attachments, errs.FromContext(ctx, rows.Err())
+}
+
+func (s *Store) CreateAttachment(ctx context.Context, attachment types.Attachment) (int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	id, err := s.db.InsertContext(ctx,
+		"INSERT INTO task_attachments (task_id, uploader_id, file_name, content_type, size, storage_key) VALUES (?, ?, ?, ?, ?, ?)",
+		attachment.TaskID, attachment.UploaderID, attachment.FileName, attachment.ContentType, attachment.Size, attachment.StorageKey)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s *Store) DeleteAttachment(ctx context.Context, attachmentID int) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM task_attachments WHERE id = ?", attachmentID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func scanRowsIntoAttachment(rows *sql.Rows) (*types.Attachment, error) {
+	a := new(types.Attachment)
+
+	err := rows.Scan(
+		&a.ID,
+		&a.TaskID,
+		&a.UploaderID,
+		&a.FileName,
+		&a.ContentType,
+		&a.Size,
+		&a.StorageKey,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
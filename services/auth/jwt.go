@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"todo/configs"
+	"todo/errs"
 	"todo/types"
 	"todo/utils"
 
@@ -62,12 +64,16 @@ func WithJWTAuth(handlerFunc http.HandlerFunc, store types.UserStore) http.Handl
 			return
 		}
 
-		u, err := store.GetUserByID(userID)
-		if err != nil {
+		u, err := store.GetUserByID(r.Context(), userID)
+		if errors.Is(err, types.ErrUserNotFound) {
 			log.Printf("failed to get user by id: %v", err)
 			permissionDenied(w)
 			return
 		}
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
 
 		// add the user to  the context
 		ctx := r.Context()
@@ -90,7 +96,7 @@ func validateJWT(tokenString string) (*jwt.Token, error) {
 }
 
 func permissionDenied(w http.ResponseWriter) {
-	utils.WriteError(w, http.StatusForbidden, fmt.Errorf("permission denied"))
+	utils.WriteError(w, http.StatusForbidden, errs.Forbidden("permission_denied", "permission denied"))
 }
 
 func GetUserIDFromContext(ctx context.Context) int {
@@ -0,0 +1,184 @@
+package board
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo/services/auth"
+	"todo/services/workflow"
+	"todo/services/workspace"
+	"todo/types"
+	"todo/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	store          types.WIPLimitStore
+	taskStore      types.TaskStore
+	workflowStore  types.WorkflowStore
+	userStore      types.UserStore
+	workspaceStore types.WorkspaceStore
+}
+
+func NewHandler(store types.WIPLimitStore, taskStore types.TaskStore, workflowStore types.WorkflowStore, userStore types.UserStore, workspaceStore types.WorkspaceStore) *Handler {
+	return &Handler{store: store, taskStore: taskStore, workflowStore: workflowStore, userStore: userStore, workspaceStore: workspaceStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/board", auth.WithJWTAuth(workspace.WithWorkspace(h.handleGetBoard, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/board/limits", auth.WithJWTAuth(h.handleGetLimits, h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/board/limits/{status}", auth.WithJWTAuth(h.handleSetLimit, h.userStore)).Methods(http.MethodPut)
+	router.HandleFunc("/board/limits/{status}", auth.WithJWTAuth(h.handleDeleteLimit, h.userStore)).Methods(http.MethodDelete)
+}
+
+// handleGetBoard returns one page of every column of the owner's workflow, page and limit apply to each column separately.
+// The board belongs to the requesting user unless ?user_id= names another user or "none" for unassigned tasks,
+// it only shows tasks of the selected workspace.
+func (h *Handler) handleGetBoard(w http.ResponseWriter, r *http.Request) {
+	pagination, err := utils.ParsePaginationParams(r, []string{"position"})
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	pagination.SortBy = "position"
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	owner := &userID
+	switch str := r.URL.Query().Get("user_id"); str {
+	case "":
+	case "none":
+		owner = nil
+	default:
+		id, err := strconv.Atoi(str)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid user_id"))
+			return
+		}
+		owner = &id
+	}
+
+	wf, err := h.workflowStore.GetWorkflow(r.Context(), owner)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	limits := map[string]*types.WIPLimit{}
+	if owner != nil {
+		all, err := h.store.GetWIPLimits(r.Context(), *owner)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		for i := range all {
+			limits[all[i].Status] = &all[i]
+		}
+	}
+
+	board := types.Board{UserID: owner, Columns: []types.BoardColumnPage{}}
+	for _, status := range wf.Statuses {
+		tasks, total, err := h.taskStore.GetColumnTasks(r.Context(), types.BoardColumn{WorkspaceID: workspace.IDFromContext(r.Context()), UserID: owner, Status: status.Name}, pagination)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get board: %w", err))
+			return
+		}
+		if tasks == nil {
+			tasks = []types.Task{}
+		}
+
+		board.Columns = append(board.Columns, types.BoardColumnPage{
+			Status:     status.Name,
+			Count:      total,
+			WIPLimit:   limits[status.Name],
+			Page:       pagination.Page,
+			Limit:      pagination.Limit,
+			TotalPages: (total + pagination.Limit - 1) / pagination.Limit,
+			Tasks:      tasks,
+		})
+	}
+
+	utils.WriteJson(w, http.StatusOK, board)
+}
+
+func (h *Handler) handleGetLimits(w http.ResponseWriter, r *http.Request) {
+	limits, err := h.store.GetWIPLimits(r.Context(), auth.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, limits)
+}
+
+func (h *Handler) handleSetLimit(w http.ResponseWriter, r *http.Request) {
+	status, ok := h.parseStatus(w, r)
+	if !ok {
+		return
+	}
+
+	var payload types.SetWIPLimitPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	limit := types.WIPLimit{
+		UserID:   auth.GetUserIDFromContext(r.Context()),
+		Status:   status,
+		MaxTasks: payload.MaxTasks,
+		Mode:     payload.Mode,
+	}
+	if limit.Mode == "" {
+		limit.Mode = ModeReject
+	}
+
+	if err := h.store.SetWIPLimit(r.Context(), limit); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, limit)
+}
+
+func (h *Handler) handleDeleteLimit(w http.ResponseWriter, r *http.Request) {
+	status, ok := h.parseStatus(w, r)
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.store.DeleteWIPLimit(r.Context(), auth.GetUserIDFromContext(r.Context()), status)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if rowsAffected == 0 {
+		utils.WriteError(w, http.StatusNotFound, errLimitNotFound.Errorf("no limit set for %s", status))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseStatus resolves the {status} route variable against the requesting user's workflow.
+func (h *Handler) parseStatus(w http.ResponseWriter, r *http.Request) (string, bool) {
+	status := mux.Vars(r)["status"]
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	wf, err := h.workflowStore.GetWorkflow(r.Context(), &userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return "", false
+	}
+	if workflow.FindStatus(wf, status) == nil {
+		utils.WriteError(w, http.StatusBadRequest, workflow.ErrUnknownStatus.Errorf("invalid status: %s", status))
+		return "", false
+	}
+
+	return status, true
+}
@@ -0,0 +1,82 @@
+package board
+
+import (
+	"context"
+	"database/sql"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/types"
+)
+
+type Store struct {
+	db *dialect.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: dialect.Wrap(db)}
+}
+
+func (s *Store) GetWIPLimits(ctx context.Context, userID int) ([]types.WIPLimit, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT user_id, status, max_tasks, mode FROM board_wip_limits WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	limits := []types.WIPLimit{}
+	for rows.Next() {
+		var l types.WIPLimit
+		if err := rows.Scan(&l.UserID, &l.Status, &l.MaxTasks, &l.Mode); err != nil {
+			return nil, err
+		}
+		limits = append(limits, l)
+	}
+
+	return limits, errs.FromContext(ctx, rows.Err())
+}
+
+// GetWIPLimit returns nil without an error when the column has no limit.
+func (s *Store) GetWIPLimit(ctx context.Context, userID int, status string) (*types.WIPLimit, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	l := new(types.WIPLimit)
+	err := s.db.QueryRowContext(ctx,
+		"SELECT user_id, status, max_tasks, mode FROM board_wip_limits WHERE user_id = ? AND status = ?",
+		userID, status).Scan(&l.UserID, &l.Status, &l.MaxTasks, &l.Mode)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (s *Store) SetWIPLimit(ctx context.Context, limit types.WIPLimit) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, s.db.Dialect().Upsert(
+		"INSERT INTO board_wip_limits (user_id, status, max_tasks, mode) VALUES (?, ?, ?, ?)",
+		[]string{"user_id", "status"}, "max_tasks", "mode"),
+		limit.UserID, limit.Status, limit.MaxTasks, limit.Mode)
+
+	return err
+}
+
+func (s *Store) DeleteWIPLimit(ctx context.Context, userID int, status string) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM board_wip_limits WHERE user_id = ? AND status = ?", userID, status)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
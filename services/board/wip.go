@@ -0,0 +1,59 @@
+package board
+
+import (
+	"context"
+	"fmt"
+	"todo/errs"
+	"todo/types"
+)
+
+const (
+	ModeReject = "reject"
+	ModeWarn   = "warn"
+)
+
+var (
+	ErrWIPLimitReached = errs.Conflict("wip_limit_reached", "work in progress limit reached")
+	errLimitNotFound   = errs.NotFound("wip_limit_not_found", "no limit set")
+)
+
+// CheckWIPLimit is called before task moves into column. A full column with
+// a reject limit yields ErrWIPLimitReached, with a warn limit the move is
+// allowed and a warning is returned instead. Moves within a column are free.
+func CheckWIPLimit(ctx context.Context, limits types.WIPLimitStore, tasks types.TaskStore, task *types.Task, column types.BoardColumn) (string, error) {
+	return CheckBatchWIPLimit(ctx, limits, tasks, task, column, 0)
+}
+
+// CheckBatchWIPLimit is CheckWIPLimit for one task of a batch, added counts the
+// tasks that earlier items of the batch move into column.
+func CheckBatchWIPLimit(ctx context.Context, limits types.WIPLimitStore, tasks types.TaskStore, task *types.Task, column types.BoardColumn, added int) (string, error) {
+	if column.UserID == nil {
+		return "", nil
+	}
+
+	sameOwner := task.UserID != nil && *task.UserID == *column.UserID
+	if sameOwner && task.Status == column.Status {
+		return "", nil
+	}
+
+	limit, err := limits.GetWIPLimit(ctx, *column.UserID, column.Status)
+	if err != nil || limit == nil {
+		return "", err
+	}
+
+	count, err := tasks.CountColumnTasks(ctx, column)
+	if err != nil {
+		return "", err
+	}
+	count += added
+	if count < limit.MaxTasks {
+		return "", nil
+	}
+
+	msg := fmt.Sprintf("column %s already holds %d of %d tasks", column.Status, count, limit.MaxTasks)
+	if limit.Mode == ModeWarn {
+		return msg, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrWIPLimitReached, msg)
+}
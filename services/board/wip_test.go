@@ -0,0 +1,88 @@
+package board
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"todo/types"
+)
+
+// limitStore holds one limit for every column.
+type limitStore struct {
+	types.WIPLimitStore
+	limit *types.WIPLimit
+	err   error
+}
+
+func (s limitStore) GetWIPLimit(ctx context.Context, userID int, status string) (*types.WIPLimit, error) {
+	return s.limit, s.err
+}
+
+// countStore reports the same number of tasks for every column.
+type countStore struct {
+	types.TaskStore
+	count int
+	err   error
+}
+
+func (s countStore) CountColumnTasks(ctx context.Context, column types.BoardColumn) (int, error) {
+	return s.count, s.err
+}
+
+func TestCheckBatchWIPLimit(t *testing.T) {
+	owner, other := 1, 2
+	task := &types.Task{ID: 1, UserID: &owner, Status: "pending"}
+	column := types.BoardColumn{WorkspaceID: 1, UserID: &owner, Status: "in_progress"}
+	reject := &types.WIPLimit{UserID: owner, Status: "in_progress", MaxTasks: 3, Mode: ModeReject}
+	warn := &types.WIPLimit{UserID: owner, Status: "in_progress", MaxTasks: 3, Mode: ModeWarn}
+	dbErr := errors.New("driver: bad connection")
+
+	tests := []struct {
+		name        string
+		limits      limitStore
+		tasks       countStore
+		task        *types.Task
+		column      types.BoardColumn
+		added       int
+		wantWarning bool
+		wantErr     error
+	}{
+		{"no limit", limitStore{}, countStore{count: 10}, task, column, 0, false, nil},
+		{"room left", limitStore{limit: reject}, countStore{count: 1}, task, column, 0, false, nil},
+		{"room left for the batch", limitStore{limit: reject}, countStore{count: 1}, task, column, 1, false, nil},
+		{"filled by the batch", limitStore{limit: reject}, countStore{count: 1}, task, column, 2, false, ErrWIPLimitReached},
+		{"already full", limitStore{limit: reject}, countStore{count: 3}, task, column, 0, false, ErrWIPLimitReached},
+		{"warn limit", limitStore{limit: warn}, countStore{count: 1}, task, column, 2, true, nil},
+		{"within the column", limitStore{limit: reject}, countStore{count: 3}, &types.Task{ID: 1, UserID: &owner, Status: "in_progress"}, column, 5, false, nil},
+		{"from another owner's column", limitStore{limit: reject}, countStore{count: 3}, &types.Task{ID: 1, UserID: &other, Status: "in_progress"}, column, 0, false, ErrWIPLimitReached},
+		{"column without owner", limitStore{limit: reject}, countStore{count: 3}, task, types.BoardColumn{WorkspaceID: 1, Status: "in_progress"}, 0, false, nil},
+		{"limit lookup fails", limitStore{err: dbErr}, countStore{}, task, column, 0, false, dbErr},
+		{"count fails", limitStore{limit: reject}, countStore{err: dbErr}, task, column, 0, false, dbErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warning, err := CheckBatchWIPLimit(context.Background(), tt.limits, tt.tasks, tt.task, tt.column, tt.added)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckBatchWIPLimit() error = %v, want %v", err, tt.wantErr)
+			}
+			if (warning != "") != tt.wantWarning {
+				t.Errorf("CheckBatchWIPLimit() warning = %q, want one: %v", warning, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestCheckWIPLimit(t *testing.T) {
+	owner := 1
+	task := &types.Task{ID: 1, UserID: &owner, Status: "pending"}
+	column := types.BoardColumn{WorkspaceID: 1, UserID: &owner, Status: "in_progress"}
+	limits := limitStore{limit: &types.WIPLimit{UserID: owner, Status: "in_progress", MaxTasks: 3, Mode: ModeReject}}
+
+	if _, err := CheckWIPLimit(context.Background(), limits, countStore{count: 2}, task, column); err != nil {
+		t.Errorf("CheckWIPLimit() with room for one more = %v", err)
+	}
+	if _, err := CheckWIPLimit(context.Background(), limits, countStore{count: 3}, task, column); !errors.Is(err, ErrWIPLimitReached) {
+		t.Errorf("CheckWIPLimit() on a full column = %v, want %v", err, ErrWIPLimitReached)
+	}
+}
@@ -0,0 +1,274 @@
+package checklist
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"todo/errs"
+	"todo/services/auth"
+	"todo/services/history"
+	"todo/services/notification"
+	"todo/services/workflow"
+	"todo/services/workspace"
+	"todo/types"
+	"todo/utils"
+
+	"github.com/gorilla/mux"
+)
+
+var errInvalidOrder = errs.Validation("invalid_checklist_order", "every checklist item must be listed exactly once")
+
+type Handler struct {
+	store          types.ChecklistStore
+	taskStore      types.TaskStore
+	workflowStore  types.WorkflowStore
+	userStore      types.UserStore
+	eventStore     types.TaskEventStore
+	notifier       *notification.Notifier
+	workspaceStore types.WorkspaceStore
+}
+
+func NewHandler(store types.ChecklistStore, taskStore types.TaskStore, workflowStore types.WorkflowStore, userStore types.UserStore, eventStore types.TaskEventStore, notifier *notification.Notifier, workspaceStore types.WorkspaceStore) *Handler {
+	return &Handler{store: store, taskStore: taskStore, workflowStore: workflowStore, userStore: userStore, eventStore: eventStore, notifier: notifier, workspaceStore: workspaceStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/tasks/{task_id}/checklist", auth.WithJWTAuth(workspace.WithWorkspace(h.handleGetChecklist, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{task_id}/checklist", auth.WithJWTAuth(workspace.WithWorkspace(h.handleAddItem, h.workspaceStore), h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/{task_id}/checklist/order", auth.WithJWTAuth(workspace.WithWorkspace(h.handleReorder, h.workspaceStore), h.userStore)).Methods(http.MethodPut)
+	router.HandleFunc("/tasks/{task_id}/checklist/{item_id}/toggle", auth.WithJWTAuth(workspace.WithWorkspace(h.handleToggleItem, h.workspaceStore), h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/{task_id}/checklist/{item_id}", auth.WithJWTAuth(workspace.WithWorkspace(h.handleDeleteItem, h.workspaceStore), h.userStore)).Methods(http.MethodDelete)
+}
+
+func (h *Handler) handleGetChecklist(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	items, err := h.store.GetChecklistItems(r.Context(), task.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get checklist: %v", err))
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, items)
+}
+
+func (h *Handler) handleAddItem(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	var payload types.CreateChecklistItemPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	itemID, err := h.store.CreateChecklistItem(r.Context(), task.ID, payload.Text)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	item, err := h.store.GetChecklistItemByID(r.Context(), itemID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, item)
+}
+
+func (h *Handler) handleToggleItem(w http.ResponseWriter, r *http.Request) {
+	task, item, ok := h.getItem(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.ToggleChecklistItem(r.Context(), item.ID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.autoComplete(r, task)
+
+	toggled, err := h.store.GetChecklistItemByID(r.Context(), item.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, toggled)
+}
+
+func (h *Handler) handleReorder(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	var payload types.ReorderChecklistPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	items, err := h.store.GetChecklistItems(r.Context(), task.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// the new order has to mention every item of the task exactly once
+	remaining := make(map[int]bool, len(items))
+	for _, item := range items {
+		remaining[item.ID] = true
+	}
+	for _, itemID := range payload.ItemIDs {
+		if !remaining[itemID] {
+			utils.WriteError(w, http.StatusBadRequest, errInvalidOrder.Errorf("item %d is unknown or listed twice", itemID))
+			return
+		}
+		delete(remaining, itemID)
+	}
+	if len(remaining) > 0 {
+		utils.WriteError(w, http.StatusBadRequest, errInvalidOrder.Errorf("every checklist item must be listed"))
+		return
+	}
+
+	if err := h.store.ReorderChecklistItems(r.Context(), task.ID, payload.ItemIDs); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	items, err = h.store.GetChecklistItems(r.Context(), task.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, items)
+}
+
+func (h *Handler) handleDeleteItem(w http.ResponseWriter, r *http.Request) {
+	task, item, ok := h.getItem(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.store.DeleteChecklistItem(r.Context(), item.ID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete checklist item: %v", err))
+		return
+	}
+
+	// removing the last open item can complete the checklist too
+	h.autoComplete(r, task)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// autoComplete moves the task to the first done status of its owner's workflow when it opted in
+// and every checklist item is done. Nothing happens if the workflow does not allow that transition.
+func (h *Handler) autoComplete(r *http.Request, task *types.Task) {
+	if !task.ChecklistAutoComplete {
+		return
+	}
+
+	current, err := h.taskStore.GetTaskByID(r.Context(), task.ID)
+	if err != nil {
+		return
+	}
+	if current.Checklist.Total == 0 || current.Checklist.Done < current.Checklist.Total {
+		return
+	}
+
+	wf, err := h.workflowStore.GetWorkflow(r.Context(), current.UserID)
+	if err != nil {
+		log.Printf("failed to get workflow for task %d: %v", current.ID, err)
+		return
+	}
+	if s := workflow.FindStatus(wf, current.Status); s != nil && s.Category == workflow.CategoryDone {
+		return
+	}
+
+	completed := workflow.FirstStatusIn(wf, workflow.CategoryDone)
+	if completed == "" || workflow.ValidateStatusChange(wf, current.Status, completed) != nil {
+		return
+	}
+
+	// a concurrent write wins, the next checklist change tries again
+	_, err = h.taskStore.UpdateTask(r.Context(), current.ID, current.Version, types.UpdateTaskPayload{
+		UserID:      current.UserID,
+		Title:       &current.Title,
+		Description: &current.Description,
+		Status:      &completed,
+		Priority:    &current.Priority,
+		DueDate:     current.DueDate,
+
+		ChecklistAutoComplete: &current.ChecklistAutoComplete,
+		CustomFields:          current.CustomFields,
+		AssigneeIDs:           &current.AssigneeIDs,
+		Labels:                &current.Labels,
+	})
+	if err != nil {
+		log.Printf("failed to auto-complete task %d: %v", current.ID, err)
+		return
+	}
+
+	updated, err := h.taskStore.GetTaskByID(r.Context(), current.ID)
+	if err != nil {
+		return
+	}
+
+	actorID := auth.GetUserIDFromContext(r.Context())
+	event := history.NewTaskEvent(history.ActionUpdate, actorID, current, updated)
+	if err := h.eventStore.CreateTaskEvent(r.Context(), event); err != nil {
+		log.Printf("failed to record task event: %v", err)
+	}
+	h.notifier.NotifyTaskEvent(event)
+}
+
+// getItem resolves both route variables and makes sure the item belongs to the task.
+func (h *Handler) getItem(w http.ResponseWriter, r *http.Request) (*types.Task, *types.ChecklistItem, bool) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return nil, nil, false
+	}
+
+	itemID, err := strconv.Atoi(mux.Vars(r)["item_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid checklist item ID"))
+		return nil, nil, false
+	}
+
+	item, err := h.store.GetChecklistItemByID(r.Context(), itemID)
+	if errors.Is(err, ErrItemNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return nil, nil, false
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return nil, nil, false
+	}
+	if item.TaskID != task.ID {
+		utils.WriteError(w, http.StatusNotFound, ErrItemNotFound)
+		return nil, nil, false
+	}
+
+	return task, item, true
+}
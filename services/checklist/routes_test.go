@@ -0,0 +1,125 @@
+package checklist
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"todo/services/assignment"
+	"todo/services/auth"
+	"todo/services/history"
+	"todo/services/notification"
+	"todo/services/storetest"
+	"todo/services/task"
+	"todo/services/workflow"
+	"todo/services/workspace"
+	"todo/types"
+
+	"github.com/gorilla/mux"
+)
+
+// memberStore makes everyone a member of every workspace.
+type memberStore struct {
+	types.WorkspaceStore
+}
+
+func (memberStore) GetMember(ctx context.Context, workspaceID, userID int) (*types.WorkspaceMember, error) {
+	return &types.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: workspace.RoleMember}, nil
+}
+
+// failingStore fails to look up items.
+type failingStore struct {
+	types.ChecklistStore
+	err error
+}
+
+func (s failingStore) GetChecklistItemByID(ctx context.Context, itemID int) (*types.ChecklistItem, error) {
+	return nil, s.err
+}
+
+// serve runs the handler on the task's checklist item as the task owner.
+func serve(h *Handler, handlerFunc http.HandlerFunc, tk *types.Task, itemID int) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(workspace.Header, strconv.Itoa(tk.WorkspaceID))
+	r = mux.SetURLVars(r, map[string]string{"task_id": strconv.Itoa(tk.ID), "item_id": strconv.Itoa(itemID)})
+	r = r.WithContext(context.WithValue(r.Context(), auth.UserKey, *tk.UserID))
+	w := httptest.NewRecorder()
+	workspace.WithWorkspace(handlerFunc, memberStore{})(w, r)
+
+	return w
+}
+
+func newHandler(store types.ChecklistStore, db *sql.DB) *Handler {
+	return NewHandler(store, task.NewStore(db), workflow.NewStore(db), nil, history.NewStore(db),
+		notification.NewNotifier(assignment.NewStore(db), nil), memberStore{})
+}
+
+func TestHandleToggleItemAutoCompletes(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.SQLite(t)
+	store := NewStore(db)
+	h := newHandler(store, db)
+	tk := newTask(t, db, true)
+
+	first, _ := store.CreateChecklistItem(ctx, tk.ID, "passport")
+	second, _ := store.CreateChecklistItem(ctx, tk.ID, "charger")
+
+	for i, itemID := range []int{first, second} {
+		if w := serve(h, h.handleToggleItem, tk, itemID); w.Code != http.StatusOK {
+			t.Fatalf("toggle: %d %s", w.Code, w.Body)
+		}
+
+		got, err := task.NewStore(db).GetTaskByID(context.Background(), tk.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := tk.Status
+		if i == 1 {
+			want = "completed"
+		}
+		if got.Status != want {
+			t.Errorf("status after %d of 2 items = %q, want %q", i+1, got.Status, want)
+		}
+	}
+}
+
+func TestHandleDeleteItemErrors(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.SQLite(t)
+	tk := newTask(t, db, false)
+	store := NewStore(db)
+	otherTask, err := task.NewStore(db).CreateTask(context.Background(), types.CreateTaskPayload{
+		UserID: tk.UserID, Title: "other", Description: &tk.Description, Priority: 2, WorkspaceID: tk.WorkspaceID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, _ := store.CreateChecklistItem(ctx, otherTask.ID, "not this task's")
+
+	tests := []struct {
+		name       string
+		store      types.ChecklistStore
+		itemID     int
+		wantStatus int
+	}{
+		{"not found", failingStore{err: ErrItemNotFound}, 1, http.StatusNotFound},
+		{"database error", failingStore{err: errors.New("driver: bad connection")}, 1, http.StatusInternalServerError},
+		{"of another task", store, foreign, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHandler(tt.store, db)
+			if w := serve(h, h.handleDeleteItem, tk, tt.itemID); w.Code != tt.wantStatus {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+		})
+	}
+
+	if texts := itemTexts(t, store, otherTask.ID); len(texts) != 1 {
+		t.Errorf("the other task's items = %v, want it kept", texts)
+	}
+}
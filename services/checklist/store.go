@@ -0,0 +1,149 @@
+package checklist
+
+import (
+	"context"
+	"database/sql"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/types"
+)
+
+var ErrItemNotFound = errs.NotFound("checklist_item_not_found", "checklist item not found")
+
+type Store struct {
+	db *dialect.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: dialect.Wrap(db)}
+}
+
+func (s *Store) GetChecklistItemByID(ctx context.Context, itemID int) (*types.ChecklistItem, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, task_id, text, done, position, created_at, updated_at FROM task_checklist_items WHERE id = ?",
+		itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	item := new(types.ChecklistItem)
+	for rows.Next() {
+		item, err = scanRowsIntoChecklistItem(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
+
+	if item.ID == 0 {
+		return nil, ErrItemNotFound
+	}
+
+	return item, nil
+}
+
+func (s *Store) GetChecklistItems(ctx context.Context, taskID int) ([]types.ChecklistItem, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, task_id, text, done, position, created_at, updated_at FROM task_checklist_items WHERE task_id = ? ORDER BY position, id",
+		taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []types.ChecklistItem{}
+	for rows.Next() {
+		item, err := scanRowsIntoChecklistItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+
+	return items, errs.FromContext(ctx, rows.Err())
+}
+
+// CreateChecklistItem appends the item at the end of the task's checklist.
+func (s *Store) CreateChecklistItem(ctx context.Context, taskID int, text string) (int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	id, err := s.db.InsertContext(ctx, `
+	INSERT INTO task_checklist_items (task_id, text, position)
+	SELECT ?, ?, COALESCE(MAX(position) + 1, 0) FROM task_checklist_items WHERE task_id = ?`,
+		taskID, text, taskID)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s *Store) ToggleChecklistItem(ctx context.Context, itemID int) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE task_checklist_items SET done = NOT done WHERE id = ?", itemID)
+	return err
+}
+
+// ReorderChecklistItems sets each item's position to its index in itemIDs.
+func (s *Store) ReorderChecklistItems(ctx context.Context, taskID int, itemIDs []int) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for position, itemID := range itemIDs {
+		_, err := tx.ExecContext(ctx, "UPDATE task_checklist_items SET position = ? WHERE id = ? AND task_id = ?", position, itemID, taskID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return errs.FromContext(ctx, tx.Commit())
+}
+
+func (s *Store) DeleteChecklistItem(ctx context.Context, itemID int) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM task_checklist_items WHERE id = ?", itemID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func scanRowsIntoChecklistItem(rows *sql.Rows) (*types.ChecklistItem, error) {
+	item := new(types.ChecklistItem)
+
+	err := rows.Scan(
+		&item.ID,
+		&item.TaskID,
+		&item.Text,
+		&item.Done,
+		&item.Position,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
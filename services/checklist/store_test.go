@@ -0,0 +1,100 @@
+package checklist
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"todo/services/storetest"
+	"todo/services/task"
+	"todo/types"
+)
+
+// newTask adds a task owned by the first seeded user.
+func newTask(t *testing.T, db *sql.DB, autoComplete bool) *types.Task {
+	t.Helper()
+
+	userIDs, workspaceIDs := storetest.Seed(t, db)
+	description := ""
+	created, err := task.NewStore(db).CreateTask(context.Background(), types.CreateTaskPayload{
+		UserID:                &userIDs[0],
+		Title:                 "Pack",
+		Description:           &description,
+		Priority:              2,
+		ChecklistAutoComplete: autoComplete,
+		WorkspaceID:           workspaceIDs[0],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return created
+}
+
+func itemTexts(t *testing.T, store *Store, taskID int) []string {
+	ctx := context.Background()
+	t.Helper()
+
+	items, err := store.GetChecklistItems(ctx, taskID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	texts := []string{}
+	for _, item := range items {
+		texts = append(texts, item.Text)
+	}
+	return texts
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.SQLite(t)
+	store := NewStore(db)
+	tk := newTask(t, db, false)
+
+	ids := []int{}
+	for _, text := range []string{"passport", "charger", "socks"} {
+		id, err := store.CreateChecklistItem(ctx, tk.ID, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := store.ReorderChecklistItems(ctx, tk.ID, []int{ids[2], ids[0], ids[1]}); err != nil {
+		t.Fatal(err)
+	}
+	if got := itemTexts(t, store, tk.ID); len(got) != 3 || got[0] != "socks" || got[1] != "passport" || got[2] != "charger" {
+		t.Errorf("items after reordering = %v", got)
+	}
+
+	if err := store.ToggleChecklistItem(ctx, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	item, err := store.GetChecklistItemByID(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !item.Done || item.TaskID != tk.ID {
+		t.Errorf("toggled item = %+v", item)
+	}
+
+	got, err := task.NewStore(db).GetTaskByID(context.Background(), tk.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Checklist.Done != 1 || got.Checklist.Total != 3 {
+		t.Errorf("checklist summary = %+v, want 1 of 3 done", got.Checklist)
+	}
+
+	if n, err := store.DeleteChecklistItem(ctx, ids[1]); err != nil || n != 1 {
+		t.Fatalf("DeleteChecklistItem() = %d, %v, want 1", n, err)
+	}
+	if _, err := store.GetChecklistItemByID(ctx, ids[1]); !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("GetChecklistItemByID() of a deleted item = %v, want %v", err, ErrItemNotFound)
+	}
+	if got := itemTexts(t, store, tk.ID); len(got) != 2 || got[0] != "socks" || got[1] != "passport" {
+		t.Errorf("items after deleting = %v", got)
+	}
+}
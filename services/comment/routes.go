@@ -0,0 +1,214 @@
+package comment
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo/errs"
+	"todo/services/auth"
+	"todo/services/notification"
+	"todo/services/workspace"
+	"todo/types"
+	"todo/utils"
+
+	"github.com/gorilla/mux"
+)
+
+var errNotAuthor = errs.Forbidden("permission_denied", "only the author or the task owner can change this comment")
+
+type Handler struct {
+	store          types.CommentStore
+	taskStore      types.TaskStore
+	userStore      types.UserStore
+	notifier       *notification.Notifier
+	workspaceStore types.WorkspaceStore
+}
+
+func NewHandler(store types.CommentStore, taskStore types.TaskStore, userStore types.UserStore, notifier *notification.Notifier, workspaceStore types.WorkspaceStore) *Handler {
+	return &Handler{store: store, taskStore: taskStore, userStore: userStore, notifier: notifier, workspaceStore: workspaceStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/tasks/{task_id}/comments", auth.WithJWTAuth(workspace.WithWorkspace(h.handleGetComments, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{task_id}/comments", auth.WithJWTAuth(workspace.WithWorkspace(h.handleCreateComment, h.workspaceStore), h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/{task_id}/comments/{comment_id}", auth.WithJWTAuth(workspace.WithWorkspace(h.handleUpdateComment, h.workspaceStore), h.userStore)).Methods(http.MethodPut)
+	router.HandleFunc("/tasks/{task_id}/comments/{comment_id}", auth.WithJWTAuth(workspace.WithWorkspace(h.handleDeleteComment, h.workspaceStore), h.userStore)).Methods(http.MethodDelete)
+	router.HandleFunc("/tasks/{task_id}/comments/{comment_id}/revisions", auth.WithJWTAuth(workspace.WithWorkspace(h.handleGetRevisions, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+}
+
+func (h *Handler) handleGetComments(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	pagination, err := utils.ParsePaginationParams(r, []string{"created_at"})
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	comments, total, err := h.store.GetPaginatedComments(r.Context(), task.ID, pagination)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get comments: %v", err))
+		return
+	}
+
+	utils.WritePaginatedResponse(w, pagination.Page, pagination.Limit, total, comments)
+}
+
+func (h *Handler) handleCreateComment(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	var payload types.CommentPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	authorID := auth.GetUserIDFromContext(r.Context())
+	commentID, err := h.store.CreateComment(r.Context(), types.Comment{
+		TaskID:   task.ID,
+		AuthorID: &authorID,
+		Body:     payload.Body,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	created, err := h.store.GetCommentByID(r.Context(), commentID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.notifier.Notify(task.ID, authorID, fmt.Sprintf("New comment on %s", task.Title), payload.Body)
+
+	utils.WriteJson(w, http.StatusCreated, created)
+}
+
+func (h *Handler) handleUpdateComment(w http.ResponseWriter, r *http.Request) {
+	_, comment, ok := h.getEditableComment(w, r)
+	if !ok {
+		return
+	}
+
+	var payload types.CommentPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	if payload.Body != comment.Body {
+		editorID := auth.GetUserIDFromContext(r.Context())
+		if err := h.store.UpdateComment(r.Context(), comment.ID, editorID, payload.Body); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	updated, err := h.store.GetCommentByID(r.Context(), comment.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, updated)
+}
+
+func (h *Handler) handleDeleteComment(w http.ResponseWriter, r *http.Request) {
+	_, comment, ok := h.getEditableComment(w, r)
+	if !ok {
+		return
+	}
+
+	rowsAffected, err := h.store.DeleteComment(r.Context(), comment.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete comment: %v", err))
+		return
+	}
+	if rowsAffected == 0 {
+		utils.WriteError(w, http.StatusNotFound, ErrCommentNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleGetRevisions(w http.ResponseWriter, r *http.Request) {
+	_, comment, ok := h.getComment(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.store.GetCommentRevisions(r.Context(), comment.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get revisions: %v", err))
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, revisions)
+}
+
+// getComment resolves both route variables and makes sure the comment belongs to the task.
+func (h *Handler) getComment(w http.ResponseWriter, r *http.Request) (*types.Task, *types.Comment, bool) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return nil, nil, false
+	}
+
+	commentID, err := strconv.Atoi(mux.Vars(r)["comment_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid comment ID"))
+		return nil, nil, false
+	}
+
+	comment, err := h.store.GetCommentByID(r.Context(), commentID)
+	if errors.Is(err, ErrCommentNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return nil, nil, false
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return nil, nil, false
+	}
+	if comment.TaskID != task.ID {
+		utils.WriteError(w, http.StatusNotFound, ErrCommentNotFound)
+		return nil, nil, false
+	}
+
+	return task, comment, true
+}
+
+// getEditableComment is getComment restricted to the comment author and the task owner.
+func (h *Handler) getEditableComment(w http.ResponseWriter, r *http.Request) (*types.Task, *types.Comment, bool) {
+	task, comment, ok := h.getComment(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	isAuthor := comment.AuthorID != nil && *comment.AuthorID == userID
+	isOwner := task.UserID != nil && *task.UserID == userID
+	if !isAuthor && !isOwner {
+		utils.WriteError(w, http.StatusForbidden, errNotAuthor)
+		return nil, nil, false
+	}
+
+	return task, comment, true
+}
@@ -0,0 +1,93 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"todo/services/auth"
+	"todo/services/task"
+	"todo/services/workspace"
+	"todo/types"
+
+	"github.com/gorilla/mux"
+)
+
+type stubStore struct {
+	types.CommentStore
+	comment *types.Comment
+	err     error
+	deleted []int
+}
+
+func (s *stubStore) GetCommentByID(ctx context.Context, commentID int) (*types.Comment, error) {
+	return s.comment, s.err
+}
+
+func (s *stubStore) DeleteComment(ctx context.Context, commentID int) (int64, error) {
+	s.deleted = append(s.deleted, commentID)
+	return 1, nil
+}
+
+// memberStore makes everyone a member of every workspace.
+type memberStore struct {
+	types.WorkspaceStore
+}
+
+func (memberStore) GetMember(ctx context.Context, workspaceID, userID int) (*types.WorkspaceMember, error) {
+	return &types.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: workspace.RoleMember}, nil
+}
+
+func TestHandleDeleteComment(t *testing.T) {
+	const owner, author, other = 1, 2, 3
+
+	tasks := task.NewMemoryStore()
+	description := ""
+	tk, err := tasks.CreateTask(context.Background(), types.CreateTaskPayload{
+		UserID: ptr(owner), Title: "task", Description: &description, Priority: 2, WorkspaceID: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	comment := &types.Comment{ID: 7, TaskID: tk.ID, AuthorID: ptr(author)}
+
+	tests := []struct {
+		name       string
+		userID     int
+		store      *stubStore
+		wantStatus int
+	}{
+		{"by the author", author, &stubStore{comment: comment}, http.StatusNoContent},
+		{"by the task owner", owner, &stubStore{comment: comment}, http.StatusNoContent},
+		{"by someone else", other, &stubStore{comment: comment}, http.StatusForbidden},
+		{"not found", author, &stubStore{err: ErrCommentNotFound}, http.StatusNotFound},
+		{"database error", author, &stubStore{err: errors.New("driver: bad connection")}, http.StatusInternalServerError},
+		{"of another task", author, &stubStore{comment: &types.Comment{ID: 7, TaskID: tk.ID + 1, AuthorID: ptr(author)}}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(tt.store, tasks, nil, nil, memberStore{})
+
+			r := httptest.NewRequest(http.MethodDelete, "/tasks/1/comments/7", nil)
+			r.Header.Set(workspace.Header, "1")
+			r = mux.SetURLVars(r, map[string]string{"task_id": strconv.Itoa(tk.ID), "comment_id": "7"})
+			r = r.WithContext(context.WithValue(r.Context(), auth.UserKey, tt.userID))
+			w := httptest.NewRecorder()
+			workspace.WithWorkspace(h.handleDeleteComment, memberStore{})(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+			if deleted := len(tt.store.deleted) == 1; deleted != (tt.wantStatus == http.StatusNoContent) {
+				t.Errorf("deleted %v", tt.store.deleted)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
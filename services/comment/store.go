@@ -0,0 +1,183 @@
+package comment
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/types"
+	"todo/utils"
+)
+
+var ErrCommentNotFound = errs.NotFound("comment_not_found", "comment not found")
+
+type Store struct {
+	db *dialect.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: dialect.Wrap(db)}
+}
+
+func (s *Store) GetCommentByID(ctx context.Context, commentID int) (*types.Comment, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, task_id, author_id, guest_name, body, created_at, updated_at, deleted_at FROM task_comments WHERE id = ? AND deleted_at IS NULL",
+		commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	c := new(types.Comment)
+	for rows.Next() {
+		c, err = scanRowsIntoComment(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
+
+	if c.ID == 0 {
+		return nil, ErrCommentNotFound
+	}
+
+	return c, nil
+}
+
+func (s *Store) GetPaginatedComments(ctx context.Context, taskID int, pagination utils.PaginationParams) ([]types.Comment, int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	var total int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM task_comments WHERE task_id = ? AND deleted_at IS NULL", taskID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`
+	SELECT id, task_id, author_id, guest_name, body, created_at, updated_at, deleted_at
+	FROM task_comments
+	WHERE task_id = ? AND deleted_at IS NULL
+	ORDER BY created_at %s, id %s
+	LIMIT ? OFFSET ?`, pagination.Order, pagination.Order)
+
+	rows, err := s.db.QueryContext(ctx, query, taskID, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	comments := []types.Comment{}
+	for rows.Next() {
+		c, err := scanRowsIntoComment(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		comments = append(comments, *c)
+	}
+
+	return comments, total, errs.FromContext(ctx, rows.Err())
+}
+
+func (s *Store) GetCommentRevisions(ctx context.Context, commentID int) ([]types.CommentRevision, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, comment_id, editor_id, body, created_at FROM task_comment_revisions WHERE comment_id = ? ORDER BY id DESC",
+		commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []types.CommentRevision{}
+	for rows.Next() {
+		var rev types.CommentRevision
+		if err := rows.Scan(&rev.ID, &rev.CommentID, &rev.EditorID, &rev.Body, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, errs.FromContext(ctx, rows.Err())
+}
+
+func (s *Store) CreateComment(ctx context.Context, comment types.Comment) (int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	id, err := s.db.InsertContext(ctx,
+		"INSERT INTO task_comments (task_id, author_id, guest_name, body) VALUES (?, ?, ?, ?)",
+		comment.TaskID, comment.AuthorID, comment.GuestName, comment.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// UpdateComment replaces the body and keeps the previous one as a revision.
+func (s *Store) UpdateComment(ctx context.Context, commentID, editorID int, body string) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+	INSERT INTO task_comment_revisions (comment_id, editor_id, body)
+	SELECT id, ?, body FROM task_comments WHERE id = ? AND deleted_at IS NULL`,
+		editorID, commentID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE task_comments SET body = ? WHERE id = ? AND deleted_at IS NULL", body, commentID)
+	if err != nil {
+		return err
+	}
+
+	return errs.FromContext(ctx, tx.Commit())
+}
+
+func (s *Store) DeleteComment(ctx context.Context, commentID int) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "UPDATE task_comments SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL", commentID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func scanRowsIntoComment(rows *sql.Rows) (*types.Comment, error) {
+	c := new(types.Comment)
+
+	err := rows.Scan(
+		&c.ID,
+		&c.TaskID,
+		&c.AuthorID,
+		&c.GuestName,
+		&c.Body,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+		&c.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
@@ -0,0 +1,71 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"todo/services/storetest"
+	"todo/services/task"
+	"todo/types"
+	"todo/utils"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.SQLite(t)
+	store := NewStore(db)
+
+	userIDs, workspaceIDs := storetest.Seed(t, db)
+	description := ""
+	tk, err := task.NewStore(db).CreateTask(context.Background(), types.CreateTaskPayload{
+		UserID: &userIDs[0], Title: "task", Description: &description, Priority: 2, WorkspaceID: workspaceIDs[0],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []int{}
+	for _, body := range []string{"first", "second", "third"} {
+		id, err := store.CreateComment(ctx, types.Comment{TaskID: tk.ID, AuthorID: &userIDs[0], Body: body})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := store.UpdateComment(ctx, ids[0], userIDs[1], "first, edited"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := store.GetCommentByID(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Body != "first, edited" || c.TaskID != tk.ID || *c.AuthorID != userIDs[0] {
+		t.Errorf("edited comment = %+v", c)
+	}
+	revisions, err := store.GetCommentRevisions(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 1 || revisions[0].Body != "first" || *revisions[0].EditorID != userIDs[1] {
+		t.Errorf("revisions = %+v, want the body before the edit", revisions)
+	}
+
+	if n, err := store.DeleteComment(ctx, ids[1]); err != nil || n != 1 {
+		t.Fatalf("DeleteComment() = %d, %v, want 1", n, err)
+	}
+	if n, err := store.DeleteComment(ctx, ids[1]); err != nil || n != 0 {
+		t.Errorf("DeleteComment() of a deleted comment = %d, %v, want 0", n, err)
+	}
+	if _, err := store.GetCommentByID(ctx, ids[1]); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("GetCommentByID() of a deleted comment = %v, want %v", err, ErrCommentNotFound)
+	}
+
+	comments, total, err := store.GetPaginatedComments(ctx, tk.ID, utils.PaginationParams{Limit: 1, Offset: 1, Order: "asc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(comments) != 1 || comments[0].ID != ids[2] {
+		t.Errorf("page 2 = %+v of %d, want comment %d of 2", comments, total, ids[2])
+	}
+}
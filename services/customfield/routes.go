@@ -0,0 +1,184 @@
+package customfield
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"todo/errs"
+	"todo/services/auth"
+	"todo/types"
+	"todo/utils"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	errFieldExists    = errs.Conflict("custom_field_exists", "custom field already exists")
+	errInvalidKey     = errs.Validation("invalid_custom_field_key", "field keys use lowercase letters, digits and underscores")
+	errInvalidOptions = errs.Validation("invalid_custom_field_options", "invalid custom field options")
+)
+
+type Handler struct {
+	store     types.CustomFieldStore
+	userStore types.UserStore
+}
+
+func NewHandler(store types.CustomFieldStore, userStore types.UserStore) *Handler {
+	return &Handler{store: store, userStore: userStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/custom-fields", auth.WithJWTAuth(h.handleGetFields, h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/custom-fields", auth.WithJWTAuth(h.handleCreateField, h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/custom-fields/{key}", auth.WithJWTAuth(h.handleUpdateField, h.userStore)).Methods(http.MethodPut)
+	router.HandleFunc("/custom-fields/{key}", auth.WithJWTAuth(h.handleDeleteField, h.userStore)).Methods(http.MethodDelete)
+}
+
+func (h *Handler) handleGetFields(w http.ResponseWriter, r *http.Request) {
+	fields, err := h.store.GetCustomFields(r.Context(), auth.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, fields)
+}
+
+func (h *Handler) handleCreateField(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload types.CreateCustomFieldPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	if !keyPattern.MatchString(payload.Key) {
+		utils.WriteError(w, http.StatusBadRequest, errInvalidKey)
+		return
+	}
+	if err := checkOptions(payload.Type, payload.Options); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	_, err := h.store.GetCustomFieldByKey(r.Context(), userID, payload.Key)
+	if err == nil {
+		utils.WriteError(w, http.StatusConflict, errFieldExists.Errorf("custom field %s already exists", payload.Key))
+		return
+	}
+	if !errors.Is(err, ErrFieldNotFound) {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, err = h.store.CreateCustomField(r.Context(), types.CustomField{
+		UserID:   userID,
+		Key:      payload.Key,
+		Name:     payload.Name,
+		Type:     payload.Type,
+		Options:  payload.Options,
+		Required: payload.Required,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.writeField(w, r, userID, payload.Key, http.StatusCreated)
+}
+
+func (h *Handler) handleUpdateField(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	key := mux.Vars(r)["key"]
+
+	field, err := h.store.GetCustomFieldByKey(r.Context(), userID, key)
+	if errors.Is(err, ErrFieldNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var payload types.UpdateCustomFieldPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	if err := checkOptions(field.Type, payload.Options); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	field.Name = payload.Name
+	field.Options = payload.Options
+	field.Required = payload.Required
+	if err := h.store.UpdateCustomField(r.Context(), *field); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.writeField(w, r, userID, key, http.StatusOK)
+}
+
+func (h *Handler) handleDeleteField(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	rowsAffected, err := h.store.DeleteCustomField(r.Context(), auth.GetUserIDFromContext(r.Context()), key)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete custom field: %v", err))
+		return
+	}
+	if rowsAffected == 0 {
+		utils.WriteError(w, http.StatusNotFound, ErrFieldNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) writeField(w http.ResponseWriter, r *http.Request, userID int, key string, status int) {
+	field, err := h.store.GetCustomFieldByKey(r.Context(), userID, key)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, status, field)
+}
+
+// checkOptions makes sure choice fields have distinct options and other fields none at all.
+func checkOptions(fieldType string, options []string) error {
+	if fieldType != TypeSelect && fieldType != TypeMultiSelect {
+		if len(options) > 0 {
+			return errInvalidOptions.Errorf("options are only allowed for select and multi_select fields")
+		}
+		return nil
+	}
+
+	if len(options) == 0 {
+		return errInvalidOptions.Errorf("%s fields need at least one option", fieldType)
+	}
+	seen := map[string]bool{}
+	for _, o := range options {
+		if seen[o] {
+			return errInvalidOptions.Errorf("option %s is listed twice", o)
+		}
+		seen[o] = true
+	}
+
+	return nil
+}
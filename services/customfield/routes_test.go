@@ -0,0 +1,63 @@
+package customfield
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo/services/auth"
+	"todo/types"
+
+	"github.com/gorilla/mux"
+)
+
+// failingStore fails to look up fields.
+type failingStore struct {
+	types.CustomFieldStore
+	err error
+}
+
+func (s failingStore) GetCustomFieldByKey(ctx context.Context, userID int, key string) (*types.CustomField, error) {
+	return nil, s.err
+}
+
+func TestHandlerLookupErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"not found", ErrFieldNotFound, http.StatusNotFound},
+		{"database error", errors.New("driver: bad connection"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(failingStore{err: tt.err}, nil)
+
+			r := httptest.NewRequest(http.MethodPut, "/custom-fields/size", strings.NewReader(`{"name":"Size"}`))
+			r.Header.Set("Content-Type", "application/json")
+			r = mux.SetURLVars(r, map[string]string{"key": "size"})
+			r = r.WithContext(context.WithValue(r.Context(), auth.UserKey, 1))
+			w := httptest.NewRecorder()
+			h.handleUpdateField(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("update got %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+		})
+	}
+
+	// a failed lookup is no reason to create the field
+	h := NewHandler(failingStore{err: errors.New("driver: bad connection")}, nil)
+	r := httptest.NewRequest(http.MethodPost, "/custom-fields", strings.NewReader(`{"key":"size","name":"Size","type":"text"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r = r.WithContext(context.WithValue(r.Context(), auth.UserKey, 1))
+	w := httptest.NewRecorder()
+	h.handleCreateField(w, r)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("create got %d %s, want 500", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,187 @@
+package customfield
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/types"
+)
+
+var ErrFieldNotFound = errs.NotFound("custom_field_not_found", "custom field not found")
+
+type Store struct {
+	db *dialect.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: dialect.Wrap(db)}
+}
+
+func (s *Store) GetCustomFields(ctx context.Context, userID int) ([]types.CustomField, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, user_id, field_key, name, type, options, required, created_at FROM custom_fields WHERE user_id = ? ORDER BY id",
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fields := []types.CustomField{}
+	for rows.Next() {
+		f, err := scanRowsIntoCustomField(rows)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, *f)
+	}
+
+	return fields, errs.FromContext(ctx, rows.Err())
+}
+
+func (s *Store) GetCustomFieldByKey(ctx context.Context, userID int, key string) (*types.CustomField, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, user_id, field_key, name, type, options, required, created_at FROM custom_fields WHERE user_id = ? AND field_key = ?",
+		userID, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	f := new(types.CustomField)
+	for rows.Next() {
+		f, err = scanRowsIntoCustomField(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
+
+	if f.ID == 0 {
+		return nil, ErrFieldNotFound
+	}
+
+	return f, nil
+}
+
+func (s *Store) CreateCustomField(ctx context.Context, field types.CustomField) (int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	options, err := marshalOptions(field.Options)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := s.db.InsertContext(ctx,
+		"INSERT INTO custom_fields (user_id, field_key, name, type, options, required) VALUES (?, ?, ?, ?, ?, ?)",
+		field.UserID, field.Key, field.Name, field.Type, options, field.Required)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// UpdateCustomField changes name, options and required flag, key and type stay as they are.
+func (s *Store) UpdateCustomField(ctx context.Context, field types.CustomField) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	options, err := marshalOptions(field.Options)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		"UPDATE custom_fields SET name = ?, options = ?, required = ? WHERE id = ?",
+		field.Name, options, field.Required, field.ID)
+
+	return err
+}
+
+// DeleteCustomField removes the definition together with the values stored on the user's tasks.
+func (s *Store) DeleteCustomField(ctx context.Context, userID int, key string) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM custom_fields WHERE user_id = ? AND field_key = ?", userID, key)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil || rowsAffected == 0 {
+		return rowsAffected, err
+	}
+
+	path := fmt.Sprintf(`$."%s"`, key)
+	query, args := "UPDATE tasks SET custom_fields = JSON_REMOVE(custom_fields, ?) WHERE user_id = ? AND JSON_CONTAINS_PATH(custom_fields, 'one', ?)", []any{path, userID, path}
+	switch tx.Dialect() {
+	case dialect.Postgres:
+		query, args = "UPDATE tasks SET custom_fields = custom_fields - ?::text WHERE user_id = ? AND custom_fields -> ? IS NOT NULL", []any{key, userID, key}
+	case dialect.SQLite:
+		query = "UPDATE tasks SET custom_fields = json_remove(custom_fields, ?) WHERE user_id = ? AND json_type(custom_fields, ?) IS NOT NULL"
+	}
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return 0, err
+	}
+
+	return rowsAffected, errs.FromContext(ctx, tx.Commit())
+}
+
+func marshalOptions(options []string) (any, error) {
+	if len(options) == 0 {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+func scanRowsIntoCustomField(rows *sql.Rows) (*types.CustomField, error) {
+	f := new(types.CustomField)
+	var options []byte
+
+	err := rows.Scan(
+		&f.ID,
+		&f.UserID,
+		&f.Key,
+		&f.Name,
+		&f.Type,
+		&options,
+		&f.Required,
+		&f.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if options != nil {
+		if err := json.Unmarshal(options, &f.Options); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
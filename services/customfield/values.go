@@ -0,0 +1,192 @@
+package customfield
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+	"todo/errs"
+	"todo/types"
+)
+
+const (
+	TypeText        = "text"
+	TypeNumber      = "number"
+	TypeDate        = "date"
+	TypeSelect      = "select"
+	TypeMultiSelect = "multi_select"
+	TypeCheckbox    = "checkbox"
+)
+
+const (
+	dateLayout    = "2006-01-02"
+	maxTextLength = 1000
+)
+
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ErrInvalidValue is returned for values that do not fit the field definitions.
+var ErrInvalidValue = errs.Validation("invalid_custom_field_value", "invalid custom field value")
+
+// Merge applies changes on top of current without modifying either, a nil value removes the field.
+func Merge(current, changes map[string]any) map[string]any {
+	merged := make(map[string]any, len(current)+len(changes))
+	for key, value := range current {
+		merged[key] = value
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+
+	return merged
+}
+
+// Validate checks values against the field definitions and returns them normalized,
+// unknown keys and missing required fields are errors.
+func Validate(fields []types.CustomField, values map[string]any) (map[string]any, error) {
+	byKey := make(map[string]types.CustomField, len(fields))
+	for _, f := range fields {
+		byKey[f.Key] = f
+	}
+
+	normalized := make(map[string]any, len(values))
+	for key, value := range values {
+		field, ok := byKey[key]
+		if !ok {
+			return nil, ErrInvalidValue.Errorf("unknown custom field: %s", key)
+		}
+		if value == nil {
+			continue
+		}
+
+		v, err := normalize(field, value)
+		if err != nil {
+			return nil, ErrInvalidValue.Errorf("custom field %s: %v", key, err)
+		}
+		normalized[key] = v
+	}
+
+	for _, f := range fields {
+		if f.Required && isEmpty(normalized[f.Key]) {
+			return nil, ErrInvalidValue.Errorf("custom field %s is required", f.Key)
+		}
+	}
+
+	return normalized, nil
+}
+
+// ParseFilterValue converts a query string value into the value stored for the field.
+func ParseFilterValue(field types.CustomField, raw string) (any, error) {
+	switch field.Type {
+	case TypeNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, ErrInvalidValue.Errorf("custom field %s expects a number", field.Key)
+		}
+		return n, nil
+	case TypeCheckbox:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, ErrInvalidValue.Errorf("custom field %s expects true or false", field.Key)
+		}
+		return b, nil
+	case TypeDate:
+		if _, err := time.Parse(dateLayout, raw); err != nil {
+			return nil, ErrInvalidValue.Errorf("custom field %s expects a date as YYYY-MM-DD", field.Key)
+		}
+	}
+
+	return raw, nil
+}
+
+func normalize(field types.CustomField, value any) (any, error) {
+	switch field.Type {
+	case TypeText:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string")
+		}
+		if len(s) > maxTextLength {
+			return nil, fmt.Errorf("longer than %d characters", maxTextLength)
+		}
+		return s, nil
+	case TypeNumber:
+		switch n := value.(type) {
+		case float64:
+			return n, nil
+		case int:
+			return float64(n), nil
+		}
+		return nil, fmt.Errorf("expected a number")
+	case TypeDate:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a date as YYYY-MM-DD")
+		}
+		d, err := time.Parse(dateLayout, s)
+		if err != nil {
+			return nil, fmt.Errorf("expected a date as YYYY-MM-DD")
+		}
+		return d.Format(dateLayout), nil
+	case TypeSelect:
+		s, ok := value.(string)
+		if !ok || !hasOption(field, s) {
+			return nil, fmt.Errorf("expected one of %v", field.Options)
+		}
+		return s, nil
+	case TypeMultiSelect:
+		list, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("expected a list of %v", field.Options)
+		}
+		selected := make([]any, 0, len(list))
+		seen := map[string]bool{}
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok || !hasOption(field, s) {
+				return nil, fmt.Errorf("expected a list of %v", field.Options)
+			}
+			if !seen[s] {
+				seen[s] = true
+				selected = append(selected, s)
+			}
+		}
+		return selected, nil
+	case TypeCheckbox:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected true or false")
+		}
+		return b, nil
+	}
+
+	return nil, fmt.Errorf("unsupported field type %s", field.Type)
+}
+
+func hasOption(field types.CustomField, option string) bool {
+	for _, o := range field.Options {
+		if o == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isEmpty treats a missing value, a blank text and an empty selection as not set, false is a valid checkbox value.
+func isEmpty(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	}
+
+	return false
+}
@@ -0,0 +1,66 @@
+package customfield
+
+import (
+	"reflect"
+	"testing"
+	"todo/types"
+)
+
+var testFields = []types.CustomField{
+	{Key: "points", Type: TypeNumber, Required: true},
+	{Key: "customer", Type: TypeText},
+	{Key: "launch", Type: TypeDate},
+	{Key: "size", Type: TypeSelect, Options: []string{"s", "m", "l"}},
+	{Key: "labels", Type: TypeMultiSelect, Options: []string{"ui", "api"}},
+	{Key: "billable", Type: TypeCheckbox},
+}
+
+func TestValidate(t *testing.T) {
+	values, err := Validate(testFields, map[string]any{
+		"points":   3.0,
+		"launch":   "2026-11-01",
+		"size":     "m",
+		"labels":   []any{"api", "ui", "api"},
+		"billable": false,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(values["labels"], []any{"api", "ui"}) {
+		t.Errorf("expected duplicate labels to be dropped, got %v", values["labels"])
+	}
+	if values["billable"] != false {
+		t.Errorf("expected false checkbox to be kept, got %v", values["billable"])
+	}
+}
+
+func TestValidateRejects(t *testing.T) {
+	cases := []map[string]any{
+		{"customer": "acme"},                  // required points missing
+		{"points": "3"},                       // wrong type
+		{"points": 1.0, "unknown": "x"},       // not defined
+		{"points": 1.0, "launch": "01/11"},    // bad date
+		{"points": 1.0, "size": "xl"},         // not an option
+		{"points": 1.0, "labels": []any{1.0}}, // not an option
+	}
+
+	for _, values := range cases {
+		if _, err := Validate(testFields, values); err == nil {
+			t.Errorf("Validate(%v) should fail", values)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	current := map[string]any{"points": 3.0, "customer": "acme"}
+
+	merged := Merge(current, map[string]any{"points": 5.0, "customer": nil})
+
+	if !reflect.DeepEqual(merged, map[string]any{"points": 5.0}) {
+		t.Errorf("unexpected merge result: %v", merged)
+	}
+	if current["customer"] != "acme" {
+		t.Error("expected the current values to be left untouched")
+	}
+}
@@ -0,0 +1,92 @@
+package history
+
+import (
+	"reflect"
+	"time"
+	"todo/types"
+)
+
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionRevert  = "revert"
+)
+
+// NewTaskEvent builds an event with the field-level diff between before and after.
+// before is nil for creations, the snapshot is taken from after.
+func NewTaskEvent(action string, actorID int, before, after *types.Task) types.TaskEvent {
+	event := types.TaskEvent{
+		TaskID:   after.ID,
+		Action:   action,
+		Changes:  Diff(before, after),
+		Snapshot: *after,
+	}
+	if actorID > 0 {
+		event.ActorID = &actorID
+	}
+
+	return event
+}
+
+// Diff returns the tracked fields whose value differs between before and after.
+func Diff(before, after *types.Task) map[string]types.FieldChange {
+	changes := map[string]types.FieldChange{}
+
+	var old map[string]any
+	if before != nil {
+		old = trackedFields(before)
+	}
+
+	current := trackedFields(after)
+	for field, value := range current {
+		previous := old[field]
+		if !reflect.DeepEqual(previous, value) {
+			changes[field] = types.FieldChange{Before: previous, After: value}
+		}
+	}
+
+	// custom fields that were cleared only show up on the before side
+	for field, previous := range old {
+		if _, ok := current[field]; !ok {
+			changes[field] = types.FieldChange{Before: previous, After: nil}
+		}
+	}
+
+	return changes
+}
+
+// trackedFields flattens a task into comparable values, pointers become their value or nil
+// and every custom field is tracked on its own as custom_fields.<key>.
+func trackedFields(t *types.Task) map[string]any {
+	fields := map[string]any{
+		"user_id":     nil,
+		"title":       t.Title,
+		"description": t.Description,
+		"status":      t.Status,
+		"priority":    t.Priority,
+		"due_date":    nil,
+
+		"checklist_auto_complete": t.ChecklistAutoComplete,
+		"assignee_ids":            nil,
+		"labels":                  nil,
+	}
+	if t.UserID != nil {
+		fields["user_id"] = *t.UserID
+	}
+	if t.DueDate != nil {
+		fields["due_date"] = t.DueDate.UTC().Format(time.RFC3339)
+	}
+	if len(t.AssigneeIDs) > 0 {
+		fields["assignee_ids"] = t.AssigneeIDs
+	}
+	if len(t.Labels) > 0 {
+		fields["labels"] = t.Labels
+	}
+	for key, value := range t.CustomFields {
+		fields["custom_fields."+key] = value
+	}
+
+	return fields
+}
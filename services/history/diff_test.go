@@ -0,0 +1,73 @@
+package history
+
+import (
+	"testing"
+	"time"
+	"todo/types"
+)
+
+func TestDiffOnCreate(t *testing.T) {
+	task := &types.Task{ID: 1, Title: "write docs", Status: "pending", Priority: 2}
+
+	changes := Diff(nil, task)
+
+	if changes["title"].After != "write docs" {
+		t.Errorf("expected title to be recorded, got %v", changes["title"])
+	}
+	if _, ok := changes["user_id"]; ok {
+		t.Error("expected unset user_id to be left out of the diff")
+	}
+}
+
+func TestDiffOnUpdate(t *testing.T) {
+	userID := 7
+	due := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	before := &types.Task{ID: 1, Title: "write docs", Status: "pending", Priority: 2, DueDate: &due}
+	after := &types.Task{ID: 1, UserID: &userID, Title: "write docs", Status: "completed", Priority: 2}
+
+	changes := Diff(before, after)
+
+	if len(changes) != 3 {
+		t.Errorf("expected 3 changes, got %d: %v", len(changes), changes)
+	}
+	if c := changes["status"]; c.Before != "pending" || c.After != "completed" {
+		t.Errorf("unexpected status change: %v", c)
+	}
+	if c := changes["user_id"]; c.Before != nil || c.After != 7 {
+		t.Errorf("unexpected user_id change: %v", c)
+	}
+	if c := changes["due_date"]; c.Before != "2026-01-02T03:04:05Z" || c.After != nil {
+		t.Errorf("unexpected due_date change: %v", c)
+	}
+}
+
+func TestDiffOnCustomFields(t *testing.T) {
+	before := &types.Task{ID: 1, CustomFields: map[string]any{"points": 3.0, "labels": []any{"ui"}}}
+	after := &types.Task{ID: 1, CustomFields: map[string]any{"labels": []any{"ui"}, "customer": "acme"}}
+
+	changes := Diff(before, after)
+
+	if len(changes) != 2 {
+		t.Errorf("expected 2 changes, got %d: %v", len(changes), changes)
+	}
+	if c := changes["custom_fields.points"]; c.Before != 3.0 || c.After != nil {
+		t.Errorf("unexpected points change: %v", c)
+	}
+	if c := changes["custom_fields.customer"]; c.Before != nil || c.After != "acme" {
+		t.Errorf("unexpected customer change: %v", c)
+	}
+}
+
+func TestDiffOnAssignees(t *testing.T) {
+	before := &types.Task{ID: 1, AssigneeIDs: []int{2, 3}}
+	after := &types.Task{ID: 1, AssigneeIDs: []int{}}
+
+	changes := Diff(before, after)
+
+	if c, ok := changes["assignee_ids"]; !ok || c.After != nil {
+		t.Errorf("expected assignees to be cleared, got %v", changes)
+	}
+	if len(Diff(after, after)) != 0 {
+		t.Error("expected no changes between equal tasks")
+	}
+}
@@ -0,0 +1,218 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"todo/errs"
+	"todo/services/auth"
+	"todo/services/customfield"
+	"todo/services/notification"
+	"todo/services/workflow"
+	"todo/services/workspace"
+	"todo/types"
+	"todo/utils"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	errVersionOutdated = errs.Conflict("version_outdated", "this version no longer fits the task")
+	errRevertConflict  = errs.Conflict("version_conflict", "task was changed while reverting, try again")
+)
+
+type Handler struct {
+	store          types.TaskEventStore
+	taskStore      types.TaskStore
+	workflowStore  types.WorkflowStore
+	fieldStore     types.CustomFieldStore
+	userStore      types.UserStore
+	notifier       *notification.Notifier
+	workspaceStore types.WorkspaceStore
+}
+
+func NewHandler(store types.TaskEventStore, taskStore types.TaskStore, workflowStore types.WorkflowStore, fieldStore types.CustomFieldStore, userStore types.UserStore, notifier *notification.Notifier, workspaceStore types.WorkspaceStore) *Handler {
+	return &Handler{store: store, taskStore: taskStore, workflowStore: workflowStore, fieldStore: fieldStore, userStore: userStore, notifier: notifier, workspaceStore: workspaceStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/tasks/{task_id}/history", auth.WithJWTAuth(workspace.WithWorkspace(h.handleGetHistory, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{task_id}/history/{event_id}/revert", auth.WithJWTAuth(workspace.WithWorkspace(h.handleRevert, h.workspaceStore), h.userStore)).Methods(http.MethodPost)
+}
+
+func (h *Handler) handleGetHistory(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(mux.Vars(r)["task_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid task ID"))
+		return
+	}
+
+	// the history of trashed tasks stays readable
+	workspaceID, err := h.taskStore.GetTaskWorkspaceID(r.Context(), taskID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if workspaceID == 0 || workspaceID != workspace.IDFromContext(r.Context()) {
+		utils.WriteError(w, http.StatusNotFound, types.ErrTaskNotFound)
+		return
+	}
+
+	pagination, err := utils.ParsePaginationParams(r, []string{"created_at"})
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	events, total, err := h.store.GetPaginatedTaskEvents(r.Context(), taskID, pagination)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get history: %v", err))
+		return
+	}
+
+	utils.WritePaginatedResponse(w, pagination.Page, pagination.Limit, total, events)
+}
+
+// handleRevert restores every tracked field of the task to the snapshot stored in the given event.
+func (h *Handler) handleRevert(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	taskID, err := strconv.Atoi(vars["task_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid task ID"))
+		return
+	}
+
+	eventID, err := strconv.Atoi(vars["event_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid event ID"))
+		return
+	}
+
+	existingTask, err := h.taskStore.GetTaskByID(r.Context(), taskID)
+	if errors.Is(err, types.ErrTaskNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !workspace.Contains(r.Context(), existingTask) {
+		utils.WriteError(w, http.StatusNotFound, types.ErrTaskNotFound)
+		return
+	}
+
+	event, err := h.store.GetTaskEventByID(r.Context(), eventID)
+	if errors.Is(err, ErrEventNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if event.TaskID != taskID {
+		utils.WriteError(w, http.StatusNotFound, ErrEventNotFound)
+		return
+	}
+
+	version := event.Snapshot
+	if version.UserID != nil {
+		if !h.checkMember(w, r, existingTask.WorkspaceID, *version.UserID) {
+			return
+		}
+	}
+
+	// versions recorded before assignees or labels existed leave the current ones alone
+	var assigneeIDs *[]int
+	if version.AssigneeIDs != nil {
+		for _, id := range version.AssigneeIDs {
+			if !h.checkMember(w, r, existingTask.WorkspaceID, id) {
+				return
+			}
+		}
+		assigneeIDs = &version.AssigneeIDs
+	}
+	var labels *[]string
+	if version.Labels != nil {
+		labels = &version.Labels
+	}
+
+	// reverting skips the transition rules, but the status still has to exist in the owner's workflow
+	wf, err := h.workflowStore.GetWorkflow(r.Context(), version.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if workflow.FindStatus(wf, version.Status) == nil {
+		utils.WriteError(w, http.StatusConflict, errVersionOutdated.Errorf("status %s from this version no longer exists", version.Status))
+		return
+	}
+
+	var fields []types.CustomField
+	if version.UserID != nil {
+		if fields, err = h.fieldStore.GetCustomFields(r.Context(), *version.UserID); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	customFields, err := customfield.Validate(fields, version.CustomFields)
+	if err != nil {
+		utils.WriteError(w, http.StatusConflict, errVersionOutdated.Errorf("this version no longer fits the custom fields: %v", err))
+		return
+	}
+
+	updated, err := h.taskStore.UpdateTask(r.Context(), taskID, existingTask.Version, types.UpdateTaskPayload{
+		UserID:      version.UserID,
+		Title:       &version.Title,
+		Description: &version.Description,
+		Status:      &version.Status,
+		Priority:    &version.Priority,
+		DueDate:     version.DueDate,
+
+		ChecklistAutoComplete: &version.ChecklistAutoComplete,
+		CustomFields:          customFields,
+		AssigneeIDs:           assigneeIDs,
+		Labels:                labels,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if updated == 0 {
+		utils.WriteError(w, http.StatusConflict, errRevertConflict)
+		return
+	}
+
+	updatedTask, err := h.taskStore.GetTaskByID(r.Context(), taskID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	actorID := auth.GetUserIDFromContext(r.Context())
+	revertEvent := NewTaskEvent(ActionRevert, actorID, existingTask, updatedTask)
+	if err := h.store.CreateTaskEvent(r.Context(), revertEvent); err != nil {
+		log.Printf("failed to record task event: %v", err)
+	}
+	h.notifier.NotifyTaskEvent(revertEvent)
+
+	utils.WriteJson(w, http.StatusOK, updatedTask)
+}
+
+// checkMember writes a 409 when a user of the reverted version has left the workspace or no longer exists.
+func (h *Handler) checkMember(w http.ResponseWriter, r *http.Request, workspaceID, userID int) bool {
+	member, err := h.workspaceStore.GetMember(r.Context(), workspaceID, userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	if member == nil {
+		utils.WriteError(w, http.StatusConflict, errVersionOutdated.Errorf("user %d from this version is no longer a member of this workspace", userID))
+		return false
+	}
+
+	return true
+}
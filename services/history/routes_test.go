@@ -0,0 +1,84 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"todo/errs"
+	"todo/services/auth"
+	"todo/services/workspace"
+	"todo/types"
+
+	"github.com/gorilla/mux"
+)
+
+// memberStore makes everyone a member of every workspace.
+type memberStore struct {
+	types.WorkspaceStore
+}
+
+func (memberStore) GetMember(ctx context.Context, workspaceID, userID int) (*types.WorkspaceMember, error) {
+	return &types.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: workspace.RoleMember}, nil
+}
+
+// taskStore holds a single task.
+type taskStore struct {
+	types.TaskStore
+	task types.Task
+}
+
+func (s taskStore) GetTaskByID(ctx context.Context, taskID int) (*types.Task, error) {
+	if taskID != s.task.ID {
+		return nil, types.ErrTaskNotFound
+	}
+
+	t := s.task
+	return &t, nil
+}
+
+// eventStore fails to look up events, or returns the given one.
+type eventStore struct {
+	types.TaskEventStore
+	event *types.TaskEvent
+	err   error
+}
+
+func (s eventStore) GetTaskEventByID(ctx context.Context, eventID int) (*types.TaskEvent, error) {
+	return s.event, s.err
+}
+
+func TestHandleRevertLookupErrors(t *testing.T) {
+	userID := 1
+	tk := types.Task{ID: 7, UserID: &userID, WorkspaceID: 3}
+
+	tests := []struct {
+		name       string
+		store      eventStore
+		wantStatus int
+	}{
+		{"not found", eventStore{err: ErrEventNotFound}, http.StatusNotFound},
+		{"timeout", eventStore{err: errs.ErrQueryTimeout}, http.StatusGatewayTimeout},
+		{"database error", eventStore{err: errors.New("driver: bad connection")}, http.StatusInternalServerError},
+		{"of another task", eventStore{event: &types.TaskEvent{ID: 1, TaskID: tk.ID + 1}}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(tt.store, taskStore{task: tk}, nil, nil, nil, nil, memberStore{})
+
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.Header.Set(workspace.Header, strconv.Itoa(tk.WorkspaceID))
+			r = mux.SetURLVars(r, map[string]string{"task_id": strconv.Itoa(tk.ID), "event_id": "1"})
+			r = r.WithContext(context.WithValue(r.Context(), auth.UserKey, userID))
+			w := httptest.NewRecorder()
+			workspace.WithWorkspace(h.handleRevert, memberStore{})(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+		})
+	}
+}
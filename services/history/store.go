@@ -0,0 +1,130 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/types"
+	"todo/utils"
+)
+
+var ErrEventNotFound = errs.NotFound("event_not_found", "event not found")
+
+type Store struct {
+	db *dialect.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: dialect.Wrap(db)}
+}
+
+func (s *Store) GetTaskEventByID(ctx context.Context, eventID int) (*types.TaskEvent, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, task_id, actor_id, action, changes, snapshot, created_at FROM task_events WHERE id = ?", eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	e := new(types.TaskEvent)
+	for rows.Next() {
+		e, err = scanRowsIntoTaskEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
+
+	if e.ID == 0 {
+		return nil, ErrEventNotFound
+	}
+
+	return e, nil
+}
+
+func (s *Store) GetPaginatedTaskEvents(ctx context.Context, taskID int, pagination utils.PaginationParams) ([]types.TaskEvent, int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM task_events WHERE task_id = ?", taskID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// newest first, sort params are ignored on purpose
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT id, task_id, actor_id, action, changes, snapshot, created_at
+	FROM task_events
+	WHERE task_id = ?
+	ORDER BY id DESC
+	LIMIT ? OFFSET ?`, taskID, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := []types.TaskEvent{}
+	for rows.Next() {
+		e, err := scanRowsIntoTaskEvent(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, *e)
+	}
+
+	return events, total, errs.FromContext(ctx, rows.Err())
+}
+
+func (s *Store) CreateTaskEvent(ctx context.Context, event types.TaskEvent) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := json.Marshal(event.Snapshot)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		"INSERT INTO task_events (task_id, actor_id, action, changes, snapshot) VALUES (?, ?, ?, ?, ?)",
+		event.TaskID, event.ActorID, event.Action, changes, snapshot)
+
+	return err
+}
+
+func scanRowsIntoTaskEvent(rows *sql.Rows) (*types.TaskEvent, error) {
+	e := new(types.TaskEvent)
+	var changes, snapshot []byte
+
+	err := rows.Scan(
+		&e.ID,
+		&e.TaskID,
+		&e.ActorID,
+		&e.Action,
+		&changes,
+		&snapshot,
+		&e.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(changes, &e.Changes); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(snapshot, &e.Snapshot); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
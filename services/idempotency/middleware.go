@@ -0,0 +1,207 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"time"
+	"todo/configs"
+	"todo/errs"
+	"todo/services/auth"
+	"todo/services/workspace"
+	"todo/types"
+	"todo/utils"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+
+	defaultLeaseInSeconds = 60
+)
+
+var (
+	errKeyInUse   = errs.Conflict("idempotency_key_in_use", "a request with this Idempotency-Key is still in progress")
+	errKeyReused  = errs.New(http.StatusUnprocessableEntity, "idempotency_key_reused", "Idempotency-Key was already used for a different request")
+	errKeyTooLong = errs.Validation("idempotency_key_too_long", fmt.Sprintf("%s must be at most %d characters", HeaderKey, maxKeyLength))
+)
+
+// replayedHeaders are stored with the response and sent again on a replay.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag", "X-WIP-Warning"}
+
+// WithIdempotencyKey replays the stored response when a request is retried with the same
+// Idempotency-Key and payload. Keys are scoped to the authenticated user, reusing one for
+// a different request is rejected with 422. Server errors are not stored so they can be retried.
+// Unauthenticated requests are told apart by client and payload, see anonymousKey.
+func WithIdempotencyKey(handlerFunc http.HandlerFunc, store types.IdempotencyStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderKey)
+		if key == "" {
+			handlerFunc(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			utils.WriteError(w, http.StatusBadRequest, errKeyTooLong)
+			return
+		}
+
+		body, err := utils.ReadBody(r)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		if r.Body != nil {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		token, err := newToken()
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// a claim outlives the request, after that a crashed request no longer blocks retries
+		now := time.Now()
+		record := types.IdempotencyKey{
+			UserID:      auth.GetUserIDFromContext(r.Context()),
+			Key:         key,
+			RequestHash: requestHash(r, body),
+			ExpiresAt:   now.Add(time.Duration(configs.Envs.IdempotencyTTLInHours) * time.Hour),
+			LockedUntil: now.Add(claimLease()),
+			Token:       token,
+		}
+		if record.UserID <= 0 {
+			record.UserID, record.Key = 0, anonymousKey(r, key, record.RequestHash)
+		}
+
+		claimed, err := store.ClaimIdempotencyKey(r.Context(), record)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if !claimed {
+			replay(w, r, store, record)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		handlerFunc(rec, r)
+
+		// the handler may have used up the request deadline, the key is released or saved anyway
+		ctx := context.WithoutCancel(r.Context())
+
+		if rec.status >= http.StatusInternalServerError {
+			if err := store.DeleteIdempotencyKey(ctx, record.UserID, record.Key, record.Token); err != nil {
+				log.Printf("failed to release idempotency key: %v", err)
+			}
+			return
+		}
+
+		record.StatusCode = rec.status
+		record.Headers = map[string]string{}
+		for _, name := range replayedHeaders {
+			if value := w.Header().Get(name); value != "" {
+				record.Headers[name] = value
+			}
+		}
+		record.Body = rec.body.Bytes()
+		if err := store.SaveIdempotencyResponse(ctx, record); err != nil {
+			log.Printf("failed to store idempotent response: %v", err)
+		}
+	}
+}
+
+// replay answers a retry with the response stored for the key.
+func replay(w http.ResponseWriter, r *http.Request, store types.IdempotencyStore, record types.IdempotencyKey) {
+	stored, err := store.GetIdempotencyKey(r.Context(), record.UserID, record.Key)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	switch {
+	case stored == nil:
+		// the key expired or the first request failed in the meantime
+		utils.WriteError(w, http.StatusConflict, errKeyInUse.Errorf("a request with this %s is being retried, try again", HeaderKey))
+	case stored.RequestHash != record.RequestHash:
+		utils.WriteError(w, http.StatusUnprocessableEntity, errKeyReused)
+	case stored.StatusCode == 0:
+		utils.WriteError(w, http.StatusConflict, errKeyInUse)
+	default:
+		for name, value := range stored.Headers {
+			w.Header().Set(name, value)
+		}
+		w.Header().Set(HeaderReplayed, "true")
+		w.WriteHeader(stored.StatusCode)
+		w.Write(stored.Body)
+	}
+}
+
+// claimLease is how long a claim blocks retries, never less than the request deadline.
+// Without a deadline the configured lease alone counts, 0 falls back to a minute.
+func claimLease() time.Duration {
+	lease := max(configs.Envs.IdempotencyLockInSeconds, configs.Envs.RequestTimeoutInSeconds)
+	if lease <= 0 {
+		lease = defaultLeaseInSeconds
+	}
+
+	return time.Duration(lease) * time.Second
+}
+
+// anonymousKey scopes the key of an unauthenticated request, which all share user 0, to the
+// client address and the payload. Only an identical request from the same client is replayed,
+// callers that happen to pick the same key neither see nor block each other.
+func anonymousKey(r *http.Request, key, hash string) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	sum := sha256.Sum256([]byte(host + "\n" + hash + "\n" + key))
+	return "anonymous:" + hex.EncodeToString(sum[:])
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// requestHash identifies the request behind a key, the workspace is part of it
+// because the same body creates a task in whichever workspace is selected.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n%s\n", r.Method, r.URL.Path, r.Header.Get(workspace.Header))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder passes the response through and keeps a copy of it.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,211 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"todo/configs"
+	"todo/services/auth"
+	"todo/types"
+)
+
+type memoryStore struct {
+	keys map[string]types.IdempotencyKey
+}
+
+func (s *memoryStore) GetIdempotencyKey(ctx context.Context, userID int, key string) (*types.IdempotencyKey, error) {
+	k, ok := s.keys[fmt.Sprint(userID, key)]
+	if !ok {
+		return nil, nil
+	}
+	return &k, nil
+}
+
+func (s *memoryStore) ClaimIdempotencyKey(ctx context.Context, key types.IdempotencyKey) (bool, error) {
+	id := fmt.Sprint(key.UserID, key.Key)
+	if k, ok := s.keys[id]; ok && !(k.StatusCode == 0 && !k.LockedUntil.After(time.Now())) {
+		return false, nil
+	}
+	s.keys[id] = key
+	return true, nil
+}
+
+func (s *memoryStore) SaveIdempotencyResponse(ctx context.Context, key types.IdempotencyKey) error {
+	id := fmt.Sprint(key.UserID, key.Key)
+	if s.keys[id].Token == key.Token {
+		s.keys[id] = key
+	}
+	return nil
+}
+
+func (s *memoryStore) DeleteIdempotencyKey(ctx context.Context, userID int, key, token string) error {
+	id := fmt.Sprint(userID, key)
+	if s.keys[id].Token == token {
+		delete(s.keys, id)
+	}
+	return nil
+}
+
+func (s *memoryStore) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func authenticated(r *http.Request, userID int) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), auth.UserKey, userID))
+}
+
+func TestWithIdempotencyKey(t *testing.T) {
+	calls := 0
+	status := http.StatusCreated
+	handler := WithIdempotencyKey(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Location", "/tasks/1")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"id":%d}`, calls)
+	}, &memoryStore{keys: map[string]types.IdempotencyKey{}})
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		r := authenticated(httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body)), 1)
+		if key != "" {
+			r.Header.Set(HeaderKey, key)
+		}
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+
+	first := send("a", `{"title":"x"}`)
+	retry := send("a", `{"title":"x"}`)
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry got %d %s, want the first response", retry.Code, retry.Body)
+	}
+	if retry.Header().Get("Location") != "/tasks/1" || retry.Header().Get(HeaderReplayed) != "true" {
+		t.Errorf("retry headers = %v", retry.Header())
+	}
+
+	if w := send("a", `{"title":"y"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("reuse with another payload got %d, want 422", w.Code)
+	}
+
+	send("", `{"title":"x"}`)
+	send("", `{"title":"x"}`)
+	if calls != 3 {
+		t.Errorf("requests without a key ran %d times, want 3", calls)
+	}
+
+	// server errors release the key
+	status = http.StatusInternalServerError
+	send("b", `{}`)
+	status = http.StatusCreated
+	if w := send("b", `{}`); w.Code != http.StatusCreated || calls != 5 {
+		t.Errorf("retry after a server error got %d with %d calls", w.Code, calls)
+	}
+}
+
+func TestWithIdempotencyKeyStaleClaim(t *testing.T) {
+	calls := 0
+	store := &memoryStore{keys: map[string]types.IdempotencyKey{}}
+	handler := WithIdempotencyKey(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	}, store)
+
+	send := func() *httptest.ResponseRecorder {
+		r := authenticated(httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{}`)), 1)
+		r.Header.Set(HeaderKey, "a")
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+	hash := requestHash(httptest.NewRequest(http.MethodPost, "/tasks", nil), []byte(`{}`))
+
+	// a request in progress blocks the retry
+	store.keys[fmt.Sprint(1, "a")] = types.IdempotencyKey{Key: "a", RequestHash: hash, LockedUntil: time.Now().Add(time.Minute)}
+	if w := send(); w.Code != http.StatusConflict || calls != 0 {
+		t.Errorf("retry during the first request got %d with %d calls, want 409", w.Code, calls)
+	}
+
+	// a request that died without a response does not once its claim ran out
+	store.keys[fmt.Sprint(1, "a")] = types.IdempotencyKey{Key: "a", RequestHash: hash, LockedUntil: time.Now().Add(-time.Second)}
+	if w := send(); w.Code != http.StatusCreated || calls != 1 {
+		t.Errorf("retry after a stale claim got %d with %d calls, want 201", w.Code, calls)
+	}
+}
+
+func TestWithIdempotencyKeyTakenOver(t *testing.T) {
+	store := &memoryStore{keys: map[string]types.IdempotencyKey{}}
+	status := http.StatusCreated
+	handler := WithIdempotencyKey(func(w http.ResponseWriter, r *http.Request) {
+		// the claim ran out while the handler was busy and a retry took the key over
+		store.keys[fmt.Sprint(1, "a")] = types.IdempotencyKey{UserID: 1, Key: "a", Token: "retry"}
+		w.WriteHeader(status)
+	}, store)
+
+	for _, status = range []int{http.StatusCreated, http.StatusInternalServerError} {
+		r := authenticated(httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{}`)), 1)
+		r.Header.Set(HeaderKey, "a")
+		handler(httptest.NewRecorder(), r)
+
+		if k, ok := store.keys[fmt.Sprint(1, "a")]; !ok || k.Token != "retry" || k.StatusCode != 0 {
+			t.Errorf("after a %d the claim of the retry is %+v, want it untouched", status, k)
+		}
+	}
+}
+
+func TestWithIdempotencyKeyAnonymous(t *testing.T) {
+	calls := 0
+	handler := WithIdempotencyKey(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":%d}`, calls)
+	}, &memoryStore{keys: map[string]types.IdempotencyKey{}})
+
+	send := func(addr, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(body))
+		r.RemoteAddr = addr
+		r.Header.Set(HeaderKey, "a")
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+
+	first := send("10.0.0.1:1234", `{"email":"a@example.com"}`)
+	if w := send("10.0.0.1:5678", `{"email":"a@example.com"}`); w.Body.String() != first.Body.String() || calls != 1 {
+		t.Errorf("retry from the same client got %s with %d calls, want the first response", w.Body, calls)
+	}
+	if w := send("10.0.0.2:1234", `{"email":"a@example.com"}`); w.Code != http.StatusCreated || w.Header().Get(HeaderReplayed) != "" || calls != 2 {
+		t.Errorf("another client got %d %s with %d calls, want its own response", w.Code, w.Body, calls)
+	}
+	if w := send("10.0.0.1:1234", `{"email":"b@example.com"}`); w.Code != http.StatusCreated || calls != 3 {
+		t.Errorf("another payload under the same key got %d with %d calls, want its own response", w.Code, calls)
+	}
+}
+
+func TestClaimLease(t *testing.T) {
+	envs := configs.Envs
+	t.Cleanup(func() { configs.Envs = envs })
+
+	tests := []struct {
+		lock, request int64
+		want          time.Duration
+	}{
+		{60, 30, time.Minute},
+		{60, 120, 2 * time.Minute},
+		{60, 0, time.Minute},
+		{0, 0, defaultLeaseInSeconds * time.Second},
+	}
+
+	for _, tt := range tests {
+		configs.Envs.IdempotencyLockInSeconds, configs.Envs.RequestTimeoutInSeconds = tt.lock, tt.request
+		if got := claimLease(); got != tt.want {
+			t.Errorf("claimLease() with a %ds lock and a %ds request timeout = %v, want %v", tt.lock, tt.request, got, tt.want)
+		}
+	}
+}
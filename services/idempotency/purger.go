@@ -0,0 +1,36 @@
+package idempotency
+
+import (
+	"context"
+	"log"
+	"time"
+	"todo/types"
+)
+
+// Purger periodically removes expired keys, expired keys are already ignored before that.
+type Purger struct {
+	store    types.IdempotencyStore
+	interval time.Duration
+}
+
+func NewPurger(store types.IdempotencyStore, interval time.Duration) *Purger {
+	return &Purger{store: store, interval: interval}
+}
+
+// Run blocks until ctx is cancelled.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.store.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+			log.Printf("failed to purge idempotency keys: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
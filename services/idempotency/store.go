@@ -0,0 +1,130 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/types"
+)
+
+const keyColumns = `user_id, idempotency_key, request_hash, status_code, headers, body, expires_at, created_at`
+
+type Store struct {
+	db *dialect.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: dialect.Wrap(db)}
+}
+
+func (s *Store) GetIdempotencyKey(ctx context.Context, userID int, key string) (*types.IdempotencyKey, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+keyColumns+" FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ? AND expires_at > ?",
+		userID, key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var k *types.IdempotencyKey
+	for rows.Next() {
+		if k, err = scanRowsIntoKey(rows); err != nil {
+			return nil, err
+		}
+	}
+
+	return k, errs.FromContext(ctx, rows.Err())
+}
+
+// ClaimIdempotencyKey replaces an expired key or a claim whose request ended without storing a
+// response, the unique index decides between concurrent claims.
+func (s *Store) ClaimIdempotencyKey(ctx context.Context, key types.IdempotencyKey) (bool, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+	_, err := s.db.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ? AND (expires_at <= ? OR (status_code = 0 AND locked_until <= ?))",
+		key.UserID, key.Key, now, now)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		s.db.Dialect().InsertIgnore("INTO idempotency_keys (user_id, idempotency_key, request_hash, expires_at, locked_until, claim_token) VALUES (?, ?, ?, ?, ?, ?)"),
+		key.UserID, key.Key, key.RequestHash, key.ExpiresAt, key.LockedUntil, key.Token)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+func (s *Store) SaveIdempotencyResponse(ctx context.Context, key types.IdempotencyKey) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	headers, err := json.Marshal(key.Headers)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		"UPDATE idempotency_keys SET status_code = ?, headers = ?, body = ? WHERE user_id = ? AND idempotency_key = ? AND claim_token = ?",
+		key.StatusCode, string(headers), key.Body, key.UserID, key.Key, key.Token)
+	return err
+}
+
+func (s *Store) DeleteIdempotencyKey(ctx context.Context, userID int, key, token string) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ? AND claim_token = ?", userID, key, token)
+	return err
+}
+
+func (s *Store) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= ?", time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func scanRowsIntoKey(rows *sql.Rows) (*types.IdempotencyKey, error) {
+	k := new(types.IdempotencyKey)
+	var headers []byte
+
+	err := rows.Scan(
+		&k.UserID,
+		&k.Key,
+		&k.RequestHash,
+		&k.StatusCode,
+		&headers,
+		&k.Body,
+		&k.ExpiresAt,
+		&k.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if headers != nil {
+		if err := json.Unmarshal(headers, &k.Headers); err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
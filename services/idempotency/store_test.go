@@ -0,0 +1,84 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+	"todo/services/storetest"
+	"todo/types"
+)
+
+func TestClaimIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(storetest.SQLite(t))
+	now := time.Now()
+	key := types.IdempotencyKey{UserID: 1, Key: "a", RequestHash: "hash", ExpiresAt: now.Add(time.Hour), LockedUntil: now.Add(time.Minute)}
+
+	claim := func(key types.IdempotencyKey) bool {
+		t.Helper()
+
+		claimed, err := store.ClaimIdempotencyKey(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return claimed
+	}
+
+	if !claim(key) {
+		t.Fatal("first claim failed")
+	}
+	if claim(key) {
+		t.Error("claimed a key whose request is still running")
+	}
+
+	// the first request died, its claim ran out
+	stale := key
+	stale.Key, stale.LockedUntil = "b", now.Add(-time.Second)
+	if !claim(stale) || !claim(stale) {
+		t.Error("a claim past its lease was not taken over")
+	}
+
+	// a stored response is kept until the key expires, however old the claim
+	stale.StatusCode = 201
+	if err := store.SaveIdempotencyResponse(ctx, stale); err != nil {
+		t.Fatal(err)
+	}
+	if claim(stale) {
+		t.Error("claimed a key with a stored response")
+	}
+
+	expired := key
+	expired.Key, expired.ExpiresAt = "c", now.Add(-time.Second)
+	if !claim(expired) || !claim(expired) {
+		t.Error("an expired key was not taken over")
+	}
+}
+
+func TestIdempotencyKeyToken(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(storetest.SQLite(t))
+	now := time.Now()
+	first := types.IdempotencyKey{UserID: 1, Key: "a", RequestHash: "hash", ExpiresAt: now.Add(time.Hour), LockedUntil: now.Add(-time.Second), Token: "first"}
+	retry := first
+	retry.LockedUntil, retry.Token = now.Add(time.Minute), "retry"
+
+	for _, key := range []types.IdempotencyKey{first, retry} {
+		if claimed, err := store.ClaimIdempotencyKey(ctx, key); err != nil || !claimed {
+			t.Fatalf("claim %s = %v, %v", key.Token, claimed, err)
+		}
+	}
+
+	// the first request finishes after the retry took its key over
+	first.StatusCode = 201
+	if err := store.SaveIdempotencyResponse(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteIdempotencyKey(ctx, first.UserID, first.Key, first.Token); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.GetIdempotencyKey(ctx, 1, "a")
+	if err != nil || got == nil || got.StatusCode != 0 {
+		t.Errorf("key of the retry = %+v, %v, want its claim untouched", got, err)
+	}
+}
@@ -0,0 +1,246 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/netip"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"todo/errs"
+	"todo/types"
+)
+
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelInApp   = "in_app"
+)
+
+// ErrInvalidTarget is returned for an address a channel cannot deliver to.
+var ErrInvalidTarget = errs.Validation("invalid_target", "invalid notification target")
+
+// Message is a single notification addressed to a user through one channel.
+type Message struct {
+	UserID  int    `json:"user_id"`
+	TaskID  *int   `json:"task_id,omitempty"`
+	Target  string `json:"-"` // email address or webhook URL, unused by in-app delivery
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Channel delivers messages through one transport.
+type Channel interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	From     string
+}
+
+// smtpTimeout bounds a delivery whose context has no deadline of its own.
+const smtpTimeout = time.Minute
+
+type EmailChannel struct {
+	cfg SMTPConfig
+}
+
+func NewEmailChannel(cfg SMTPConfig) *EmailChannel {
+	return &EmailChannel{cfg: cfg}
+}
+
+// Send talks to the SMTP server until ctx is done. The subject is Q-encoded, user input
+// like a task title never starts a header of its own.
+func (c *EmailChannel) Send(ctx context.Context, msg Message) error {
+	if msg.Target == "" {
+		return fmt.Errorf("missing email address")
+	}
+	to, err := mail.ParseAddress(msg.Target)
+	if err != nil {
+		return fmt.Errorf("invalid email address: %v", err)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, smtpTimeout)
+		defer cancel()
+	}
+
+	body := strings.Join([]string{
+		"From: " + c.cfg.From,
+		"To: " + to.Address,
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		msg.Body,
+	}, "\r\n")
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(c.cfg.Host, c.cfg.Port))
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, c.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if c.cfg.User != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.cfg.User, c.cfg.Password, c.cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(c.cfg.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(body)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// WebhookChannel posts messages to https URLs of public hosts. Addresses are checked when
+// they are dialed, so neither a DNS name nor a redirect can point it at the loopback,
+// private or link-local networks the server sits in.
+type WebhookChannel struct {
+	client *http.Client
+}
+
+func NewWebhookChannel(timeout time.Duration) *WebhookChannel {
+	dialer := &net.Dialer{Timeout: timeout, Control: checkDialAddress}
+
+	return &WebhookChannel{client: &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: timeout},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return fmt.Errorf("too many redirects")
+			}
+			return CheckWebhookURL(req.URL.String())
+		},
+	}}
+}
+
+// CheckWebhookURL tells whether target may be used as a webhook: an https URL whose host,
+// when it is an IP address, is a public one.
+func CheckWebhookURL(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL")
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("webhook URL must use https")
+	}
+	if ip, err := netip.ParseAddr(u.Hostname()); err == nil && !isPublic(ip) {
+		return fmt.Errorf("webhook URL must not point to a private address")
+	}
+
+	return nil
+}
+
+// checkDialAddress runs with the resolved address of every connection a webhook opens.
+func checkDialAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !isPublic(addrPort.Addr()) {
+		return fmt.Errorf("webhook address %s is not public", addrPort.Addr())
+	}
+
+	return nil
+}
+
+func isPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, not covered by IsPrivate.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+func (c *WebhookChannel) Send(ctx context.Context, msg Message) error {
+	if msg.Target == "" {
+		return fmt.Errorf("missing webhook URL")
+	}
+	if err := CheckWebhookURL(msg.Target); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.Target, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+type InAppChannel struct {
+	store types.NotificationStore
+}
+
+func NewInAppChannel(store types.NotificationStore) *InAppChannel {
+	return &InAppChannel{store: store}
+}
+
+func (c *InAppChannel) Send(ctx context.Context, msg Message) error {
+	return c.store.CreateNotification(ctx, types.Notification{
+		UserID:  msg.UserID,
+		TaskID:  msg.TaskID,
+		Message: msg.Body,
+	})
+}
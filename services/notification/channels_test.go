@@ -0,0 +1,203 @@
+package notification
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP accepts one connection, answers every command with a success and returns the
+// DATA section it received.
+func fakeSMTP(t *testing.T) (host, port string, data <-chan string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+		reply := func(line string) {
+			w.WriteString(line + "\r\n")
+			w.Flush()
+		}
+
+		reply("220 localhost ready")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				var body strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					body.WriteString(line)
+				}
+				received <- body.String()
+				reply("250 queued")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	host, port, _ = net.SplitHostPort(ln.Addr().String())
+	return host, port, received
+}
+
+func TestEmailChannelEncodesSubject(t *testing.T) {
+	host, port, data := fakeSMTP(t)
+	channel := NewEmailChannel(SMTPConfig{Host: host, Port: port, From: "todo@example.com"})
+
+	err := channel.Send(context.Background(), Message{
+		Target:  "a@example.com",
+		Subject: "Task reminder: rent\r\nBcc: victim@example.com",
+		Body:    "due tomorrow",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := <-data
+	header, _, _ := strings.Cut(body, "\r\n\r\n")
+	for _, line := range strings.Split(header, "\r\n") {
+		if strings.HasPrefix(line, "Bcc:") {
+			t.Fatalf("the subject started a header of its own:\n%s", header)
+		}
+	}
+	if !strings.Contains(header, "Subject: =?utf-8?q?") {
+		t.Errorf("subject was not Q-encoded:\n%s", header)
+	}
+}
+
+func TestEmailChannelHonorsDeadline(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	// the server accepts but never greets
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	channel := NewEmailChannel(SMTPConfig{Host: host, Port: port, From: "todo@example.com"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := channel.Send(ctx, Message{Target: "a@example.com", Subject: "s", Body: "b"}); err == nil {
+		t.Fatal("Send() succeeded against a server that never answered")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send() returned after %v, past the context deadline", elapsed)
+	}
+}
+
+func TestCheckWebhookURL(t *testing.T) {
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"https://example.com/hook", true},
+		{"https://93.184.216.34/hook", true},
+		{"http://example.com/hook", false},
+		{"https:///hook", false},
+		{"https://127.0.0.1/hook", false},
+		{"https://[::1]/hook", false},
+		{"https://10.0.0.8/hook", false},
+		{"https://192.168.1.1/hook", false},
+		{"https://169.254.169.254/latest/meta-data", false},
+		{"https://100.64.0.1/hook", false},
+		{"https://[::ffff:127.0.0.1]/hook", false},
+		{"https://0.0.0.0/hook", false},
+	}
+
+	for _, tt := range tests {
+		if err := CheckWebhookURL(tt.url); (err == nil) != tt.ok {
+			t.Errorf("CheckWebhookURL(%q) = %v, want ok %v", tt.url, err, tt.ok)
+		}
+	}
+}
+
+func TestWebhookChannelRejectsPrivateAddresses(t *testing.T) {
+	called := false
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	// localhost is a name, it passes CheckWebhookURL and is caught when dialed
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	err := NewWebhookChannel(time.Second).Send(context.Background(), Message{Target: "https://localhost:" + port + "/hook"})
+	if err == nil || !strings.Contains(err.Error(), "not public") {
+		t.Errorf("Send() = %v, want the dial to be refused", err)
+	}
+	if called {
+		t.Error("the webhook reached a loopback server")
+	}
+}
+
+func TestWebhookChannelSend(t *testing.T) {
+	var contentType string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusGone)
+		}
+	}))
+	defer srv.Close()
+
+	// the test server only listens on loopback, route its certificate's name there and skip
+	// the dial check
+	client := srv.Client()
+	client.Transport.(*http.Transport).DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	channel := &WebhookChannel{client: client}
+
+	if err := channel.Send(context.Background(), Message{Target: "https://example.com/hook", Subject: "s"}); err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("content type = %q", contentType)
+	}
+	if err := channel.Send(context.Background(), Message{Target: "https://example.com/gone"}); err == nil {
+		t.Error("Send() accepted a 410 response")
+	}
+}
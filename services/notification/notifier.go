@@ -0,0 +1,108 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"todo/types"
+)
+
+// deliveryTimeout bounds one round of background deliveries.
+const deliveryTimeout = time.Minute
+
+var eventVerbs = map[string]string{
+	"create":  "created",
+	"update":  "updated",
+	"delete":  "moved to the trash",
+	"restore": "restored",
+	"revert":  "reverted",
+}
+
+// Notifier tells the watchers of a task what someone else did to it. Messages go
+// out in the background through each watcher's channel, failures are only logged.
+type Notifier struct {
+	watchers types.WatcherStore
+	channels map[string]Channel
+}
+
+func NewNotifier(watchers types.WatcherStore, channels map[string]Channel) *Notifier {
+	return &Notifier{watchers: watchers, channels: channels}
+}
+
+// NotifyTaskEvent describes a recorded task event to the watchers, updates without changes are skipped.
+func (n *Notifier) NotifyTaskEvent(event types.TaskEvent) {
+	if len(event.Changes) == 0 && event.Action == "update" {
+		return
+	}
+
+	actorID := 0
+	if event.ActorID != nil {
+		actorID = *event.ActorID
+	}
+
+	subject, body := describeEvent(event)
+	n.Notify(event.TaskID, actorID, subject, body)
+}
+
+// Notify sends the message to every watcher of the task except the actor.
+func (n *Notifier) Notify(taskID, actorID int, subject, body string) {
+	go n.deliver(taskID, actorID, subject, body)
+}
+
+func (n *Notifier) deliver(taskID, actorID int, subject, body string) {
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	defer cancel()
+
+	watchers, err := n.watchers.GetWatchers(ctx, taskID)
+	if err != nil {
+		log.Printf("failed to get watchers of task %d: %v", taskID, err)
+		return
+	}
+
+	for _, w := range watchers {
+		if w.UserID == actorID {
+			continue
+		}
+
+		channel, ok := n.channels[w.Channel]
+		if !ok {
+			log.Printf("channel %s is not configured, watcher %d of task %d is skipped", w.Channel, w.UserID, taskID)
+			continue
+		}
+
+		err := channel.Send(ctx, Message{
+			UserID:  w.UserID,
+			TaskID:  &taskID,
+			Target:  w.Target,
+			Subject: subject,
+			Body:    body,
+		})
+		if err != nil {
+			log.Printf("failed to notify watcher %d of task %d: %v", w.UserID, taskID, err)
+		}
+	}
+}
+
+func describeEvent(event types.TaskEvent) (string, string) {
+	verb, ok := eventVerbs[event.Action]
+	if !ok {
+		verb = event.Action
+	}
+
+	title := event.Snapshot.Title
+	subject := fmt.Sprintf("Task %s: %s", verb, title)
+	if event.Action != "update" && event.Action != "revert" {
+		return subject, fmt.Sprintf("%s was %s", title, verb)
+	}
+
+	fields := make([]string, 0, len(event.Changes))
+	for field := range event.Changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return subject, fmt.Sprintf("%s was %s, changed: %s", title, verb, strings.Join(fields, ", "))
+}
@@ -0,0 +1,31 @@
+package notification
+
+import (
+	"testing"
+	"todo/types"
+)
+
+func TestDescribeEvent(t *testing.T) {
+	event := types.TaskEvent{
+		Action:   "update",
+		Snapshot: types.Task{Title: "write docs"},
+		Changes: map[string]types.FieldChange{
+			"title":  {},
+			"status": {},
+		},
+	}
+
+	subject, body := describeEvent(event)
+
+	if subject != "Task updated: write docs" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+	if body != "write docs was updated, changed: status, title" {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	event.Action = "delete"
+	if _, body := describeEvent(event); body != "write docs was moved to the trash" {
+		t.Errorf("unexpected body %q", body)
+	}
+}
@@ -0,0 +1,73 @@
+package notification
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo/errs"
+	"todo/services/auth"
+	"todo/types"
+	"todo/utils"
+
+	"github.com/gorilla/mux"
+)
+
+var errNotificationNotFound = errs.NotFound("notification_not_found", "notification not found")
+
+type Handler struct {
+	store     types.NotificationStore
+	userStore types.UserStore
+}
+
+func NewHandler(store types.NotificationStore, userStore types.UserStore) *Handler {
+	return &Handler{store: store, userStore: userStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/notifications", auth.WithJWTAuth(h.handleGetNotifications, h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/notifications/{notification_id}/read", auth.WithJWTAuth(h.handleMarkRead, h.userStore)).Methods(http.MethodPost)
+}
+
+func (h *Handler) handleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	pagination, err := utils.ParsePaginationParams(r, []string{"created_at"})
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	notifications, total, err := h.store.GetNotificationsByUserID(r.Context(), userID, pagination)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get notifications: %v", err))
+		return
+	}
+
+	utils.WritePaginatedResponse(w, pagination.Page, pagination.Limit, total, notifications)
+}
+
+func (h *Handler) handleMarkRead(w http.ResponseWriter, r *http.Request) {
+	str, ok := mux.Vars(r)["notification_id"]
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("missing notification ID"))
+		return
+	}
+
+	notificationID, err := strconv.Atoi(str)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid notification ID"))
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	rowsAffected, err := h.store.MarkNotificationRead(r.Context(), notificationID, userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if rowsAffected == 0 {
+		utils.WriteError(w, http.StatusNotFound, errNotificationNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
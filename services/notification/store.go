@@ -0,0 +1,98 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/types"
+	"todo/utils"
+)
+
+type Store struct {
+	db *dialect.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: dialect.Wrap(db)}
+}
+
+func (s *Store) GetNotificationsByUserID(ctx context.Context, userID int, pagination utils.PaginationParams) ([]types.Notification, int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM notifications WHERE user_id = ?", userID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// newest first, sort params are ignored on purpose
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT id, user_id, task_id, message, read_at, created_at
+	FROM notifications
+	WHERE user_id = ?
+	ORDER BY created_at DESC, id DESC
+	LIMIT ? OFFSET ?`, userID, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	notifications := []types.Notification{}
+	for rows.Next() {
+		n, err := scanRowsIntoNotification(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		notifications = append(notifications, *n)
+	}
+
+	return notifications, total, errs.FromContext(ctx, rows.Err())
+}
+
+func (s *Store) CreateNotification(ctx context.Context, notification types.Notification) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO notifications (user_id, task_id, message) VALUES (?, ?, ?)",
+		notification.UserID, notification.TaskID, notification.Message)
+
+	return err
+}
+
+func (s *Store) MarkNotificationRead(ctx context.Context, notificationID, userID int) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx,
+		"UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND read_at IS NULL",
+		notificationID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	// mysql reports changed rows, so count matches separately to keep the call idempotent
+	var matched int64
+	err = s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM notifications WHERE id = ? AND user_id = ?", notificationID, userID).Scan(&matched)
+
+	return matched, err
+}
+
+func scanRowsIntoNotification(rows *sql.Rows) (*types.Notification, error) {
+	n := new(types.Notification)
+
+	err := rows.Scan(
+		&n.ID,
+		&n.UserID,
+		&n.TaskID,
+		&n.Message,
+		&n.ReadAt,
+		&n.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
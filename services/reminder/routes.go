@@ -0,0 +1,172 @@
+package reminder
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"todo/errs"
+	"todo/services/auth"
+	"todo/services/notification"
+	"todo/services/workspace"
+	"todo/types"
+	"todo/utils"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	errNoDueDate = errs.Validation("no_due_date", "task has no due date to offset from")
+	errNotOwner  = errs.Forbidden("permission_denied", "only the reminder's user can delete it")
+)
+
+type Handler struct {
+	store          types.ReminderStore
+	taskStore      types.TaskStore
+	userStore      types.UserStore
+	workspaceStore types.WorkspaceStore
+}
+
+func NewHandler(store types.ReminderStore, taskStore types.TaskStore, userStore types.UserStore, workspaceStore types.WorkspaceStore) *Handler {
+	return &Handler{store: store, taskStore: taskStore, userStore: userStore, workspaceStore: workspaceStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/tasks/{task_id}/reminders", auth.WithJWTAuth(workspace.WithWorkspace(h.handleGetReminders, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{task_id}/reminders", auth.WithJWTAuth(workspace.WithWorkspace(h.handleCreateReminder, h.workspaceStore), h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/reminders/{reminder_id}", auth.WithJWTAuth(workspace.WithWorkspace(h.handleDeleteReminder, h.workspaceStore), h.userStore)).Methods(http.MethodDelete)
+}
+
+func (h *Handler) handleGetReminders(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	reminders, err := h.store.GetRemindersByTaskID(r.Context(), task.ID, userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get reminders: %v", err))
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, reminders)
+}
+
+func (h *Handler) handleCreateReminder(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	var payload types.CreateReminderPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	if payload.OffsetMinutes != nil && task.DueDate == nil {
+		utils.WriteError(w, http.StatusBadRequest, errNoDueDate)
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	switch payload.Channel {
+	case notification.ChannelEmail:
+		// default to the requesting user's own address
+		if payload.Target == "" {
+			u, err := h.userStore.GetUserByID(r.Context(), userID)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, err)
+				return
+			}
+			payload.Target = u.Email
+		}
+		if err := utils.Validate.Var(payload.Target, "email"); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, notification.ErrInvalidTarget.Errorf("invalid email target"))
+			return
+		}
+	case notification.ChannelWebhook:
+		if err := notification.CheckWebhookURL(payload.Target); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, notification.ErrInvalidTarget.Errorf("invalid webhook target: %v", err))
+			return
+		}
+	case notification.ChannelInApp:
+		payload.Target = ""
+	}
+
+	reminder := types.Reminder{
+		TaskID:        task.ID,
+		UserID:        userID,
+		RemindAt:      payload.RemindAt,
+		OffsetMinutes: payload.OffsetMinutes,
+		Channel:       payload.Channel,
+		Target:        payload.Target,
+	}
+
+	id, err := h.store.CreateReminder(r.Context(), reminder)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	created, err := h.store.GetReminderByID(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, created)
+}
+
+func (h *Handler) handleDeleteReminder(w http.ResponseWriter, r *http.Request) {
+	str, ok := mux.Vars(r)["reminder_id"]
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("missing reminder ID"))
+		return
+	}
+
+	reminderID, err := strconv.Atoi(str)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid reminder ID"))
+		return
+	}
+
+	reminder, err := h.store.GetReminderByID(r.Context(), reminderID)
+	if errors.Is(err, ErrReminderNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	task, err := h.taskStore.GetTaskByID(r.Context(), reminder.TaskID)
+	if err != nil && !errors.Is(err, types.ErrTaskNotFound) {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err != nil || !workspace.Contains(r.Context(), task) {
+		utils.WriteError(w, http.StatusNotFound, ErrReminderNotFound)
+		return
+	}
+
+	if reminder.UserID != auth.GetUserIDFromContext(r.Context()) {
+		utils.WriteError(w, http.StatusForbidden, errNotOwner)
+		return
+	}
+
+	if _, err := h.store.DeleteReminder(r.Context(), reminderID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete reminder: %v", err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,89 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"todo/services/auth"
+	"todo/services/task"
+	"todo/services/workspace"
+	"todo/types"
+
+	"github.com/gorilla/mux"
+)
+
+type stubStore struct {
+	types.ReminderStore
+	reminder *types.Reminder
+	err      error
+	deleted  []int
+}
+
+func (s *stubStore) GetReminderByID(ctx context.Context, reminderID int) (*types.Reminder, error) {
+	return s.reminder, s.err
+}
+
+func (s *stubStore) DeleteReminder(ctx context.Context, reminderID int) (int64, error) {
+	s.deleted = append(s.deleted, reminderID)
+	return 1, nil
+}
+
+// memberStore makes everyone a member of every workspace.
+type memberStore struct {
+	types.WorkspaceStore
+}
+
+func (memberStore) GetMember(ctx context.Context, workspaceID, userID int) (*types.WorkspaceMember, error) {
+	return &types.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: workspace.RoleMember}, nil
+}
+
+func TestHandleDeleteReminder(t *testing.T) {
+	tasks := task.NewMemoryStore()
+	tk, err := tasks.CreateTask(context.Background(), types.CreateTaskPayload{
+		UserID: ptr(1), Title: "task", Description: ptr(""), Priority: 2, WorkspaceID: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := tasks.CreateTask(context.Background(), types.CreateTaskPayload{
+		UserID: ptr(1), Title: "other", Description: ptr(""), Priority: 2, WorkspaceID: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		store      *stubStore
+		wantStatus int
+	}{
+		{"deleted", &stubStore{reminder: &types.Reminder{ID: 7, TaskID: tk.ID, UserID: 1}}, http.StatusNoContent},
+		{"not found", &stubStore{err: ErrReminderNotFound}, http.StatusNotFound},
+		{"database error", &stubStore{err: errors.New("driver: bad connection")}, http.StatusInternalServerError},
+		{"someone else's", &stubStore{reminder: &types.Reminder{ID: 7, TaskID: tk.ID, UserID: 2}}, http.StatusForbidden},
+		{"of another workspace", &stubStore{reminder: &types.Reminder{ID: 7, TaskID: other.ID, UserID: 1}}, http.StatusNotFound},
+		{"of a deleted task", &stubStore{reminder: &types.Reminder{ID: 7, TaskID: other.ID + 1, UserID: 1}}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(tt.store, tasks, nil, memberStore{})
+
+			r := httptest.NewRequest(http.MethodDelete, "/reminders/7", nil)
+			r.Header.Set(workspace.Header, "1")
+			r = mux.SetURLVars(r, map[string]string{"reminder_id": "7"})
+			r = r.WithContext(context.WithValue(r.Context(), auth.UserKey, 1))
+			w := httptest.NewRecorder()
+			workspace.WithWorkspace(h.handleDeleteReminder, memberStore{})(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+			if deleted := len(tt.store.deleted) == 1; deleted != (tt.wantStatus == http.StatusNoContent) {
+				t.Errorf("deleted %v", tt.store.deleted)
+			}
+		})
+	}
+}
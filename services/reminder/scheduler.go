@@ -0,0 +1,106 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"todo/services/notification"
+	"todo/types"
+)
+
+// Scheduler polls the database for due reminders and delivers them.
+// All state lives in task_reminders, so pending reminders survive restarts
+// and several API instances can run a scheduler side by side.
+type Scheduler struct {
+	store       types.ReminderStore
+	channels    map[string]notification.Channel
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+	timeout     time.Duration // per delivery, the lease of a batch outlasts all of them
+}
+
+func NewScheduler(store types.ReminderStore, channels map[string]notification.Channel, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		store:       store,
+		channels:    channels,
+		interval:    interval,
+		batchSize:   50,
+		maxAttempts: 5,
+		timeout:     30 * time.Second,
+	}
+}
+
+// Run blocks until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	// keep draining while full batches come back
+	for {
+		n, err := s.processBatch(ctx)
+		if err != nil {
+			log.Printf("failed to process reminders: %v", err)
+			return
+		}
+		if n < s.batchSize || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// processBatch claims a batch and delivers it outside of any transaction, so a slow channel
+// holds no locks. Each outcome is recorded on its own.
+func (s *Scheduler) processBatch(ctx context.Context) (int, error) {
+	lease := time.Duration(s.batchSize)*s.timeout + time.Minute
+	due, err := s.store.ClaimDueReminders(ctx, s.batchSize, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, d := range due {
+		deliverCtx, cancel := context.WithTimeout(ctx, s.timeout)
+		deliverErr := s.deliver(deliverCtx, d)
+		cancel()
+
+		if err := s.store.ReleaseReminder(ctx, d.Reminder.ID, deliverErr, s.maxAttempts); err != nil {
+			log.Printf("failed to record reminder %d: %v", d.Reminder.ID, err)
+		}
+	}
+
+	return len(due), nil
+}
+
+func (s *Scheduler) deliver(ctx context.Context, d types.DueReminder) error {
+	channel, ok := s.channels[d.Reminder.Channel]
+	if !ok {
+		return fmt.Errorf("channel %s is not configured", d.Reminder.Channel)
+	}
+
+	body := fmt.Sprintf("Reminder: %s", d.Task.Title)
+	if d.Task.DueDate != nil {
+		body = fmt.Sprintf("Reminder: %s is due at %s", d.Task.Title, d.Task.DueDate.Format(time.RFC3339))
+	}
+
+	taskID := d.Task.ID
+	return channel.Send(ctx, notification.Message{
+		UserID:  d.Reminder.UserID,
+		TaskID:  &taskID,
+		Target:  d.Reminder.Target,
+		Subject: fmt.Sprintf("Task reminder: %s", d.Task.Title),
+		Body:    body,
+	})
+}
@@ -0,0 +1,112 @@
+package reminder
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"todo/services/notification"
+	"todo/services/storetest"
+	"todo/types"
+	"todo/utils"
+)
+
+type recordingChannel struct {
+	sent []notification.Message
+	err  error
+}
+
+func (c *recordingChannel) Send(ctx context.Context, msg notification.Message) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return errors.New("delivery without a deadline")
+	}
+	c.sent = append(c.sent, msg)
+	return c.err
+}
+
+func TestSchedulerDelivers(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.SQLite(t)
+	store := NewStore(db)
+
+	due := time.Now().Add(10 * time.Minute)
+	tk, userID := newTask(t, db, &due)
+	id := createReminder(t, store, types.Reminder{TaskID: tk.ID, UserID: userID, OffsetMinutes: ptr(30), Channel: "webhook", Target: "https://example.com/hook"})
+
+	channel := &recordingChannel{}
+	scheduler := NewScheduler(store, map[string]notification.Channel{notification.ChannelWebhook: channel}, time.Minute)
+	scheduler.tick(context.Background())
+	scheduler.tick(context.Background())
+
+	if len(channel.sent) != 1 {
+		t.Fatalf("sent %d messages over two polls, want 1", len(channel.sent))
+	}
+	msg := channel.sent[0]
+	if msg.UserID != userID || msg.Target != "https://example.com/hook" || msg.TaskID == nil || *msg.TaskID != tk.ID {
+		t.Errorf("message = %+v", msg)
+	}
+	if msg.Subject != "Task reminder: Pay rent" {
+		t.Errorf("subject = %q", msg.Subject)
+	}
+
+	if r, _ := store.GetReminderByID(ctx, id); r.Status != "sent" {
+		t.Errorf("status = %q, want sent", r.Status)
+	}
+}
+
+func TestSchedulerRetries(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.SQLite(t)
+	store := NewStore(db)
+
+	tk, userID := newTask(t, db, nil)
+	past := time.Now().Add(-time.Minute)
+	failing := createReminder(t, store, types.Reminder{TaskID: tk.ID, UserID: userID, RemindAt: &past, Channel: "in_app"})
+	unconfigured := createReminder(t, store, types.Reminder{TaskID: tk.ID, UserID: userID, RemindAt: &past, Channel: "email", Target: "a@example.com"})
+
+	channel := &recordingChannel{err: errors.New("unavailable")}
+	scheduler := NewScheduler(store, map[string]notification.Channel{notification.ChannelInApp: channel}, time.Minute)
+	scheduler.maxAttempts = 3
+	for range 5 {
+		scheduler.tick(context.Background())
+	}
+
+	if len(channel.sent) != 3 {
+		t.Errorf("tried %d times, want 3", len(channel.sent))
+	}
+	for _, id := range []int{failing, unconfigured} {
+		r, err := store.GetReminderByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Status != "failed" || r.Attempts != 3 || r.LastError == nil {
+			t.Errorf("reminder %d = %+v, want failed after 3 attempts", id, r)
+		}
+	}
+}
+
+func TestSchedulerInApp(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.SQLite(t)
+	store := NewStore(db)
+	notifications := notification.NewStore(db)
+
+	tk, userID := newTask(t, db, nil)
+	past := time.Now().Add(-time.Minute)
+	createReminder(t, store, types.Reminder{TaskID: tk.ID, UserID: userID, RemindAt: &past, Channel: "in_app"})
+
+	// the channel writes to the database the scheduler claims from
+	channels := map[string]notification.Channel{notification.ChannelInApp: notification.NewInAppChannel(notifications)}
+	scheduler := NewScheduler(store, channels, time.Minute)
+	for range 3 {
+		scheduler.tick(context.Background())
+	}
+
+	got, total, err := notifications.GetNotificationsByUserID(ctx, userID, utils.PaginationParams{Limit: 10, SortBy: "created_at", Order: "desc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(got) != 1 || got[0].TaskID == nil || *got[0].TaskID != tk.ID {
+		t.Errorf("notifications over three polls = %+v of %d, want one for task %d", got, total, tk.ID)
+	}
+}
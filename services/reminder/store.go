@@ -0,0 +1,233 @@
+package reminder
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/types"
+)
+
+var ErrReminderNotFound = errs.NotFound("reminder_not_found", "reminder not found")
+
+const reminderColumns = "id, task_id, user_id, remind_at, offset_minutes, channel, target, status, attempts, last_error, sent_at, created_at"
+
+type Store struct {
+	db *dialect.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: dialect.Wrap(db)}
+}
+
+func (s *Store) GetReminderByID(ctx context.Context, reminderID int) (*types.Reminder, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+reminderColumns+" FROM task_reminders WHERE id = ?", reminderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	r := new(types.Reminder)
+	for rows.Next() {
+		r, err = scanRowsIntoReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
+
+	if r.ID == 0 {
+		return nil, ErrReminderNotFound
+	}
+
+	return r, nil
+}
+
+// GetRemindersByTaskID lists the reminders the user set on the task, other users' targets stay private.
+func (s *Store) GetRemindersByTaskID(ctx context.Context, taskID, userID int) ([]types.Reminder, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+reminderColumns+" FROM task_reminders WHERE task_id = ? AND user_id = ? ORDER BY id", taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []types.Reminder{}
+	for rows.Next() {
+		r, err := scanRowsIntoReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, *r)
+	}
+
+	return reminders, errs.FromContext(ctx, rows.Err())
+}
+
+func (s *Store) CreateReminder(ctx context.Context, reminder types.Reminder) (int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	id, err := s.db.InsertContext(ctx,
+		"INSERT INTO task_reminders (task_id, user_id, remind_at, offset_minutes, channel, target) VALUES (?, ?, ?, ?, ?, ?)",
+		reminder.TaskID, reminder.UserID, reminder.RemindAt, reminder.OffsetMinutes, reminder.Channel, reminder.Target)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s *Store) DeleteReminder(ctx context.Context, reminderID int) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM task_reminders WHERE id = ?", reminderID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// ClaimDueReminders leases up to limit pending reminders whose time has come and returns
+// them with their tasks. The claim commits before anything is delivered, the lease keeps
+// other schedulers away until ReleaseReminder records the outcome or it runs out, e.g.
+// because the instance that held it died. SKIP LOCKED lets several API instances claim
+// side by side.
+func (s *Store) ClaimDueReminders(ctx context.Context, limit int, lease time.Duration) ([]types.DueReminder, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// offset reminders are resolved against the current due_date so moving a task reschedules them
+	rows, err := tx.QueryContext(ctx, `
+	SELECT r.id, r.task_id, r.user_id, r.remind_at, r.offset_minutes, r.channel, r.target, r.status,
+		r.attempts, r.last_error, r.sent_at, r.created_at,
+		t.id, t.user_id, t.title, t.description, t.status, t.priority, t.due_date, t.created_at, t.updated_at
+	FROM task_reminders r
+	JOIN tasks t ON t.id = r.task_id
+	WHERE r.status = 'pending'
+		AND (r.locked_until IS NULL OR r.locked_until <= CURRENT_TIMESTAMP)
+		AND t.deleted_at IS NULL
+		AND COALESCE(r.remind_at, `+tx.Dialect().MinusMinutes("t.due_date", "r.offset_minutes")+`) <= CURRENT_TIMESTAMP
+	ORDER BY r.id
+	LIMIT ?`+tx.Dialect().Lock("FOR UPDATE OF r SKIP LOCKED"), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var due []types.DueReminder
+	for rows.Next() {
+		var d types.DueReminder
+		err := rows.Scan(
+			&d.Reminder.ID,
+			&d.Reminder.TaskID,
+			&d.Reminder.UserID,
+			&d.Reminder.RemindAt,
+			&d.Reminder.OffsetMinutes,
+			&d.Reminder.Channel,
+			&d.Reminder.Target,
+			&d.Reminder.Status,
+			&d.Reminder.Attempts,
+			&d.Reminder.LastError,
+			&d.Reminder.SentAt,
+			&d.Reminder.CreatedAt,
+			&d.Task.ID,
+			&d.Task.UserID,
+			&d.Task.Title,
+			&d.Task.Description,
+			&d.Task.Status,
+			&d.Task.Priority,
+			&d.Task.DueDate,
+			&d.Task.CreatedAt,
+			&d.Task.UpdatedAt,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]any, len(due))
+	for i, d := range due {
+		ids[i] = d.Reminder.ID
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE task_reminders SET locked_until = "+tx.Dialect().SecondsFromNow()+" WHERE id IN (?"+strings.Repeat(", ?", len(ids)-1)+")",
+		append([]any{int(lease.Seconds())}, ids...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return due, errs.FromContext(ctx, tx.Commit())
+}
+
+// ReleaseReminder records the outcome of delivering a claimed reminder and ends its lease. A
+// failed reminder stays pending for the next poll until it failed maxAttempts times.
+func (s *Store) ReleaseReminder(ctx context.Context, reminderID int, deliverErr error, maxAttempts int) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	if deliverErr == nil {
+		_, err := s.db.ExecContext(ctx,
+			"UPDATE task_reminders SET attempts = attempts + 1, last_error = NULL, status = 'sent', sent_at = CURRENT_TIMESTAMP, locked_until = NULL WHERE id = ?",
+			reminderID)
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+	UPDATE task_reminders
+	SET attempts = attempts + 1, last_error = ?, locked_until = NULL,
+		status = CASE WHEN attempts + 1 >= ? THEN 'failed' ELSE 'pending' END
+	WHERE id = ?`,
+		deliverErr.Error(), maxAttempts, reminderID)
+
+	return err
+}
+
+func scanRowsIntoReminder(rows *sql.Rows) (*types.Reminder, error) {
+	r := new(types.Reminder)
+
+	err := rows.Scan(
+		&r.ID,
+		&r.TaskID,
+		&r.UserID,
+		&r.RemindAt,
+		&r.OffsetMinutes,
+		&r.Channel,
+		&r.Target,
+		&r.Status,
+		&r.Attempts,
+		&r.LastError,
+		&r.SentAt,
+		&r.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
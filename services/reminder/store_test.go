@@ -0,0 +1,197 @@
+package reminder
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+	"todo/services/storetest"
+	"todo/services/task"
+	"todo/types"
+)
+
+// newTask adds a task due at due, owned by the first seeded user.
+func newTask(t *testing.T, db *sql.DB, due *time.Time) (types.Task, int) {
+	t.Helper()
+
+	userIDs, workspaceIDs := storetest.Seed(t, db)
+	created, err := task.NewStore(db).CreateTask(context.Background(), types.CreateTaskPayload{
+		UserID:      &userIDs[0],
+		Title:       "Pay rent",
+		Description: ptr("for the landlord"),
+		Priority:    2,
+		DueDate:     due,
+		CreatorID:   userIDs[0],
+		WorkspaceID: workspaceIDs[0],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return *created, userIDs[0]
+}
+
+func createReminder(t *testing.T, store *Store, reminder types.Reminder) int {
+	ctx := context.Background()
+	t.Helper()
+
+	id, err := store.CreateReminder(ctx, reminder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+func claimedIDs(t *testing.T, store *Store) []int {
+	ctx := context.Background()
+	t.Helper()
+
+	due, err := store.ClaimDueReminders(ctx, 10, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []int{}
+	for _, d := range due {
+		ids = append(ids, d.Reminder.ID)
+	}
+	return ids
+}
+
+func TestClaimDueReminders(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.SQLite(t)
+	store := NewStore(db)
+
+	due := time.Now().Add(30 * time.Minute)
+	tk, userID := newTask(t, db, &due)
+
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	absolute := createReminder(t, store, types.Reminder{TaskID: tk.ID, UserID: userID, RemindAt: &past, Channel: "in_app"})
+	createReminder(t, store, types.Reminder{TaskID: tk.ID, UserID: userID, RemindAt: &future, Channel: "in_app"})
+	offset := createReminder(t, store, types.Reminder{TaskID: tk.ID, UserID: userID, OffsetMinutes: ptr(60), Channel: "in_app"})
+	createReminder(t, store, types.Reminder{TaskID: tk.ID, UserID: userID, OffsetMinutes: ptr(10), Channel: "in_app"})
+
+	claimed, err := store.ClaimDueReminders(ctx, 10, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 2 || claimed[0].Reminder.ID != absolute || claimed[1].Reminder.ID != offset {
+		t.Fatalf("claimed %+v, want reminders %d and %d", claimed, absolute, offset)
+	}
+	if claimed[0].Task.ID != tk.ID || claimed[0].Task.Title != "Pay rent" {
+		t.Errorf("claimed task = %+v", claimed[0].Task)
+	}
+
+	if ids := claimedIDs(t, store); len(ids) != 0 {
+		t.Errorf("leased reminders were claimed again: %v", ids)
+	}
+}
+
+func TestClaimDueRemindersAfterLease(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.SQLite(t)
+	store := NewStore(db)
+
+	tk, userID := newTask(t, db, nil)
+	past := time.Now().Add(-time.Minute)
+	id := createReminder(t, store, types.Reminder{TaskID: tk.ID, UserID: userID, RemindAt: &past, Channel: "in_app"})
+
+	// a scheduler that died keeps its claim only until the lease runs out
+	if _, err := store.ClaimDueReminders(ctx, 10, 0); err != nil {
+		t.Fatal(err)
+	}
+	if ids := claimedIDs(t, store); len(ids) != 1 || ids[0] != id {
+		t.Errorf("claimed %v after the lease ran out, want [%d]", ids, id)
+	}
+}
+
+func TestReleaseReminder(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.SQLite(t)
+	store := NewStore(db)
+
+	tk, userID := newTask(t, db, nil)
+	past := time.Now().Add(-time.Minute)
+	sent := createReminder(t, store, types.Reminder{TaskID: tk.ID, UserID: userID, RemindAt: &past, Channel: "in_app"})
+	failing := createReminder(t, store, types.Reminder{TaskID: tk.ID, UserID: userID, RemindAt: &past, Channel: "email", Target: "a@example.com"})
+
+	claimedIDs(t, store)
+	if err := store.ReleaseReminder(ctx, sent, nil, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.ReleaseReminder(ctx, failing, errors.New("smtp down"), 2); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := store.GetReminderByID(ctx, sent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Status != "sent" || r.Attempts != 1 || r.SentAt == nil || r.LastError != nil {
+		t.Errorf("sent reminder = %+v", r)
+	}
+
+	r, err = store.GetReminderByID(ctx, failing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Status != "pending" || r.Attempts != 1 || r.LastError == nil || *r.LastError != "smtp down" {
+		t.Errorf("failed reminder = %+v, want it pending for a retry", r)
+	}
+
+	// the release ends the lease, the next poll retries
+	if ids := claimedIDs(t, store); len(ids) != 1 || ids[0] != failing {
+		t.Fatalf("claimed %v, want [%d]", ids, failing)
+	}
+	if err := store.ReleaseReminder(ctx, failing, errors.New("smtp down"), 2); err != nil {
+		t.Fatal(err)
+	}
+	if r, _ := store.GetReminderByID(ctx, failing); r.Status != "failed" || r.Attempts != 2 {
+		t.Errorf("reminder after the last attempt = %+v, want failed", r)
+	}
+	if ids := claimedIDs(t, store); len(ids) != 0 {
+		t.Errorf("claimed %v, done reminders must stay done", ids)
+	}
+}
+
+func TestGetRemindersByTaskID(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.SQLite(t)
+	store := NewStore(db)
+
+	userIDs, workspaceIDs := storetest.Seed(t, db)
+	tk, err := task.NewStore(db).CreateTask(ctx, types.CreateTaskPayload{
+		UserID: &userIDs[0], Title: "Pay rent", Description: ptr(""), Priority: 2, CreatorID: userIDs[0], WorkspaceID: workspaceIDs[0],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	own := createReminder(t, store, types.Reminder{TaskID: tk.ID, UserID: userIDs[0], RemindAt: &past, Channel: "in_app"})
+	createReminder(t, store, types.Reminder{TaskID: tk.ID, UserID: userIDs[1], RemindAt: &past, Channel: "email", Target: "b@example.com"})
+
+	reminders, err := store.GetRemindersByTaskID(ctx, tk.ID, userIDs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reminders) != 1 || reminders[0].ID != own {
+		t.Errorf("GetRemindersByTaskID() = %+v, want only reminder %d", reminders, own)
+	}
+}
+
+func TestGetReminderByIDNotFound(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(storetest.SQLite(t))
+
+	if _, err := store.GetReminderByID(ctx, 1); !errors.Is(err, ErrReminderNotFound) {
+		t.Errorf("GetReminderByID() = %v, want %v", err, ErrReminderNotFound)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,280 @@
+package share
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"todo/errs"
+	"todo/services/auth"
+	"todo/services/notification"
+	"todo/services/workspace"
+	"todo/types"
+	"todo/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	PermissionRead    = "read"
+	PermissionComment = "comment"
+)
+
+// PasswordHeader carries the password of a protected share link.
+const PasswordHeader = "X-Share-Password"
+
+var (
+	errPastExpiry    = errs.Validation("invalid_expires_at", "expires_at must be in the future")
+	errReadOnlyLink  = errs.Forbidden("read_only_link", "this link is read-only")
+	errWrongPassword = errs.New(http.StatusUnauthorized, "share_password_required", "this link needs a valid "+PasswordHeader+" header")
+)
+
+type Handler struct {
+	store          types.ShareLinkStore
+	taskStore      types.TaskStore
+	checklistStore types.ChecklistStore
+	commentStore   types.CommentStore
+	userStore      types.UserStore
+	workspaceStore types.WorkspaceStore
+	notifier       *notification.Notifier
+	secret         []byte
+}
+
+func NewHandler(store types.ShareLinkStore, taskStore types.TaskStore, checklistStore types.ChecklistStore, commentStore types.CommentStore, userStore types.UserStore, workspaceStore types.WorkspaceStore, notifier *notification.Notifier, secret []byte) *Handler {
+	return &Handler{store: store, taskStore: taskStore, checklistStore: checklistStore, commentStore: commentStore, userStore: userStore, workspaceStore: workspaceStore, notifier: notifier, secret: secret}
+}
+
+// RegisterRoutes serves the /shared routes without authentication, the signed token is the only credential.
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/tasks/{task_id}/shares", auth.WithJWTAuth(workspace.WithWorkspace(h.handleGetLinks, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{task_id}/shares", auth.WithJWTAuth(workspace.WithWorkspace(h.handleCreateLink, h.workspaceStore), h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/{task_id}/shares/{share_id}", auth.WithJWTAuth(workspace.WithWorkspace(h.handleRevokeLink, h.workspaceStore), h.userStore)).Methods(http.MethodDelete)
+	router.HandleFunc("/shared/{token}", h.handleGetShared).Methods(http.MethodGet)
+	router.HandleFunc("/shared/{token}/comments", h.handleCreateSharedComment).Methods(http.MethodPost)
+}
+
+func (h *Handler) handleGetLinks(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	links, err := h.store.GetShareLinks(r.Context(), task.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get share links: %v", err))
+		return
+	}
+	for i := range links {
+		links[i].Token = Sign(h.secret, links[i].ID)
+	}
+
+	utils.WriteJson(w, http.StatusOK, links)
+}
+
+func (h *Handler) handleCreateLink(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	var payload types.CreateShareLinkPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+	if payload.ExpiresAt != nil && !payload.ExpiresAt.After(time.Now()) {
+		utils.WriteError(w, http.StatusBadRequest, errPastExpiry)
+		return
+	}
+	if payload.Permission == "" {
+		payload.Permission = PermissionRead
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	link := types.ShareLink{
+		TaskID:     task.ID,
+		Permission: payload.Permission,
+		ExpiresAt:  payload.ExpiresAt,
+		CreatedBy:  &userID,
+	}
+	if payload.Password != "" {
+		hash, err := auth.HashPassword(payload.Password)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		link.PasswordHash = hash
+	}
+
+	linkID, err := h.store.CreateShareLink(r.Context(), link)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	created, err := h.store.GetShareLinkByID(r.Context(), linkID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	created.Token = Sign(h.secret, created.ID)
+
+	utils.WriteJson(w, http.StatusCreated, created)
+}
+
+func (h *Handler) handleRevokeLink(w http.ResponseWriter, r *http.Request) {
+	task, ok := workspace.GetTask(w, r, h.taskStore)
+	if !ok {
+		return
+	}
+
+	linkID, err := strconv.Atoi(mux.Vars(r)["share_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid share link ID"))
+		return
+	}
+
+	rowsAffected, err := h.store.RevokeShareLink(r.Context(), task.ID, linkID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to revoke share link: %v", err))
+		return
+	}
+	if rowsAffected == 0 {
+		utils.WriteError(w, http.StatusNotFound, ErrLinkNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetShared returns the shared task with its checklist and one page of comments.
+func (h *Handler) handleGetShared(w http.ResponseWriter, r *http.Request) {
+	link, task, ok := h.getSharedTask(w, r)
+	if !ok {
+		return
+	}
+
+	pagination, err := utils.ParsePaginationParams(r, []string{"created_at"})
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	items, err := h.checklistStore.GetChecklistItems(r.Context(), task.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	comments, total, err := h.commentStore.GetPaginatedComments(r.Context(), task.ID, pagination)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, types.SharedTask{
+		Title:        task.Title,
+		Description:  task.Description,
+		Status:       task.Status,
+		Priority:     task.Priority,
+		DueDate:      task.DueDate,
+		CreatedAt:    task.CreatedAt,
+		UpdatedAt:    task.UpdatedAt,
+		Checklist:    items,
+		Comments:     comments,
+		CommentCount: total,
+		Permission:   link.Permission,
+	})
+}
+
+// handleCreateSharedComment lets visitors of a comment link write under a name of their choice.
+func (h *Handler) handleCreateSharedComment(w http.ResponseWriter, r *http.Request) {
+	link, task, ok := h.getSharedTask(w, r)
+	if !ok {
+		return
+	}
+	if link.Permission != PermissionComment {
+		utils.WriteError(w, http.StatusForbidden, errReadOnlyLink)
+		return
+	}
+
+	var payload types.SharedCommentPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	commentID, err := h.commentStore.CreateComment(r.Context(), types.Comment{
+		TaskID:    task.ID,
+		GuestName: &payload.Name,
+		Body:      payload.Body,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	created, err := h.commentStore.GetCommentByID(r.Context(), commentID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.notifier.Notify(task.ID, 0, fmt.Sprintf("New comment on %s", task.Title),
+		fmt.Sprintf("%s (via share link): %s", payload.Name, payload.Body))
+
+	utils.WriteJson(w, http.StatusCreated, created)
+}
+
+// getSharedTask resolves the {token} route variable to a usable link and its task, writing the error response itself on failure.
+// Unknown, revoked and expired links as well as deleted tasks all look the same to the visitor.
+func (h *Handler) getSharedTask(w http.ResponseWriter, r *http.Request) (*types.ShareLink, *types.Task, bool) {
+	linkID, ok := Verify(h.secret, mux.Vars(r)["token"])
+	if !ok {
+		utils.WriteError(w, http.StatusNotFound, ErrLinkNotFound)
+		return nil, nil, false
+	}
+
+	link, err := h.store.GetShareLinkByID(r.Context(), linkID)
+	if errors.Is(err, ErrLinkNotFound) {
+		utils.WriteError(w, http.StatusNotFound, ErrLinkNotFound)
+		return nil, nil, false
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return nil, nil, false
+	}
+	if link.RevokedAt != nil || (link.ExpiresAt != nil && time.Now().After(*link.ExpiresAt)) {
+		utils.WriteError(w, http.StatusNotFound, ErrLinkNotFound)
+		return nil, nil, false
+	}
+
+	if link.HasPassword && !auth.ComparePasswords(link.PasswordHash, []byte(r.Header.Get(PasswordHeader))) {
+		utils.WriteError(w, http.StatusUnauthorized, errWrongPassword)
+		return nil, nil, false
+	}
+
+	// a trashed task hides the link like a revoked one
+	task, err := h.taskStore.GetTaskByID(r.Context(), link.TaskID)
+	if errors.Is(err, types.ErrTaskNotFound) {
+		utils.WriteError(w, http.StatusNotFound, ErrLinkNotFound)
+		return nil, nil, false
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return nil, nil, false
+	}
+
+	return link, task, true
+}
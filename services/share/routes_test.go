@@ -0,0 +1,49 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"todo/errs"
+	"todo/types"
+
+	"github.com/gorilla/mux"
+)
+
+// failingStore fails to look up share links.
+type failingStore struct {
+	types.ShareLinkStore
+	err error
+}
+
+func (s failingStore) GetShareLinkByID(ctx context.Context, linkID int) (*types.ShareLink, error) {
+	return nil, s.err
+}
+
+func TestSharedLookupErrors(t *testing.T) {
+	secret := []byte("secret")
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"not found", ErrLinkNotFound, http.StatusNotFound},
+		{"timeout", errs.ErrQueryTimeout, http.StatusGatewayTimeout},
+		{"database error", errors.New("driver: bad connection"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			NewHandler(failingStore{err: tt.err}, nil, nil, nil, nil, nil, nil, secret).RegisterRoutes(router)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/shared/"+Sign(secret, 1), nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package share
+
+import (
+	"context"
+	"database/sql"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/types"
+)
+
+var ErrLinkNotFound = errs.NotFound("share_link_not_found", "share link not found")
+
+const linkColumns = `id, task_id, permission, password_hash, expires_at, revoked_at, created_by, created_at`
+
+type Store struct {
+	db *dialect.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: dialect.Wrap(db)}
+}
+
+func (s *Store) GetShareLinks(ctx context.Context, taskID int) ([]types.ShareLink, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+linkColumns+" FROM share_links WHERE task_id = ? ORDER BY id DESC", taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []types.ShareLink{}
+	for rows.Next() {
+		link, err := scanRowsIntoShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *link)
+	}
+
+	return links, errs.FromContext(ctx, rows.Err())
+}
+
+func (s *Store) GetShareLinkByID(ctx context.Context, linkID int) (*types.ShareLink, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+linkColumns+" FROM share_links WHERE id = ?", linkID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	link := new(types.ShareLink)
+	for rows.Next() {
+		link, err = scanRowsIntoShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
+
+	if link.ID == 0 {
+		return nil, ErrLinkNotFound
+	}
+
+	return link, nil
+}
+
+func (s *Store) CreateShareLink(ctx context.Context, link types.ShareLink) (int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	var passwordHash *string
+	if link.PasswordHash != "" {
+		passwordHash = &link.PasswordHash
+	}
+
+	id, err := s.db.InsertContext(ctx,
+		"INSERT INTO share_links (task_id, permission, password_hash, expires_at, created_by) VALUES (?, ?, ?, ?, ?)",
+		link.TaskID, link.Permission, passwordHash, link.ExpiresAt, link.CreatedBy)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// RevokeShareLink disables the link for good, revoked links stay listed.
+func (s *Store) RevokeShareLink(ctx context.Context, taskID, linkID int) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE share_links SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND task_id = ? AND revoked_at IS NULL",
+		linkID, taskID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func scanRowsIntoShareLink(rows *sql.Rows) (*types.ShareLink, error) {
+	link := new(types.ShareLink)
+	var passwordHash sql.NullString
+
+	err := rows.Scan(
+		&link.ID,
+		&link.TaskID,
+		&link.Permission,
+		&passwordHash,
+		&link.ExpiresAt,
+		&link.RevokedAt,
+		&link.CreatedBy,
+		&link.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	link.PasswordHash = passwordHash.String
+	link.HasPassword = passwordHash.Valid
+
+	return link, nil
+}
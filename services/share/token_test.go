@@ -0,0 +1,27 @@
+package share
+
+import "testing"
+
+func TestVerify(t *testing.T) {
+	secret := []byte("secret")
+	token := Sign(secret, 42)
+
+	if id, ok := Verify(secret, token); !ok || id != 42 {
+		t.Errorf("Verify(%q) = %d, %v, want 42, true", token, id, ok)
+	}
+
+	invalid := []string{
+		"",
+		"42",
+		"42.",
+		"43" + token[2:],
+		token + "00",
+		Sign([]byte("other"), 42),
+		"-1." + token[3:],
+	}
+	for _, token := range invalid {
+		if _, ok := Verify(secret, token); ok {
+			t.Errorf("Verify(%q) accepted an invalid token", token)
+		}
+	}
+}
@@ -0,0 +1,734 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+	"todo/errs"
+	"todo/services/board"
+	"todo/services/customfield"
+	"todo/types"
+	"todo/utils"
+)
+
+// TaskFixture is an empty task store with two users and two workspaces its tasks can refer to.
+// Limits holds the WIP limits the store enforces. AddItems adds comments and checklist items to
+// a task where the store counts them, it is nil for stores that count neither.
+type TaskFixture struct {
+	Store        types.TaskStore
+	Limits       types.WIPLimitStore
+	UserIDs      [2]int
+	WorkspaceIDs [2]int
+	AddItems     func(t *testing.T, taskID, comments int, checklist ...bool)
+}
+
+// TestTaskStore runs the conformance suite against the fixtures returned by newFixture,
+// which has to return an empty store on every call.
+func TestTaskStore(t *testing.T, newFixture func(t *testing.T) TaskFixture) {
+	ctx := context.Background()
+
+	t.Run("create and get", func(t *testing.T) {
+		f := newFixture(t)
+		owner, other := f.UserIDs[0], f.UserIDs[1]
+
+		due := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+		task, err := f.Store.CreateTask(ctx, types.CreateTaskPayload{
+			UserID:       &owner,
+			Title:        "Write docs",
+			Description:  ptr("for the store"),
+			Priority:     2,
+			DueDate:      &due,
+			CustomFields: map[string]any{"points": 3, "tags": []any{"a"}},
+			AssigneeIDs:  []int{other},
+			Labels:       []string{"b", "a"},
+			CreatorID:    owner,
+			WorkspaceID:  f.WorkspaceIDs[0],
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if task.ID == 0 || task.Version != 1 || task.Position == "" || task.CreatedAt.IsZero() {
+			t.Errorf("got ID %d, version %d, position %q, created at %v", task.ID, task.Version, task.Position, task.CreatedAt)
+		}
+		if task.Status != "pending" || task.Description != "for the store" || task.WorkspaceID != f.WorkspaceIDs[0] {
+			t.Errorf("got status %q, description %q, workspace %d", task.Status, task.Description, task.WorkspaceID)
+		}
+		if task.DueDate == nil || !task.DueDate.Equal(due) {
+			t.Errorf("due date = %v, want %v", task.DueDate, due)
+		}
+		if task.CreatorID == nil || *task.CreatorID != owner || task.DeletedAt != nil {
+			t.Errorf("got creator %v and deleted at %v", task.CreatorID, task.DeletedAt)
+		}
+		if !reflect.DeepEqual(task.CustomFields, map[string]any{"points": 3.0, "tags": []any{"a"}}) {
+			t.Errorf("custom fields = %v", task.CustomFields)
+		}
+		wantWatchers := []int{owner, other}
+		slices.Sort(wantWatchers)
+		if !slices.Equal(task.AssigneeIDs, []int{other}) || !slices.Equal(task.WatcherIDs, wantWatchers) {
+			t.Errorf("got assignees %v and watchers %v, the creator and assignees watch", task.AssigneeIDs, task.WatcherIDs)
+		}
+		if !slices.Equal(task.Labels, []string{"a", "b"}) {
+			t.Errorf("labels = %v, want them sorted", task.Labels)
+		}
+
+		got, err := f.Store.GetTaskByID(ctx, task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, task) {
+			t.Errorf("GetTaskByID() = %+v, want %+v", got, task)
+		}
+
+		if id, err := f.Store.GetTaskWorkspaceID(ctx, task.ID); err != nil || id != f.WorkspaceIDs[0] {
+			t.Errorf("GetTaskWorkspaceID() = %d, %v", id, err)
+		}
+		if id, err := f.Store.GetTaskWorkspaceID(ctx, task.ID+100); err != nil || id != 0 {
+			t.Errorf("GetTaskWorkspaceID() of a missing task = %d, %v, want 0", id, err)
+		}
+		if _, err := f.Store.GetTaskByID(ctx, task.ID+100); !errors.Is(err, types.ErrTaskNotFound) {
+			t.Errorf("GetTaskByID() of a missing task = %v, want %v", err, types.ErrTaskNotFound)
+		}
+	})
+
+	t.Run("comment and checklist counts", func(t *testing.T) {
+		f := newFixture(t)
+		task := createTask(t, f, "counted", nil)
+
+		// a MemoryStore reports none, comments and checklist items are kept in the database
+		wantComments, wantChecklist := 0, types.ChecklistSummary{}
+		if f.AddItems != nil {
+			f.AddItems(t, task.ID, 2, true, false)
+			wantComments, wantChecklist = 2, types.ChecklistSummary{Done: 1, Total: 2}
+		}
+
+		page, _, err := f.Store.GetPaginatedTasks(ctx, types.TaskFilter{}, utils.PaginationParams{Page: 1, Limit: 10, SortBy: "id"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, got := range append(page, *getTask(t, f, task.ID)) {
+			if got.CommentCount != wantComments || got.Checklist != wantChecklist {
+				t.Errorf("got %d comments and checklist %+v, want %d and %+v", got.CommentCount, got.Checklist, wantComments, wantChecklist)
+			}
+		}
+	})
+
+	t.Run("positions", func(t *testing.T) {
+		f := newFixture(t)
+		owner := f.UserIDs[0]
+
+		var last string
+		for i := range 3 {
+			task := createTask(t, f, fmt.Sprint(i), func(p *types.CreateTaskPayload) { p.UserID = &owner })
+			if task.Position <= last {
+				t.Errorf("position %q does not come after %q", task.Position, last)
+			}
+			last = task.Position
+		}
+
+		if got, err := f.Store.GetLastPosition(ctx, types.BoardColumn{UserID: &owner, Status: "pending"}); err != nil || got != last {
+			t.Errorf("GetLastPosition() = %q, %v, want %q", got, err, last)
+		}
+		if got, err := f.Store.GetLastPosition(ctx, types.BoardColumn{UserID: &owner, Status: "done"}); err != nil || got != "" {
+			t.Errorf("GetLastPosition() of an empty column = %q, %v", got, err)
+		}
+
+		unowned := createTask(t, f, "unowned", nil)
+		if got, err := f.Store.GetLastPosition(ctx, types.BoardColumn{Status: "pending"}); err != nil || got != unowned.Position {
+			t.Errorf("GetLastPosition() without owner = %q, %v, want %q", got, err, unowned.Position)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		f := newFixture(t)
+		owner, other := f.UserIDs[0], f.UserIDs[1]
+
+		done := createTask(t, f, "done", func(p *types.CreateTaskPayload) { p.UserID, p.Status = &owner, "done" })
+		task := createTask(t, f, "task", func(p *types.CreateTaskPayload) {
+			p.UserID, p.AssigneeIDs, p.Labels = &owner, []int{other}, []string{"old"}
+		})
+
+		update := fullUpdate(task)
+		update.Title, update.Priority, update.ChecklistAutoComplete = ptr("Renamed"), ptr(3), ptr(true)
+		update.CustomFields = map[string]any{"points": 5}
+		if n, err := f.Store.UpdateTask(ctx, task.ID, task.Version+1, update); err != nil || n != 0 {
+			t.Fatalf("UpdateTask() at a stale version = %d, %v, want 0", n, err)
+		}
+		if n, err := f.Store.UpdateTask(ctx, task.ID, task.Version, update); err != nil || n != 1 {
+			t.Fatalf("UpdateTask() = %d, %v, want 1", n, err)
+		}
+
+		got := getTask(t, f, task.ID)
+		if got.Title != "Renamed" || got.Priority != 3 || !got.ChecklistAutoComplete || got.Version != task.Version+1 {
+			t.Errorf("got title %q, priority %d, auto complete %v, version %d", got.Title, got.Priority, got.ChecklistAutoComplete, got.Version)
+		}
+		if got.Position != task.Position || !reflect.DeepEqual(got.CustomFields, map[string]any{"points": 5.0}) {
+			t.Errorf("got position %q and custom fields %v", got.Position, got.CustomFields)
+		}
+		if !slices.Equal(got.AssigneeIDs, task.AssigneeIDs) || !slices.Equal(got.Labels, task.Labels) {
+			t.Errorf("got assignees %v and labels %v, nil keeps them", got.AssigneeIDs, got.Labels)
+		}
+
+		update = fullUpdate(got)
+		update.Status, update.AssigneeIDs, update.Labels = ptr("done"), &[]int{owner}, &[]string{"new"}
+		if n, err := f.Store.UpdateTask(ctx, got.ID, got.Version, update); err != nil || n != 1 {
+			t.Fatalf("UpdateTask() = %d, %v, want 1", n, err)
+		}
+
+		moved := getTask(t, f, task.ID)
+		if moved.Status != "done" || moved.Position <= done.Position {
+			t.Errorf("got status %q at %q, want the end of the done column after %q", moved.Status, moved.Position, done.Position)
+		}
+		if !slices.Equal(moved.AssigneeIDs, []int{owner}) || !slices.Equal(moved.Labels, []string{"new"}) {
+			t.Errorf("got assignees %v and labels %v", moved.AssigneeIDs, moved.Labels)
+		}
+		if !slices.Contains(moved.WatcherIDs, other) {
+			t.Errorf("watchers = %v, removed assignees keep watching", moved.WatcherIDs)
+		}
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		f := newFixture(t)
+		owner := f.UserIDs[0]
+
+		done := createTask(t, f, "done", func(p *types.CreateTaskPayload) { p.UserID, p.Status = &owner, "done" })
+		task := createTask(t, f, "task", func(p *types.CreateTaskPayload) { p.UserID, p.Priority = &owner, 2 })
+
+		doc := types.TaskDocument{UserID: &owner, Title: "Patched", Status: "pending", Priority: 1}
+		if n, err := f.Store.PatchTask(ctx, task.ID, task.Version, doc, []string{"title"}); err != nil || n != 1 {
+			t.Fatalf("PatchTask() = %d, %v, want 1", n, err)
+		}
+
+		got := getTask(t, f, task.ID)
+		if got.Title != "Patched" || got.Priority != 2 || got.Version != task.Version+1 || got.Position != task.Position {
+			t.Errorf("got title %q, priority %d, version %d, position %q", got.Title, got.Priority, got.Version, got.Position)
+		}
+
+		doc.Status, doc.Labels = "done", []string{"z"}
+		if n, err := f.Store.PatchTask(ctx, task.ID, task.Version, doc, []string{"status", "labels"}); err != nil || n != 0 {
+			t.Fatalf("PatchTask() at a stale version = %d, %v, want 0", n, err)
+		}
+		if n, err := f.Store.PatchTask(ctx, got.ID, got.Version, doc, []string{"status", "labels"}); err != nil || n != 1 {
+			t.Fatalf("PatchTask() = %d, %v, want 1", n, err)
+		}
+
+		moved := getTask(t, f, task.ID)
+		if moved.Status != "done" || moved.Position <= done.Position || !slices.Equal(moved.Labels, []string{"z"}) {
+			t.Errorf("got status %q at %q with labels %v", moved.Status, moved.Position, moved.Labels)
+		}
+	})
+
+	t.Run("move", func(t *testing.T) {
+		f := newFixture(t)
+		task := createTask(t, f, "task", nil)
+
+		if n, err := f.Store.MoveTask(ctx, task.ID, task.Version+1, "done", "a"); err != nil || n != 0 {
+			t.Fatalf("MoveTask() at a stale version = %d, %v, want 0", n, err)
+		}
+		if n, err := f.Store.MoveTask(ctx, task.ID, task.Version, "done", "a"); err != nil || n != 1 {
+			t.Fatalf("MoveTask() = %d, %v, want 1", n, err)
+		}
+
+		got := getTask(t, f, task.ID)
+		if got.Status != "done" || got.Position != "a" || got.Version != task.Version+1 {
+			t.Errorf("got status %q, position %q, version %d", got.Status, got.Position, got.Version)
+		}
+	})
+
+	t.Run("sort and paginate", func(t *testing.T) {
+		f := newFixture(t)
+
+		base := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+		for _, day := range []int{3, 0, 4, 1, 2} {
+			createTask(t, f, fmt.Sprint(day), func(p *types.CreateTaskPayload) {
+				p.DueDate = ptr(base.AddDate(0, 0, day))
+				p.CustomFields = map[string]any{"points": 10 - day}
+			})
+		}
+		createTask(t, f, "elsewhere", func(p *types.CreateTaskPayload) { p.WorkspaceID = f.WorkspaceIDs[1] })
+
+		tests := []struct {
+			pagination utils.PaginationParams
+			want       []string
+		}{
+			{utils.PaginationParams{Limit: 2, Offset: 0, SortBy: "due_date", Order: "asc"}, []string{"0", "1"}},
+			{utils.PaginationParams{Limit: 2, Offset: 2, SortBy: "due_date", Order: "asc"}, []string{"2", "3"}},
+			{utils.PaginationParams{Limit: 2, Offset: 4, SortBy: "due_date", Order: "asc"}, []string{"4"}},
+			{utils.PaginationParams{Limit: 3, Offset: 0, SortBy: "due_date", Order: "desc"}, []string{"4", "3", "2"}},
+			{utils.PaginationParams{Limit: 2, Offset: 0, SortBy: "cf.points", Order: "asc"}, []string{"4", "3"}},
+			{utils.PaginationParams{Limit: 2, Offset: 5, SortBy: "due_date", Order: "asc"}, nil},
+		}
+		for _, tt := range tests {
+			tasks, total, err := f.Store.GetPaginatedTasks(ctx, types.TaskFilter{WorkspaceID: f.WorkspaceIDs[0]}, tt.pagination)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 5 || !slices.Equal(titles(tasks), tt.want) {
+				t.Errorf("%+v: got %v of %d, want %v of 5", tt.pagination, titles(tasks), total, tt.want)
+			}
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		f := newFixture(t)
+		owner, other := f.UserIDs[0], f.UserIDs[1]
+
+		createTask(t, f, "first", func(p *types.CreateTaskPayload) {
+			p.AssigneeIDs, p.Labels = []int{other}, []string{"bug"}
+			p.CustomFields = map[string]any{"text": "x", "points": 3, "done": true, "tags": []any{"a", "b"}}
+		})
+		createTask(t, f, "second", func(p *types.CreateTaskPayload) {
+			p.Labels = []string{"feature"}
+			p.CustomFields = map[string]any{"text": "y", "points": 4, "done": false, "tags": []any{"c"}}
+		})
+		createTask(t, f, "third", func(p *types.CreateTaskPayload) {
+			p.WorkspaceID, p.AssigneeIDs = f.WorkspaceIDs[1], []int{other}
+		})
+
+		field := func(key, fieldType string, value any) []types.CustomFieldFilter {
+			return []types.CustomFieldFilter{{Key: key, Type: fieldType, Value: value}}
+		}
+		tests := []struct {
+			name   string
+			filter types.TaskFilter
+			want   []string
+		}{
+			{"every workspace", types.TaskFilter{}, []string{"first", "second", "third"}},
+			{"workspace", types.TaskFilter{WorkspaceID: f.WorkspaceIDs[1]}, []string{"third"}},
+			{"assignee", types.TaskFilter{AssigneeID: &other}, []string{"first", "third"}},
+			{"assignee in workspace", types.TaskFilter{WorkspaceID: f.WorkspaceIDs[0], AssigneeID: &other}, []string{"first"}},
+			{"creator watches", types.TaskFilter{WatcherID: &owner}, []string{"first", "second", "third"}},
+			{"assignee watches", types.TaskFilter{WatcherID: &other}, []string{"first", "third"}},
+			{"label", types.TaskFilter{Label: ptr("feature")}, []string{"second"}},
+			{"text", types.TaskFilter{CustomFields: field("text", customfield.TypeText, "y")}, []string{"second"}},
+			{"number", types.TaskFilter{CustomFields: field("points", customfield.TypeNumber, 3.0)}, []string{"first"}},
+			{"checkbox", types.TaskFilter{CustomFields: field("done", customfield.TypeCheckbox, false)}, []string{"second"}},
+			{"multi select", types.TaskFilter{CustomFields: field("tags", customfield.TypeMultiSelect, "b")}, []string{"first"}},
+			{"no match", types.TaskFilter{CustomFields: field("text", customfield.TypeText, "z")}, nil},
+		}
+		for _, tt := range tests {
+			tasks, total, err := f.Store.GetPaginatedTasks(ctx, tt.filter, utils.PaginationParams{Limit: 10, SortBy: "id", Order: "asc"})
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if total != len(tt.want) || !slices.Equal(titles(tasks), tt.want) {
+				t.Errorf("%s: got %v of %d, want %v", tt.name, titles(tasks), total, tt.want)
+			}
+		}
+	})
+
+	t.Run("trash", func(t *testing.T) {
+		f := newFixture(t)
+		task := createTask(t, f, "task", nil)
+		createTask(t, f, "kept", nil)
+
+		if n, err := f.Store.DeleteTask(ctx, task.ID); err != nil || n != 1 {
+			t.Fatalf("DeleteTask() = %d, %v, want 1", n, err)
+		}
+		if n, err := f.Store.DeleteTask(ctx, task.ID); err != nil || n != 0 {
+			t.Errorf("DeleteTask() of a trashed task = %d, %v, want 0", n, err)
+		}
+		if _, err := f.Store.GetTaskByID(ctx, task.ID); !errors.Is(err, types.ErrTaskNotFound) {
+			t.Errorf("GetTaskByID() of a trashed task = %v, want %v", err, types.ErrTaskNotFound)
+		}
+		if n, err := f.Store.UpdateTask(ctx, task.ID, task.Version, fullUpdate(task)); err != nil || n != 0 {
+			t.Errorf("UpdateTask() of a trashed task = %d, %v, want 0", n, err)
+		}
+
+		pagination := utils.PaginationParams{Limit: 10, SortBy: "id", Order: "asc"}
+		live, _, err := f.Store.GetPaginatedTasks(ctx, types.TaskFilter{}, pagination)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trashed, _, err := f.Store.GetPaginatedTrashedTasks(ctx, types.TaskFilter{}, pagination)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(titles(live), []string{"kept"}) || !slices.Equal(titles(trashed), []string{"task"}) || trashed[0].DeletedAt == nil {
+			t.Errorf("got live %v and trashed %v", titles(live), titles(trashed))
+		}
+
+		if ids, err := f.Store.GetPurgeableTaskIDs(ctx, time.Hour); err != nil || len(ids) != 0 {
+			t.Errorf("GetPurgeableTaskIDs() = %v, %v, nothing is old enough", ids, err)
+		}
+
+		if n, err := f.Store.RestoreTask(ctx, task.ID); err != nil || n != 1 {
+			t.Fatalf("RestoreTask() = %d, %v, want 1", n, err)
+		}
+		if n, err := f.Store.RestoreTask(ctx, task.ID); err != nil || n != 0 {
+			t.Errorf("RestoreTask() of a live task = %d, %v, want 0", n, err)
+		}
+		getTask(t, f, task.ID)
+
+		if n, err := f.Store.PurgeTask(ctx, task.ID); err != nil || n != 1 {
+			t.Fatalf("PurgeTask() = %d, %v, want 1", n, err)
+		}
+		if n, err := f.Store.PurgeTask(ctx, task.ID); err != nil || n != 0 {
+			t.Errorf("PurgeTask() of a purged task = %d, %v, want 0", n, err)
+		}
+		if id, err := f.Store.GetTaskWorkspaceID(ctx, task.ID); err != nil || id != 0 {
+			t.Errorf("GetTaskWorkspaceID() of a purged task = %d, %v, want 0", id, err)
+		}
+	})
+
+	t.Run("bulk", func(t *testing.T) {
+		f := newFixture(t)
+		a := createTask(t, f, "a", nil)
+		b := createTask(t, f, "b", nil)
+
+		update := fullUpdate(a)
+		update.Title = ptr("a2")
+		changes := []types.BulkTaskChange{
+			{TaskID: a.ID, Version: a.Version, Update: &update},
+			{TaskID: b.ID, Version: b.Version + 1, Update: ptr(fullUpdate(b))},
+			{TaskID: b.ID + 100},
+		}
+
+		errs, err := f.Store.BulkUpdateTasks(ctx, changes, true)
+		if err == nil || errs[0] != nil || errs[1] == nil {
+			t.Fatalf("BulkUpdateTasks() all or nothing = %v, %v, want the second item to fail", errs, err)
+		}
+		if got := getTask(t, f, a.ID); got.Title != "a" || got.Version != a.Version {
+			t.Errorf("got title %q at version %d, the first change has to be rolled back", got.Title, got.Version)
+		}
+
+		errs, err = f.Store.BulkUpdateTasks(ctx, changes, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errs[0] != nil || errs[1] == nil || !errors.Is(errs[2], types.ErrTaskNotFound) {
+			t.Errorf("BulkUpdateTasks() = %v, want only the last two items to fail", errs)
+		}
+		if got := getTask(t, f, a.ID); got.Title != "a2" || got.Version != a.Version+1 {
+			t.Errorf("got title %q at version %d", got.Title, got.Version)
+		}
+
+		errs, err = f.Store.BulkUpdateTasks(ctx, []types.BulkTaskChange{{TaskID: b.ID}}, true)
+		if err != nil || errs[0] != nil {
+			t.Fatalf("BulkUpdateTasks() delete = %v, %v", errs, err)
+		}
+		if _, err := f.Store.GetTaskByID(ctx, b.ID); !errors.Is(err, types.ErrTaskNotFound) {
+			t.Errorf("GetTaskByID() = %v, the task has to be in the trash", err)
+		}
+	})
+
+	t.Run("wip limits", func(t *testing.T) {
+		f := newFixture(t)
+		owner := f.UserIDs[0]
+
+		mine := func(p *types.CreateTaskPayload) { p.UserID = &owner }
+		a := createTask(t, f, "a", mine)
+		b := createTask(t, f, "b", mine)
+		c := createTask(t, f, "c", mine)
+		if err := f.Limits.SetWIPLimit(ctx, types.WIPLimit{UserID: owner, Status: "in_progress", MaxTasks: 1, Mode: board.ModeReject}); err != nil {
+			t.Fatal(err)
+		}
+
+		update := fullUpdate(a)
+		update.Status = ptr("in_progress")
+		if n, err := f.Store.UpdateTask(ctx, a.ID, a.Version, update); err != nil || n != 1 {
+			t.Fatalf("UpdateTask() into an empty column = %d, %v, want 1", n, err)
+		}
+		a = getTask(t, f, a.ID)
+
+		// moves within the full column are free
+		update = fullUpdate(a)
+		update.Title = ptr("a2")
+		if n, err := f.Store.UpdateTask(ctx, a.ID, a.Version, update); err != nil || n != 1 {
+			t.Errorf("UpdateTask() within the column = %d, %v, want 1", n, err)
+		}
+		a = getTask(t, f, a.ID)
+		if n, err := f.Store.MoveTask(ctx, a.ID, a.Version, "in_progress", "b"); err != nil || n != 1 {
+			t.Errorf("MoveTask() within the column = %d, %v, want 1", n, err)
+		}
+
+		update = fullUpdate(b)
+		update.Status = ptr("in_progress")
+		if _, err := f.Store.UpdateTask(ctx, b.ID, b.Version, update); !errors.Is(err, board.ErrWIPLimitReached) {
+			t.Errorf("UpdateTask() into the full column = %v, want %v", err, board.ErrWIPLimitReached)
+		}
+		if _, err := f.Store.MoveTask(ctx, b.ID, b.Version, "in_progress", "c"); !errors.Is(err, board.ErrWIPLimitReached) {
+			t.Errorf("MoveTask() into the full column = %v, want %v", err, board.ErrWIPLimitReached)
+		}
+		doc := types.TaskDocument{UserID: &owner, Title: "b", Status: "in_progress", Priority: 1}
+		if _, err := f.Store.PatchTask(ctx, b.ID, b.Version, doc, []string{"status"}); !errors.Is(err, board.ErrWIPLimitReached) {
+			t.Errorf("PatchTask() into the full column = %v, want %v", err, board.ErrWIPLimitReached)
+		}
+		if got := getTask(t, f, b.ID); got.Status != "pending" || got.Version != b.Version {
+			t.Errorf("rejected task is %s at version %d", got.Status, got.Version)
+		}
+
+		// in one batch, the first task to arrive fills the column
+		if err := f.Limits.SetWIPLimit(ctx, types.WIPLimit{UserID: owner, Status: "in_progress", MaxTasks: 2, Mode: board.ModeReject}); err != nil {
+			t.Fatal(err)
+		}
+		toB, toC := fullUpdate(b), fullUpdate(c)
+		toB.Status, toC.Status = ptr("in_progress"), ptr("in_progress")
+		changes := []types.BulkTaskChange{{TaskID: b.ID, Version: b.Version, Update: &toB}, {TaskID: c.ID, Version: c.Version, Update: &toC}}
+		errs, err := f.Store.BulkUpdateTasks(ctx, changes, true)
+		if !errors.Is(err, board.ErrWIPLimitReached) {
+			t.Errorf("BulkUpdateTasks() all or nothing = %v, %v, want the second task rejected", errs, err)
+		}
+		if got := getTask(t, f, b.ID); got.Status != "pending" {
+			t.Errorf("first task of the rolled back batch is %s", got.Status)
+		}
+		errs, err = f.Store.BulkUpdateTasks(ctx, changes, false)
+		if err != nil || errs[0] != nil || !errors.Is(errs[1], board.ErrWIPLimitReached) {
+			t.Errorf("BulkUpdateTasks() = %v, %v, want the second task rejected", errs, err)
+		}
+
+		// warnings never stop a move
+		if err := f.Limits.SetWIPLimit(ctx, types.WIPLimit{UserID: owner, Status: "in_progress", MaxTasks: 1, Mode: board.ModeWarn}); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := f.Store.MoveTask(ctx, c.ID, c.Version, "in_progress", "d"); err != nil || n != 1 {
+			t.Errorf("MoveTask() into a column with a warn limit = %d, %v, want 1", n, err)
+		}
+	})
+
+	t.Run("create tasks", func(t *testing.T) {
+		f := newFixture(t)
+		owner := f.UserIDs[0]
+
+		batch := func(titles ...string) []types.CreateTaskPayload {
+			payloads := []types.CreateTaskPayload{}
+			for _, title := range titles {
+				payload := newTask(f, title)
+				payload.UserID, payload.Status = &owner, "in_progress"
+				payloads = append(payloads, payload)
+			}
+			return payloads
+		}
+
+		created, err := f.Store.CreateTasks(ctx, batch("a", "b"))
+		if err != nil || !slices.Equal(titles(created), []string{"a", "b"}) {
+			t.Fatalf("CreateTasks() = %v, %v", titles(created), err)
+		}
+		if created[0].Position >= created[1].Position {
+			t.Errorf("positions %q and %q, want the batch in order", created[0].Position, created[1].Position)
+		}
+
+		// the third task of the batch would exceed the limit, none is created
+		if err := f.Limits.SetWIPLimit(ctx, types.WIPLimit{UserID: owner, Status: "in_progress", MaxTasks: 4, Mode: board.ModeReject}); err != nil {
+			t.Fatal(err)
+		}
+		column := types.BoardColumn{UserID: &owner, Status: "in_progress"}
+		if _, err := f.Store.CreateTasks(ctx, batch("c", "d", "e")); !errors.Is(err, board.ErrWIPLimitReached) {
+			t.Errorf("CreateTasks() past the limit = %v, want %v", err, board.ErrWIPLimitReached)
+		}
+		if n, err := f.Store.CountColumnTasks(ctx, column); err != nil || n != 2 {
+			t.Errorf("CountColumnTasks() after the rejected batch = %d, %v, want 2", n, err)
+		}
+
+		if _, err := f.Store.CreateTasks(ctx, batch("c", "d")); err != nil {
+			t.Errorf("CreateTasks() up to the limit = %v", err)
+		}
+	})
+
+	t.Run("board columns", func(t *testing.T) {
+		f := newFixture(t)
+		owner := f.UserIDs[0]
+
+		mine := func(p *types.CreateTaskPayload) { p.UserID = &owner }
+		first := createTask(t, f, "first", mine)
+		second := createTask(t, f, "second", mine)
+		createTask(t, f, "third", func(p *types.CreateTaskPayload) { p.UserID, p.WorkspaceID = &owner, f.WorkspaceIDs[1] })
+		createTask(t, f, "unowned", nil)
+
+		column := types.BoardColumn{UserID: &owner, Status: "pending"}
+		inWorkspace := types.BoardColumn{WorkspaceID: f.WorkspaceIDs[0], UserID: &owner, Status: "pending"}
+		if n, err := f.Store.CountColumnTasks(ctx, column); err != nil || n != 3 {
+			t.Errorf("CountColumnTasks() = %d, %v, want 3", n, err)
+		}
+		if n, err := f.Store.CountColumnTasks(ctx, inWorkspace); err != nil || n != 2 {
+			t.Errorf("CountColumnTasks() in a workspace = %d, %v, want 2", n, err)
+		}
+
+		tasks, total, err := f.Store.GetColumnTasks(ctx, inWorkspace, utils.PaginationParams{Limit: 10, SortBy: "position", Order: "desc"})
+		if err != nil || total != 2 || !slices.Equal(titles(tasks), []string{"second", "first"}) {
+			t.Errorf("GetColumnTasks() = %v of %d, %v", titles(tasks), total, err)
+		}
+
+		if columns, err := f.Store.GetUnbalancedColumns(ctx, 32); err != nil || len(columns) != 0 {
+			t.Errorf("GetUnbalancedColumns() = %v, %v, want none", columns, err)
+		}
+		if _, err := f.Store.MoveTask(ctx, second.ID, second.Version, "pending", first.Position); err != nil {
+			t.Fatal(err)
+		}
+		columns, err := f.Store.GetUnbalancedColumns(ctx, 32)
+		if err != nil || len(columns) != 1 || columns[0].UserID == nil || *columns[0].UserID != owner || columns[0].Status != "pending" {
+			t.Fatalf("GetUnbalancedColumns() = %v, %v, want the column with a duplicate key", columns, err)
+		}
+
+		if err := f.Store.RebalanceColumn(ctx, column); err != nil {
+			t.Fatal(err)
+		}
+		if columns, err := f.Store.GetUnbalancedColumns(ctx, 32); err != nil || len(columns) != 0 {
+			t.Errorf("GetUnbalancedColumns() after rebalancing = %v, %v, want none", columns, err)
+		}
+		tasks, _, err = f.Store.GetColumnTasks(ctx, column, utils.PaginationParams{Limit: 10, SortBy: "position", Order: "asc"})
+		if err != nil || !slices.Equal(titles(tasks), []string{"first", "second", "third"}) {
+			t.Errorf("GetColumnTasks() after rebalancing = %v, %v, ties keep the ID order", titles(tasks), err)
+		}
+	})
+
+	t.Run("ended context", func(t *testing.T) {
+		f := newFixture(t)
+		task := createTask(t, f, "A", nil)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := f.Store.GetTaskByID(canceled, task.ID); !errors.Is(err, errs.ErrRequestTimeout) {
+			t.Errorf("GetTaskByID() with a canceled context = %v, want %v", err, errs.ErrRequestTimeout)
+		}
+		if _, err := f.Store.CreateTask(canceled, newTask(f, "B")); !errors.Is(err, errs.ErrRequestTimeout) {
+			t.Errorf("CreateTask() with a canceled context = %v, want %v", err, errs.ErrRequestTimeout)
+		}
+
+		expired, cancel := context.WithTimeoutCause(ctx, 0, errs.ErrQueryTimeout)
+		defer cancel()
+		if _, err := f.Store.UpdateTask(expired, task.ID, task.Version, fullUpdate(task)); !errors.Is(err, errs.ErrQueryTimeout) {
+			t.Errorf("UpdateTask() past its deadline = %v, want %v", err, errs.ErrQueryTimeout)
+		}
+
+		if got := getTask(t, f, task.ID); got.Version != task.Version {
+			t.Errorf("version = %d, the update must not be applied", got.Version)
+		}
+	})
+
+	t.Run("concurrent creates", func(t *testing.T) {
+		f := newFixture(t)
+
+		const n = 10
+		var wg sync.WaitGroup
+		ids := make([]int, n)
+		errs := make([]error, n)
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				task, err := f.Store.CreateTask(ctx, newTask(f, fmt.Sprint(i)))
+				if errs[i] = err; err == nil {
+					ids[i] = task.ID
+				}
+			}()
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		slices.Sort(ids)
+		if len(slices.Compact(ids)) != n {
+			t.Errorf("got IDs %v, want %d distinct", ids, n)
+		}
+	})
+
+	t.Run("concurrent moves into a limited column", func(t *testing.T) {
+		f := newFixture(t)
+		owner := f.UserIDs[0]
+
+		const n, limit = 10, 3
+		tasks := make([]*types.Task, n)
+		for i := range n {
+			tasks[i] = createTask(t, f, fmt.Sprint(i), func(p *types.CreateTaskPayload) { p.UserID = &owner })
+		}
+		if err := f.Limits.SetWIPLimit(ctx, types.WIPLimit{UserID: owner, Status: "in_progress", MaxTasks: limit, Mode: board.ModeReject}); err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		moved := make([]int64, n)
+		errs := make([]error, n)
+		for i, task := range tasks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				moved[i], errs[i] = f.Store.MoveTask(ctx, task.ID, task.Version, "in_progress", task.Position)
+			}()
+		}
+		wg.Wait()
+
+		accepted := 0
+		for i, err := range errs {
+			switch {
+			case err == nil && moved[i] == 1:
+				accepted++
+			case !errors.Is(err, board.ErrWIPLimitReached):
+				t.Errorf("MoveTask() of task %d = %d, %v, want a move or %v", i, moved[i], err, board.ErrWIPLimitReached)
+			}
+		}
+		column := types.BoardColumn{UserID: &owner, Status: "in_progress"}
+		if count, err := f.Store.CountColumnTasks(ctx, column); err != nil || accepted != limit || count != limit {
+			t.Errorf("%d moves accepted, column holds %d (%v), want %d", accepted, count, err, limit)
+		}
+	})
+}
+
+func newTask(f TaskFixture, title string) types.CreateTaskPayload {
+	return types.CreateTaskPayload{
+		Title:       title,
+		Description: ptr(""),
+		Priority:    1,
+		CreatorID:   f.UserIDs[0],
+		WorkspaceID: f.WorkspaceIDs[0],
+	}
+}
+
+// createTask creates a task from newTask changed by edit, which may be nil.
+func createTask(t *testing.T, f TaskFixture, title string, edit func(*types.CreateTaskPayload)) *types.Task {
+	t.Helper()
+
+	payload := newTask(f, title)
+	if edit != nil {
+		edit(&payload)
+	}
+
+	task, err := f.Store.CreateTask(context.Background(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return task
+}
+
+func getTask(t *testing.T, f TaskFixture, taskID int) *types.Task {
+	t.Helper()
+
+	task, err := f.Store.GetTaskByID(context.Background(), taskID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return task
+}
+
+// fullUpdate is the update that keeps every field of task, like the handlers merge them.
+func fullUpdate(task *types.Task) types.UpdateTaskPayload {
+	return types.UpdateTaskPayload{
+		UserID:                task.UserID,
+		Title:                 &task.Title,
+		Description:           &task.Description,
+		Status:                &task.Status,
+		Priority:              &task.Priority,
+		DueDate:               task.DueDate,
+		ChecklistAutoComplete: &task.ChecklistAutoComplete,
+		CustomFields:          task.CustomFields,
+	}
+}
+
+func titles(tasks []types.Task) []string {
+	var titles []string
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+
+	return titles
+}
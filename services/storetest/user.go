@@ -0,0 +1,121 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"todo/errs"
+	"todo/types"
+)
+
+// TestUserStore runs the conformance suite against the stores returned by newStore,
+// which has to return an empty store on every call.
+func TestUserStore(t *testing.T, newStore func(t *testing.T) types.UserStore) {
+	ctx := context.Background()
+
+	t.Run("create and get", func(t *testing.T) {
+		store := newStore(t)
+
+		want := types.User{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Password: "hash"}
+		if err := store.CreateUser(ctx, want); err != nil {
+			t.Fatal(err)
+		}
+
+		u, err := store.GetUserByEmail(ctx, want.Email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.ID == 0 || u.CreatedAt.IsZero() {
+			t.Errorf("got ID %d and created at %v, want both set", u.ID, u.CreatedAt)
+		}
+		if u.FirstName != want.FirstName || u.LastName != want.LastName || u.Email != want.Email || u.Password != want.Password {
+			t.Errorf("got %+v, want %+v", u, want)
+		}
+
+		byID, err := store.GetUserByID(ctx, u.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *byID != *u {
+			t.Errorf("GetUserByID() = %+v, want %+v", byID, u)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		store := newStore(t)
+
+		if _, err := store.GetUserByID(ctx, 1); !errors.Is(err, types.ErrUserNotFound) {
+			t.Errorf("GetUserByID() of a missing user = %v, want ErrUserNotFound", err)
+		}
+		if _, err := store.GetUserByEmail(ctx, "nobody@example.com"); !errors.Is(err, types.ErrUserNotFound) {
+			t.Errorf("GetUserByEmail() of a missing user = %v, want ErrUserNotFound", err)
+		}
+	})
+
+	t.Run("unique email", func(t *testing.T) {
+		store := newStore(t)
+
+		user := types.User{FirstName: "A", LastName: "B", Email: "taken@example.com", Password: "hash"}
+		if err := store.CreateUser(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		user.FirstName = "C"
+		if err := store.CreateUser(ctx, user); err == nil {
+			t.Fatal("CreateUser() with a taken email should fail")
+		}
+
+		u, err := store.GetUserByEmail(ctx, user.Email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.FirstName != "A" {
+			t.Errorf("first name = %q, the second user must not be stored", u.FirstName)
+		}
+	})
+
+	t.Run("ended context", func(t *testing.T) {
+		store := newStore(t)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		if err := store.CreateUser(canceled, types.User{FirstName: "A", LastName: "B", Email: "late@example.com", Password: "hash"}); !errors.Is(err, errs.ErrRequestTimeout) {
+			t.Errorf("CreateUser() with a canceled context = %v, want %v", err, errs.ErrRequestTimeout)
+		}
+		if _, err := store.GetUserByEmail(ctx, "late@example.com"); err == nil {
+			t.Error("the user of a canceled CreateUser() must not be stored")
+		}
+	})
+
+	t.Run("concurrent creates", func(t *testing.T) {
+		store := newStore(t)
+
+		const n = 10
+		var wg sync.WaitGroup
+		errs := make([]error, n)
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = store.CreateUser(ctx, types.User{FirstName: "U", LastName: fmt.Sprint(i), Email: fmt.Sprintf("u%d@example.com", i), Password: "hash"})
+			}()
+		}
+		wg.Wait()
+
+		ids := map[int]bool{}
+		for i, err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := store.GetUserByEmail(ctx, fmt.Sprintf("u%d@example.com", i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids[u.ID] = true
+		}
+		if len(ids) != n {
+			t.Errorf("got %d distinct IDs, want %d", len(ids), n)
+		}
+	})
+}
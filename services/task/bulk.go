@@ -0,0 +1,305 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"todo/errs"
+	"todo/services/board"
+	"todo/services/customfield"
+	"todo/services/history"
+	"todo/services/workflow"
+	"todo/services/workspace"
+	"todo/types"
+	"todo/utils"
+)
+
+// maxBulkTasks caps how many tasks one bulk request may touch.
+const maxBulkTasks = 500
+
+// maxBulkBodyBytes leaves room for maxBulkTasks changes in one body.
+const maxBulkBodyBytes = 4 << 20
+
+const (
+	opSetStatus   = "set_status"
+	opSetPriority = "set_priority"
+	opReassign    = "reassign"
+	opAddLabel    = "add_label"
+	opDelete      = "delete"
+)
+
+var (
+	errNotApplied  = errors.New("not applied because another task failed")
+	errInvalidBulk = errs.Validation("invalid_bulk_operation", "invalid bulk operation")
+)
+
+// columnKey identifies a board column as a map key.
+type columnKey struct {
+	workspaceID int
+	userID      int
+	status      string
+}
+
+// handleBulk applies one operation to many tasks in a single transaction. Every task is checked
+// like a single update first, the results report each task with the status it would get on its own.
+// WIP limits are checked against the board as it was before the request plus the tasks that earlier
+// items move into the same column.
+func (h *Handler) handleBulk(w http.ResponseWriter, r *http.Request) {
+	var payload types.BulkTaskPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	change, err := bulkChange(payload)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := utils.Validate.Struct(change); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+	if change.UserID != nil && !h.checkMembers(w, r, []int{*change.UserID}) {
+		return
+	}
+
+	ids, err := h.bulkTaskIDs(r, payload)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	results := make([]types.BulkTaskResult, len(ids))
+	var changes []types.BulkTaskChange
+	var before []*types.Task
+	var pending []int            // index in results of every change
+	added := map[columnKey]int{} // tasks moved into each column by the changes so far
+	failed := false
+	for i, id := range ids {
+		results[i] = types.BulkTaskResult{TaskID: id, Status: http.StatusOK}
+
+		existingTask, err := h.store.GetTaskByID(r.Context(), id)
+		if err != nil && !errors.Is(err, types.ErrTaskNotFound) {
+			problem := utils.NewProblem(http.StatusInternalServerError, err)
+			results[i].Status, results[i].Error = problem.Status, problem.Detail
+			failed = true
+			continue
+		}
+		if err != nil || !workspace.Contains(r.Context(), existingTask) {
+			results[i].Status, results[i].Error = http.StatusNotFound, "task not found"
+			failed = true
+			continue
+		}
+
+		item := types.BulkTaskChange{TaskID: id, Version: existingTask.Version}
+		if payload.Operation != opDelete {
+			update, status, err := h.bulkUpdate(r, existingTask, change, added)
+			if err != nil {
+				results[i].Status, results[i].Error = status, err.Error()
+				failed = true
+				continue
+			}
+			item.Update = update
+		}
+
+		changes = append(changes, item)
+		before = append(before, existingTask)
+		pending = append(pending, i)
+	}
+
+	if failed && payload.AllOrNothing {
+		writeBulkResponse(w, http.StatusConflict, results, pending, nil)
+		return
+	}
+
+	itemErrs, err := h.store.BulkUpdateTasks(r.Context(), changes, payload.AllOrNothing)
+	if err != nil && itemErrs == nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("bulk operation failed: %w", err))
+		return
+	}
+	for n, i := range pending {
+		if itemErrs[n] != nil {
+			problem := utils.NewProblem(http.StatusInternalServerError, itemErrs[n])
+			results[i].Status, results[i].Error = problem.Status, problem.Detail
+		}
+	}
+	if err != nil {
+		writeBulkResponse(w, http.StatusConflict, results, pending, itemErrs)
+		return
+	}
+
+	applied := 0
+	for n, i := range pending {
+		if itemErrs[n] != nil {
+			continue
+		}
+		applied++
+
+		if payload.Operation == opDelete {
+			h.recordEvent(r, history.ActionDelete, before[n], before[n])
+			continue
+		}
+		// the change is committed, failing the request here would only invite a retry
+		updatedTask, err := h.store.GetTaskByID(r.Context(), results[i].TaskID)
+		if err != nil {
+			log.Printf("failed to reload task %d after bulk update: %v", results[i].TaskID, err)
+			continue
+		}
+		h.recordEvent(r, history.ActionUpdate, before[n], updatedTask)
+	}
+
+	utils.WriteJson(w, http.StatusOK, types.BulkTaskResponse{Applied: applied, Results: results})
+}
+
+// bulkChange turns the operation into the update payload it stands for, so it is validated like a single update.
+func bulkChange(payload types.BulkTaskPayload) (types.UpdateTaskPayload, error) {
+	var change types.UpdateTaskPayload
+	var missing string
+
+	switch payload.Operation {
+	case opSetStatus:
+		change.Status, missing = payload.Status, "status"
+	case opSetPriority:
+		change.Priority, missing = payload.Priority, "priority"
+	case opReassign:
+		change.UserID, missing = payload.UserID, "user_id"
+	case opAddLabel:
+		if payload.Label != nil {
+			change.Labels = &[]string{*payload.Label}
+		}
+		missing = "label"
+	case opDelete:
+		return change, nil
+	}
+
+	if change.Status == nil && change.Priority == nil && change.UserID == nil && change.Labels == nil {
+		return change, errInvalidBulk.Errorf("%s needs %s", payload.Operation, missing)
+	}
+
+	return change, nil
+}
+
+// bulkTaskIDs returns the explicit IDs or the tasks matching the filter in the selected workspace.
+func (h *Handler) bulkTaskIDs(r *http.Request, payload types.BulkTaskPayload) ([]int, error) {
+	if (len(payload.IDs) > 0) == (payload.Filter != nil) {
+		return nil, errInvalidBulk.Errorf("give either ids or a filter")
+	}
+	if len(payload.IDs) > maxBulkTasks {
+		return nil, errInvalidBulk.Errorf("at most %d tasks can be changed at once", maxBulkTasks)
+	}
+	if len(payload.IDs) > 0 {
+		return payload.IDs, nil
+	}
+
+	filter := types.TaskFilter{
+		WorkspaceID: workspace.IDFromContext(r.Context()),
+		AssigneeID:  payload.Filter.AssigneeID,
+		WatcherID:   payload.Filter.WatcherID,
+		Label:       payload.Filter.Label,
+	}
+	tasks, total, err := h.store.GetPaginatedTasks(r.Context(), filter, utils.PaginationParams{Page: 1, Limit: maxBulkTasks, SortBy: "id", Order: "ASC"})
+	if err != nil {
+		return nil, err
+	}
+	if total > maxBulkTasks {
+		return nil, errInvalidBulk.Errorf("the filter matches %d tasks, at most %d can be changed at once", total, maxBulkTasks)
+	}
+
+	ids := make([]int, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+
+	return ids, nil
+}
+
+// bulkUpdate merges the change into the task and runs the checks of a single update,
+// it returns the status code a failing check would have. A task that passes and moves
+// into another column is counted in added.
+func (h *Handler) bulkUpdate(r *http.Request, task *types.Task, change types.UpdateTaskPayload, added map[columnKey]int) (*types.UpdateTaskPayload, int, error) {
+	update := types.UpdateTaskPayload{
+		UserID:      task.UserID,
+		Title:       &task.Title,
+		Description: &task.Description,
+		Status:      &task.Status,
+		Priority:    &task.Priority,
+		DueDate:     task.DueDate,
+
+		ChecklistAutoComplete: &task.ChecklistAutoComplete,
+		CustomFields:          task.CustomFields,
+		AssigneeIDs:           &task.AssigneeIDs,
+		Labels:                &task.Labels,
+	}
+	switch {
+	case change.Status != nil:
+		update.Status = change.Status
+	case change.Priority != nil:
+		update.Priority = change.Priority
+	case change.UserID != nil:
+		update.UserID = change.UserID
+	case change.Labels != nil:
+		if !slices.Contains(task.Labels, (*change.Labels)[0]) {
+			labels := append(slices.Clone(task.Labels), (*change.Labels)[0])
+			update.Labels = &labels
+		}
+	}
+
+	// a new owner brings other custom field definitions
+	if !sameUser(task.UserID, update.UserID) {
+		fields, err := h.fieldStore.GetCustomFields(r.Context(), *update.UserID)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		if update.CustomFields, err = customfield.Validate(fields, task.CustomFields); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	column := types.BoardColumn{WorkspaceID: task.WorkspaceID, UserID: update.UserID, Status: *update.Status}
+	wf, err := h.workflowStore.GetWorkflow(r.Context(), column.UserID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	from := ""
+	if sameUser(task.UserID, column.UserID) {
+		from = task.Status
+	}
+	if err := workflow.ValidateStatusChange(wf, from, column.Status); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	var key columnKey
+	if column.UserID != nil {
+		key = columnKey{workspaceID: column.WorkspaceID, userID: *column.UserID, status: column.Status}
+	}
+	if _, err := board.CheckBatchWIPLimit(r.Context(), h.wipStore, h.store, task, column, added[key]); err != nil {
+		if errors.Is(err, board.ErrWIPLimitReached) {
+			return nil, http.StatusConflict, err
+		}
+		return nil, http.StatusInternalServerError, err
+	}
+	if column.UserID != nil && (from == "" || from != column.Status) {
+		added[key]++
+	}
+
+	return &update, http.StatusOK, nil
+}
+
+// writeBulkResponse reports a rolled back request, tasks that passed their checks are marked as not applied.
+func writeBulkResponse(w http.ResponseWriter, status int, results []types.BulkTaskResult, pending []int, itemErrs []error) {
+	for n, i := range pending {
+		if itemErrs == nil || itemErrs[n] == nil {
+			results[i].Status, results[i].Error = http.StatusConflict, errNotApplied.Error()
+		}
+	}
+
+	utils.WriteJson(w, status, types.BulkTaskResponse{Applied: 0, Results: results})
+}
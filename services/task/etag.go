@@ -0,0 +1,68 @@
+package task
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"todo/errs"
+	"todo/types"
+	"todo/utils"
+)
+
+var (
+	errVersionMismatch = errs.Conflict("version_conflict", "task was changed by someone else, reload it and try again")
+	errIfMatchFailed   = errs.New(http.StatusPreconditionFailed, "precondition_failed", "If-Match does not name the current version of the task")
+)
+
+// etag is the strong entity tag of the task's representation. Version alone is not enough,
+// checklist items, comments, assignees and watchers are stored apart and change the counts
+// and ids served with the task without a write to the task itself.
+func etag(task *types.Task) string {
+	body, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Sprintf(`"%d"`, task.Version)
+	}
+
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf(`"%d-%x"`, task.Version, sum[:8])
+}
+
+// setETag adds the ETag header for a task that was read or written.
+func setETag(w http.ResponseWriter, task *types.Task) {
+	if task != nil && task.ID != 0 {
+		w.Header().Set("ETag", etag(task))
+	}
+}
+
+// checkIfMatch writes a 412 when the request carries an If-Match header that does not
+// name the task's current version. Weak tags never match.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, task *types.Task) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" || strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	current := etag(task)
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == current {
+			return true
+		}
+	}
+
+	w.Header().Set("ETag", current)
+	utils.WriteError(w, http.StatusPreconditionFailed, errIfMatchFailed)
+	return false
+}
+
+// writeVersionConflict reports a write that lost the race against another one. It is a failed
+// precondition when the client sent If-Match and a plain conflict otherwise.
+func writeVersionConflict(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("If-Match") != "" {
+		utils.WriteError(w, http.StatusPreconditionFailed, errIfMatchFailed)
+		return
+	}
+
+	utils.WriteError(w, http.StatusConflict, errVersionMismatch)
+}
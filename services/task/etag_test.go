@@ -0,0 +1,66 @@
+package task
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"todo/types"
+)
+
+func TestETag(t *testing.T) {
+	task := types.Task{ID: 1, Version: 3, AssigneeIDs: []int{1}}
+	tag := etag(&task)
+
+	if again := task; etag(&again) != tag {
+		t.Errorf("etag of an unchanged task = %s, want %s", etag(&again), tag)
+	}
+
+	// none of these write the task row, so the version stays the same
+	changes := map[string]func(*types.Task){
+		"checklist": func(task *types.Task) { task.Checklist.Done++ },
+		"comments":  func(task *types.Task) { task.CommentCount++ },
+		"assignees": func(task *types.Task) { task.AssigneeIDs = []int{1, 2} },
+		"watchers":  func(task *types.Task) { task.WatcherIDs = []int{2} },
+	}
+	for name, change := range changes {
+		changed := task
+		change(&changed)
+		if etag(&changed) == tag {
+			t.Errorf("etag did not change with the %s", name)
+		}
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	task := &types.Task{ID: 1, Version: 3}
+	current := etag(task)
+	stale := etag(&types.Task{ID: 1, Version: 3, CommentCount: 1})
+
+	tests := []struct {
+		ifMatch string
+		want    bool
+	}{
+		{"", true},
+		{"*", true},
+		{current, true},
+		{stale + ", " + current, true},
+		{stale, false},
+		{`"3"`, false},
+		{"W/" + current, false},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPut, "/tasks/1", nil)
+		if tt.ifMatch != "" {
+			r.Header.Set("If-Match", tt.ifMatch)
+		}
+		w := httptest.NewRecorder()
+
+		if got := checkIfMatch(w, r, task); got != tt.want {
+			t.Errorf("checkIfMatch(%q) = %v, want %v", tt.ifMatch, got, tt.want)
+		}
+		if !tt.want && w.Code != http.StatusPreconditionFailed {
+			t.Errorf("checkIfMatch(%q) wrote %d, want 412", tt.ifMatch, w.Code)
+		}
+	}
+}
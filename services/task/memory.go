@@ -0,0 +1,1008 @@
+package task
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"todo/errs"
+	"todo/services/board"
+	"todo/services/customfield"
+	"todo/types"
+	"todo/utils"
+)
+
+// MemoryStore keeps tasks in memory with the semantics of Store, for tests and the demo mode.
+// It is safe for concurrent use. Comments and checklist items are kept by stores of their own,
+// which Store joins in the database. MemoryStore cannot count them without a query per task it
+// returns, so every task reports no comments and an empty checklist, the conformance suite pins this.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	tasks  map[int]*memoryTask
+	nextID int
+	limits types.WIPLimitStore
+}
+
+// memoryTask is a row of the tasks table with its assignees, watchers and labels. Rows are
+// replaced on every write and never changed in place, so a copy of the map is a snapshot.
+type memoryTask struct {
+	types.Task
+
+	// custom fields as Store keeps them, values holds them decoded for filters and sorting
+	customFields []byte
+	values       map[string]any
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tasks:  map[int]*memoryTask{},
+		nextID: 1,
+	}
+}
+
+// UseWIPLimits makes moves into a column fail with board.ErrWIPLimitReached once its reject
+// limit is reached, like Store does. Without limits every move is allowed.
+func (s *MemoryStore) UseWIPLimits(limits types.WIPLimitStore) {
+	s.limits = limits
+}
+
+// GetTaskByID returns types.ErrTaskNotFound for missing and trashed tasks.
+func (s *MemoryStore) GetTaskByID(ctx context.Context, taskID int) (*types.Task, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.tasks[taskID]
+	if !ok || t.DeletedAt != nil {
+		return nil, types.ErrTaskNotFound
+	}
+
+	return t.read()
+}
+
+// GetTaskWorkspaceID looks at trashed tasks too, it returns 0 when the task does not exist.
+func (s *MemoryStore) GetTaskWorkspaceID(ctx context.Context, taskID int) (int, error) {
+	if err := canceled(ctx); err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if t, ok := s.tasks[taskID]; ok {
+		return t.WorkspaceID, nil
+	}
+
+	return 0, nil
+}
+
+// GetStoredTask returns the task whether or not it is in the trash, nil when it does not exist.
+func (s *MemoryStore) GetStoredTask(ctx context.Context, taskID int) (*types.Task, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.tasks[taskID]
+	if !ok {
+		return nil, nil
+	}
+
+	return t.read()
+}
+
+func (s *MemoryStore) GetPaginatedTasks(ctx context.Context, filter types.TaskFilter, pagination utils.PaginationParams) ([]types.Task, int, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.page(pagination, func(t *memoryTask) bool {
+		if t.DeletedAt != nil || !inWorkspace(t, filter.WorkspaceID) {
+			return false
+		}
+		if filter.AssigneeID != nil && !slices.Contains(t.AssigneeIDs, *filter.AssigneeID) {
+			return false
+		}
+		if filter.WatcherID != nil && !slices.Contains(t.WatcherIDs, *filter.WatcherID) {
+			return false
+		}
+		if filter.Label != nil && !slices.Contains(t.Labels, *filter.Label) {
+			return false
+		}
+		for _, f := range filter.CustomFields {
+			if !matchesCustomField(t.values[f.Key], f) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func (s *MemoryStore) GetPaginatedTrashedTasks(ctx context.Context, filter types.TaskFilter, pagination utils.PaginationParams) ([]types.Task, int, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.page(pagination, func(t *memoryTask) bool {
+		return t.DeletedAt != nil && inWorkspace(t, filter.WorkspaceID)
+	})
+}
+
+func (s *MemoryStore) GetColumnTasks(ctx context.Context, column types.BoardColumn, pagination utils.PaginationParams) ([]types.Task, int, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.page(pagination, func(t *memoryTask) bool {
+		return inColumn(t, column) && inWorkspace(t, column.WorkspaceID)
+	})
+}
+
+func (s *MemoryStore) CountColumnTasks(ctx context.Context, column types.BoardColumn) (int, error) {
+	if err := canceled(ctx); err != nil {
+		return 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, t := range s.tasks {
+		if inColumn(t, column) && inWorkspace(t, column.WorkspaceID) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// CreateTask returns the inserted task with its generated ID, timestamps and position.
+func (s *MemoryStore) CreateTask(ctx context.Context, task types.CreateTaskPayload) (*types.Task, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.createTask(task)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.read()
+}
+
+// CreateTasks creates every task or none like Store.CreateTasks.
+func (s *MemoryStore) CreateTasks(ctx context.Context, tasks []types.CreateTaskPayload) ([]types.Task, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot, nextID := maps.Clone(s.tasks), s.nextID
+	rollback := func() {
+		s.tasks, s.nextID = snapshot, nextID
+	}
+
+	rows := make([]*memoryTask, len(tasks))
+	for i, task := range tasks {
+		if task.Status == "" {
+			task.Status = "pending"
+		}
+		column := types.BoardColumn{WorkspaceID: task.WorkspaceID, UserID: task.UserID, Status: task.Status}
+		if err := s.checkWIPLimit(ctx, &memoryTask{}, column); err != nil {
+			rollback()
+			return nil, fmt.Errorf("task %d: %w", i+1, err)
+		}
+
+		t, err := s.createTask(task)
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+		rows[i] = t
+	}
+
+	created := make([]types.Task, len(rows))
+	for i, t := range rows {
+		task, err := t.read()
+		if err != nil {
+			return nil, err
+		}
+		created[i] = *task
+	}
+
+	return created, nil
+}
+
+// createTask adds the task at the bottom of its column, the caller holds the write lock.
+func (s *MemoryStore) createTask(task types.CreateTaskPayload) (*memoryTask, error) {
+	if task.Status == "" {
+		task.Status = "pending"
+	}
+
+	now := memoryNow()
+	t := &memoryTask{Task: types.Task{
+		ID:                    s.nextID,
+		WorkspaceID:           task.WorkspaceID,
+		UserID:                clone(task.UserID),
+		Title:                 task.Title,
+		Status:                task.Status,
+		Priority:              task.Priority,
+		DueDate:               utcClone(task.DueDate),
+		CreatedAt:             now,
+		UpdatedAt:             now,
+		ChecklistAutoComplete: task.ChecklistAutoComplete,
+		Position:              positionBetween(s.lastPosition(types.BoardColumn{UserID: task.UserID, Status: task.Status}), ""),
+		Version:               1,
+	}}
+	if task.Description != nil {
+		t.Description = *task.Description
+	}
+	if err := t.setCustomFields(task.CustomFields); err != nil {
+		return nil, err
+	}
+
+	// the creator watches the task from the start
+	if task.CreatorID > 0 {
+		t.CreatorID = &task.CreatorID
+		t.WatcherIDs = []int{task.CreatorID}
+	}
+	t.setAssignees(task.AssigneeIDs)
+	t.setLabels(task.Labels)
+
+	s.tasks[t.ID] = t
+	s.nextID++
+
+	return t, nil
+}
+
+// UpdateTask overwrites every field like Store.UpdateTask, it returns 0 unless the task is still at version.
+func (s *MemoryStore) UpdateTask(ctx context.Context, taskID, version int, task types.UpdateTaskPayload) (int64, error) {
+	if err := canceled(ctx); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateTask(ctx, taskID, version, task)
+}
+
+// BulkUpdateTasks applies every change and returns the error of each item. With allOrNothing
+// the first failure restores the tasks as they were, otherwise failed items are skipped.
+func (s *MemoryStore) BulkUpdateTasks(ctx context.Context, changes []types.BulkTaskChange, allOrNothing bool) ([]error, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := maps.Clone(s.tasks)
+
+	itemErrs := make([]error, len(changes))
+	for i, change := range changes {
+		var n int64
+		if change.Update == nil {
+			if n, itemErrs[i] = s.deleteTask(change.TaskID); itemErrs[i] == nil && n == 0 {
+				itemErrs[i] = types.ErrTaskNotFound
+			}
+		} else {
+			if n, itemErrs[i] = s.updateTask(ctx, change.TaskID, change.Version, *change.Update); itemErrs[i] == nil && n == 0 {
+				itemErrs[i] = errVersionMismatch
+			}
+		}
+
+		if itemErrs[i] != nil && allOrNothing {
+			s.tasks = snapshot
+			return itemErrs, itemErrs[i]
+		}
+	}
+
+	return itemErrs, nil
+}
+
+// updateTask writes nothing when it fails, a failed bulk item needs no rollback.
+func (s *MemoryStore) updateTask(ctx context.Context, taskID, version int, task types.UpdateTaskPayload) (int64, error) {
+	t, ok := s.tasks[taskID]
+	if !ok || t.DeletedAt != nil || t.Version != version {
+		return 0, nil
+	}
+
+	if err := s.checkWIPLimit(ctx, t, types.BoardColumn{WorkspaceID: t.WorkspaceID, UserID: task.UserID, Status: *task.Status}); err != nil {
+		return 0, err
+	}
+
+	next := *t
+	if err := next.setCustomFields(task.CustomFields); err != nil {
+		return 0, err
+	}
+
+	// a task that changes column is appended to the new one
+	if !sameUser(t.UserID, task.UserID) || t.Status != *task.Status {
+		next.Position = positionBetween(s.lastPosition(types.BoardColumn{UserID: task.UserID, Status: *task.Status}), "")
+	}
+
+	next.UserID = clone(task.UserID)
+	next.Title = *task.Title
+	next.Description = *task.Description
+	next.Status = *task.Status
+	next.Priority = *task.Priority
+	next.DueDate = utcClone(task.DueDate)
+	next.ChecklistAutoComplete = *task.ChecklistAutoComplete
+	if task.AssigneeIDs != nil {
+		next.setAssignees(*task.AssigneeIDs)
+	}
+	if task.Labels != nil {
+		next.setLabels(*task.Labels)
+	}
+	s.write(&next, true)
+
+	return 1, nil
+}
+
+// PatchTask writes only the changed fields like Store.PatchTask, it returns 0 unless the task is still at version.
+func (s *MemoryStore) PatchTask(ctx context.Context, taskID, version int, task types.TaskDocument, changed []string) (int64, error) {
+	if err := canceled(ctx); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tasks[taskID]
+	if !ok || t.DeletedAt != nil || t.Version != version {
+		return 0, nil
+	}
+
+	next := *t
+	for _, field := range changed {
+		switch field {
+		case "user_id":
+			next.UserID = clone(task.UserID)
+		case "title":
+			next.Title = task.Title
+		case "description":
+			next.Description = task.Description
+		case "status":
+			next.Status = task.Status
+		case "priority":
+			next.Priority = task.Priority
+		case "due_date":
+			next.DueDate = utcClone(task.DueDate)
+		case "checklist_auto_complete":
+			next.ChecklistAutoComplete = task.ChecklistAutoComplete
+		case "custom_fields":
+			if err := next.setCustomFields(task.CustomFields); err != nil {
+				return 0, err
+			}
+		case "assignee_ids":
+			next.setAssignees(task.AssigneeIDs)
+		case "labels":
+			next.setLabels(task.Labels)
+		}
+	}
+
+	if slices.Contains(changed, "user_id") || slices.Contains(changed, "status") {
+		if err := s.checkWIPLimit(ctx, t, types.BoardColumn{WorkspaceID: t.WorkspaceID, UserID: task.UserID, Status: task.Status}); err != nil {
+			return 0, err
+		}
+		next.Position = positionBetween(s.lastPosition(types.BoardColumn{UserID: task.UserID, Status: task.Status}), "")
+	}
+	s.write(&next, true)
+
+	return 1, nil
+}
+
+// MoveTask places the task in the given status column at the given position.
+// It returns 0 unless the task is still at version.
+func (s *MemoryStore) MoveTask(ctx context.Context, taskID, version int, status, position string) (int64, error) {
+	if err := canceled(ctx); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tasks[taskID]
+	if !ok || t.DeletedAt != nil || t.Version != version {
+		return 0, nil
+	}
+
+	if err := s.checkWIPLimit(ctx, t, types.BoardColumn{WorkspaceID: t.WorkspaceID, UserID: t.UserID, Status: status}); err != nil {
+		return 0, err
+	}
+
+	next := *t
+	next.Status = status
+	next.Position = position
+	s.write(&next, true)
+
+	return 1, nil
+}
+
+// checkWIPLimit is the check of Store, the write lock held by the caller keeps the count current.
+func (s *MemoryStore) checkWIPLimit(ctx context.Context, t *memoryTask, to types.BoardColumn) error {
+	if s.limits == nil || to.UserID == nil || (sameUser(t.UserID, to.UserID) && t.Status == to.Status) {
+		return nil
+	}
+
+	limit, err := s.limits.GetWIPLimit(ctx, *to.UserID, to.Status)
+	if err != nil || limit == nil || limit.Mode != board.ModeReject {
+		return err
+	}
+
+	count := 0
+	for _, other := range s.tasks {
+		if inColumn(other, to) && inWorkspace(other, to.WorkspaceID) {
+			count++
+		}
+	}
+	if count >= limit.MaxTasks {
+		return fmt.Errorf("%w: column %s already holds %d of %d tasks", board.ErrWIPLimitReached, to.Status, count, limit.MaxTasks)
+	}
+
+	return nil
+}
+
+// GetLastPosition returns the highest position in the column, or an empty string for an empty column.
+func (s *MemoryStore) GetLastPosition(ctx context.Context, column types.BoardColumn) (string, error) {
+	if err := canceled(ctx); err != nil {
+		return "", err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lastPosition(column), nil
+}
+
+// lastPosition ignores the workspace of the column like Store does, positions are per owner.
+func (s *MemoryStore) lastPosition(column types.BoardColumn) string {
+	last := ""
+	for _, t := range s.tasks {
+		if inColumn(t, column) && t.Position > last {
+			last = t.Position
+		}
+	}
+
+	return last
+}
+
+// GetUnbalancedColumns lists columns whose keys grew too long or collide.
+func (s *MemoryStore) GetUnbalancedColumns(ctx context.Context, maxKeyLength int) ([]types.BoardColumn, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type column struct {
+		userID int // 0 for tasks without owner
+		status string
+	}
+	positions := map[column][]string{}
+	for _, t := range s.tasks {
+		if t.DeletedAt != nil {
+			continue
+		}
+		c := column{status: t.Status}
+		if t.UserID != nil {
+			c.userID = *t.UserID
+		}
+		positions[c] = append(positions[c], t.Position)
+	}
+
+	var columns []types.BoardColumn
+	for c, keys := range positions {
+		slices.Sort(keys)
+		tooLong := slices.ContainsFunc(keys, func(key string) bool { return len(key) > maxKeyLength })
+		if !tooLong && len(slices.Compact(keys)) == len(keys) {
+			continue
+		}
+
+		unbalanced := types.BoardColumn{Status: c.status}
+		if c.userID != 0 {
+			unbalanced.UserID = &c.userID
+		}
+		columns = append(columns, unbalanced)
+	}
+	slices.SortFunc(columns, func(a, b types.BoardColumn) int {
+		return cmp.Or(compareNull(a.UserID, b.UserID, cmp.Compare[int]), cmp.Compare(a.Status, b.Status))
+	})
+
+	return columns, nil
+}
+
+// RebalanceColumn rewrites every position in the column with short, evenly spaced keys keeping the current order.
+func (s *MemoryStore) RebalanceColumn(ctx context.Context, column types.BoardColumn) error {
+	if err := canceled(ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tasks []*memoryTask
+	for _, t := range s.tasks {
+		if inColumn(t, column) {
+			tasks = append(tasks, t)
+		}
+	}
+	slices.SortFunc(tasks, func(a, b *memoryTask) int {
+		return cmp.Or(cmp.Compare(a.Position, b.Position), cmp.Compare(a.ID, b.ID))
+	})
+
+	for i, key := range evenPositions(len(tasks)) {
+		if tasks[i].Position == key {
+			continue
+		}
+		next := *tasks[i]
+		next.Position = key
+		s.write(&next, false)
+	}
+
+	return nil
+}
+
+// DeleteTask moves the task to the trash, it can be restored until it is purged.
+func (s *MemoryStore) DeleteTask(ctx context.Context, taskID int) (int64, error) {
+	if err := canceled(ctx); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.deleteTask(taskID)
+}
+
+func (s *MemoryStore) deleteTask(taskID int) (int64, error) {
+	t, ok := s.tasks[taskID]
+	if !ok || t.DeletedAt != nil {
+		return 0, nil
+	}
+
+	next := *t
+	now := memoryNow()
+	next.DeletedAt = &now
+	s.write(&next, false)
+
+	return 1, nil
+}
+
+func (s *MemoryStore) RestoreTask(ctx context.Context, taskID int) (int64, error) {
+	if err := canceled(ctx); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tasks[taskID]
+	if !ok || t.DeletedAt == nil {
+		return 0, nil
+	}
+
+	next := *t
+	next.DeletedAt = nil
+	s.write(&next, false)
+
+	return 1, nil
+}
+
+// PurgeTask removes the task for good, whether or not it is in the trash.
+func (s *MemoryStore) PurgeTask(ctx context.Context, taskID int) (int64, error) {
+	if err := canceled(ctx); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[taskID]; !ok {
+		return 0, nil
+	}
+	delete(s.tasks, taskID)
+
+	return 1, nil
+}
+
+// GetPurgeableTaskIDs lists the tasks that have been in the trash for longer than retention.
+func (s *MemoryStore) GetPurgeableTaskIDs(ctx context.Context, retention time.Duration) ([]int, error) {
+	if err := canceled(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cutoff := memoryNow().Add(-retention.Truncate(time.Second))
+
+	var ids []int
+	for _, t := range s.tasks {
+		if t.DeletedAt != nil && t.DeletedAt.Before(cutoff) {
+			ids = append(ids, t.ID)
+		}
+	}
+	slices.Sort(ids)
+
+	return ids, nil
+}
+
+// RenameStatus moves the user's tasks, trashed ones included, from one status to the other.
+// The workflow store renames statuses in the database, the demo mode passes them on with it.
+func (s *MemoryStore) RenameStatus(ctx context.Context, userID int, from, to string) error {
+	if err := canceled(ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tasks {
+		if sameUser(t.UserID, &userID) && t.Status == from {
+			next := *t
+			next.Status = to
+			s.write(&next, false)
+		}
+	}
+
+	return nil
+}
+
+// RemoveCustomField drops the value of the custom field key from the user's tasks, like deleting
+// the field in the custom field store does.
+func (s *MemoryStore) RemoveCustomField(ctx context.Context, userID int, key string) error {
+	if err := canceled(ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := maps.Clone(s.tasks)
+	for _, t := range s.tasks {
+		if _, ok := t.values[key]; !ok || !sameUser(t.UserID, &userID) {
+			continue
+		}
+
+		values := maps.Clone(t.values)
+		delete(values, key)
+		next := *t
+		if err := next.setCustomFields(values); err != nil {
+			s.tasks = snapshot
+			return err
+		}
+		s.write(&next, false)
+	}
+
+	return nil
+}
+
+// SetAssigned adds or removes an assignee like the assignment store does, new assignees start
+// watching the task. The task itself is not written, its version stays.
+func (s *MemoryStore) SetAssigned(ctx context.Context, taskID, userID int, assigned bool) error {
+	if err := canceled(ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tasks[taskID]
+	if !ok {
+		return nil
+	}
+
+	next := *t
+	if assigned {
+		next.setAssignees(append(slices.Clone(t.AssigneeIDs), userID))
+	} else {
+		next.AssigneeIDs = slices.DeleteFunc(slices.Clone(t.AssigneeIDs), func(id int) bool { return id == userID })
+	}
+	s.tasks[taskID] = &next
+
+	return nil
+}
+
+// SetWatching adds or removes a watcher like the assignment store does, without writing the task.
+func (s *MemoryStore) SetWatching(ctx context.Context, taskID, userID int, watching bool) error {
+	if err := canceled(ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tasks[taskID]
+	if !ok {
+		return nil
+	}
+
+	next := *t
+	if watching {
+		next.WatcherIDs = sortedSet(append(slices.Clone(t.WatcherIDs), userID))
+	} else {
+		next.WatcherIDs = slices.DeleteFunc(slices.Clone(t.WatcherIDs), func(id int) bool { return id == userID })
+	}
+	s.tasks[taskID] = &next
+
+	return nil
+}
+
+// write stores the new version of a task, like the database it stamps updated_at.
+func (s *MemoryStore) write(t *memoryTask, bumpVersion bool) {
+	if bumpVersion {
+		t.Version++
+	}
+	t.UpdatedAt = memoryNow()
+	s.tasks[t.ID] = t
+}
+
+// page sorts the matching tasks and cuts out one page, ties are broken by ID.
+func (s *MemoryStore) page(pagination utils.PaginationParams, match func(*memoryTask) bool) ([]types.Task, int, error) {
+	compare, err := taskOrder(pagination.SortBy)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var matched []*memoryTask
+	for _, t := range s.tasks {
+		if match(t) {
+			matched = append(matched, t)
+		}
+	}
+
+	desc := strings.EqualFold(pagination.Order, "desc")
+	slices.SortFunc(matched, func(a, b *memoryTask) int {
+		c := compare(a, b)
+		if desc {
+			c = -c
+		}
+		return cmp.Or(c, cmp.Compare(a.ID, b.ID))
+	})
+
+	total := len(matched)
+	start := min(max(pagination.Offset, 0), total)
+	end := min(start+max(pagination.Limit, 0), total)
+
+	var tasks []types.Task
+	for _, t := range matched[start:end] {
+		task, err := t.read()
+		if err != nil {
+			return nil, 0, err
+		}
+		tasks = append(tasks, *task)
+	}
+
+	return tasks, total, nil
+}
+
+// taskOrder compares tasks by a sort_by value, NULLs come first like on MySQL and SQLite.
+func taskOrder(sortBy string) (func(a, b *memoryTask) int, error) {
+	if key, ok := strings.CutPrefix(sortBy, customFieldPrefix); ok {
+		return func(a, b *memoryTask) int { return compareJSON(a.values[key], b.values[key]) }, nil
+	}
+
+	compareTime := func(a, b time.Time) int { return a.Compare(b) }
+	switch sortBy {
+	case "id":
+		return func(a, b *memoryTask) int { return cmp.Compare(a.ID, b.ID) }, nil
+	case "user_id":
+		return func(a, b *memoryTask) int { return compareNull(a.UserID, b.UserID, cmp.Compare[int]) }, nil
+	case "title":
+		return func(a, b *memoryTask) int { return cmp.Compare(a.Title, b.Title) }, nil
+	case "status":
+		return func(a, b *memoryTask) int { return cmp.Compare(a.Status, b.Status) }, nil
+	case "priority":
+		return func(a, b *memoryTask) int { return cmp.Compare(a.Priority, b.Priority) }, nil
+	case "position":
+		return func(a, b *memoryTask) int { return cmp.Compare(a.Position, b.Position) }, nil
+	case "due_date":
+		return func(a, b *memoryTask) int { return compareNull(a.DueDate, b.DueDate, compareTime) }, nil
+	case "created_at":
+		return func(a, b *memoryTask) int { return a.CreatedAt.Compare(b.CreatedAt) }, nil
+	case "updated_at":
+		return func(a, b *memoryTask) int { return a.UpdatedAt.Compare(b.UpdatedAt) }, nil
+	case "deleted_at":
+		return func(a, b *memoryTask) int { return compareNull(a.DeletedAt, b.DeletedAt, compareTime) }, nil
+	}
+
+	return nil, fmt.Errorf("unknown sort field: %s", sortBy)
+}
+
+func compareNull[T any](a, b *T, compare func(T, T) int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	return compare(*a, *b)
+}
+
+// compareJSON orders decoded JSON values, values of different types are ordered by type.
+func compareJSON(a, b any) int {
+	rank := func(v any) int {
+		switch v.(type) {
+		case nil:
+			return 0
+		case bool:
+			return 1
+		case float64:
+			return 2
+		case string:
+			return 3
+		}
+		return 4
+	}
+
+	if c := cmp.Compare(rank(a), rank(b)); c != 0 {
+		return c
+	}
+
+	switch a := a.(type) {
+	case bool:
+		if a == b.(bool) {
+			return 0
+		}
+		if a {
+			return 1
+		}
+		return -1
+	case float64:
+		return cmp.Compare(a, b.(float64))
+	case string:
+		return cmp.Compare(a, b.(string))
+	}
+
+	return 0
+}
+
+// matchesCustomField checks a stored value against a filter like customFieldCondition does.
+func matchesCustomField(value any, f types.CustomFieldFilter) bool {
+	want, err := jsonValue(f.Value)
+	if err != nil {
+		return false
+	}
+
+	if f.Type == customfield.TypeMultiSelect {
+		options, ok := value.([]any)
+		return ok && slices.Contains(options, want)
+	}
+
+	return value != nil && value == want
+}
+
+// jsonValue converts v into what decoding its JSON gives, e.g. ints into float64.
+func jsonValue(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	err = json.Unmarshal(b, &value)
+
+	return value, err
+}
+
+// read returns a copy of the task that shares no memory with the store.
+func (t *memoryTask) read() (*types.Task, error) {
+	task := t.Task
+	task.UserID = clone(t.UserID)
+	task.CreatorID = clone(t.CreatorID)
+	task.DueDate = clone(t.DueDate)
+	task.DeletedAt = clone(t.DeletedAt)
+	task.AssigneeIDs = append([]int{}, t.AssigneeIDs...)
+	task.WatcherIDs = append([]int{}, t.WatcherIDs...)
+	task.Labels = append([]string{}, t.Labels...)
+
+	task.CustomFields = map[string]any{}
+	if t.customFields != nil {
+		if err := json.Unmarshal(t.customFields, &task.CustomFields); err != nil {
+			return nil, err
+		}
+	}
+
+	return &task, nil
+}
+
+func (t *memoryTask) setCustomFields(values map[string]any) error {
+	t.customFields, t.values = nil, map[string]any{}
+	if len(values) == 0 {
+		return nil
+	}
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	t.customFields = b
+
+	return json.Unmarshal(b, &t.values)
+}
+
+// setAssignees replaces the assignees, new assignees start watching the task.
+func (t *memoryTask) setAssignees(userIDs []int) {
+	t.AssigneeIDs = sortedSet(userIDs)
+	t.WatcherIDs = sortedSet(slices.Concat(t.WatcherIDs, userIDs))
+}
+
+func (t *memoryTask) setLabels(labels []string) {
+	t.Labels = sortedSet(labels)
+}
+
+// inColumn reports whether t is a live task of the column, the workspace is not checked.
+func inColumn(t *memoryTask, column types.BoardColumn) bool {
+	return t.DeletedAt == nil && sameUser(t.UserID, column.UserID) && t.Status == column.Status
+}
+
+// inWorkspace reports whether t belongs to the workspace, 0 matches every workspace.
+func inWorkspace(t *memoryTask, workspaceID int) bool {
+	return workspaceID == 0 || t.WorkspaceID == workspaceID
+}
+
+// canceled fails a call whose ctx already ended with the error Store would return.
+func canceled(ctx context.Context) error {
+	return errs.FromContext(ctx, ctx.Err())
+}
+
+// memoryNow has the precision of the timestamps the database sets.
+func memoryNow() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+func sortedSet[T cmp.Ordered](values []T) []T {
+	set := slices.Clone(values)
+	slices.Sort(set)
+
+	return slices.Compact(set)
+}
+
+func clone[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+
+	v := *p
+	return &v
+}
+
+// utcClone copies a time like the database returns it, in UTC.
+func utcClone(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	utc := t.UTC()
+	return &utc
+}
@@ -0,0 +1,512 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"todo/errs"
+	"todo/services/history"
+	"todo/services/workspace"
+	"todo/types"
+	"todo/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	contentTypeMergePatch = "application/merge-patch+json"
+	contentTypeJSONPatch  = "application/json-patch+json"
+)
+
+var (
+	errPatchFailed      = errs.New(http.StatusUnprocessableEntity, "patch_failed", "the patch cannot be applied to the task")
+	errInvalidPatch     = errs.Validation("invalid_patch", "the patched task is invalid")
+	errUnsupportedPatch = errs.New(http.StatusUnsupportedMediaType, "unsupported_media_type", "use "+contentTypeMergePatch+" or "+contentTypeJSONPatch)
+)
+
+// handlePatchTask applies a merge patch or a JSON Patch to the task's document, chosen by the
+// Content-Type. Only the fields that differ afterwards are written and checked, a null clears a field.
+func (h *Handler) handlePatchTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	str, ok := vars["task_id"]
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("missing task ID"))
+		return
+	}
+
+	taskID, err := strconv.Atoi(str)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid task ID"))
+		return
+	}
+	existingTask, err := h.store.GetTaskByID(r.Context(), taskID)
+	if errors.Is(err, types.ErrTaskNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !workspace.Contains(r.Context(), existingTask) {
+		utils.WriteError(w, http.StatusNotFound, types.ErrTaskNotFound)
+		return
+	}
+	if !checkIfMatch(w, r, existingTask) {
+		return
+	}
+
+	body, err := utils.ReadBody(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	current := taskDocument(existingTask)
+	var doc any
+	if err := remarshal(current, &doc); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case contentTypeJSONPatch:
+		var ops []PatchOperation
+		if err := utils.DecodeJSON(body, &ops); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		if doc, err = ApplyJSONPatch(doc, ops); err != nil {
+			utils.WriteError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+	case contentTypeMergePatch, "application/json":
+		var patch any
+		if err := utils.DecodeJSON(body, &patch); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		doc = MergePatch(doc, patch)
+	default:
+		utils.WriteError(w, http.StatusUnsupportedMediaType, errUnsupportedPatch)
+		return
+	}
+
+	var task types.TaskDocument
+	b, err := json.Marshal(doc)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := utils.DecodeJSON(b, &task); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, errInvalidPatch.Errorf("invalid patched task: %v", err))
+		return
+	}
+
+	// a due date that already passed is only rejected when the patch sets it
+	err = utils.Validate.Struct(task)
+	if sameTime(existingTask.DueDate, task.DueDate) {
+		err = utils.Validate.StructExcept(task, "DueDate")
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	customFields, ok := h.checkCustomFields(w, r, task.UserID, task.CustomFields)
+	if !ok {
+		return
+	}
+	task.CustomFields = customFields
+
+	changed, err := changedFields(current, task)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if len(changed) == 0 {
+		setETag(w, existingTask)
+		utils.WriteJson(w, http.StatusOK, existingTask)
+		return
+	}
+
+	if slices.Contains(changed, "user_id") && task.UserID != nil && !h.checkMembers(w, r, []int{*task.UserID}) {
+		return
+	}
+	if slices.Contains(changed, "assignee_ids") && !h.checkMembers(w, r, task.AssigneeIDs) {
+		return
+	}
+	if slices.Contains(changed, "user_id") || slices.Contains(changed, "status") {
+		column := types.BoardColumn{WorkspaceID: existingTask.WorkspaceID, UserID: task.UserID, Status: task.Status}
+		if !h.checkStatus(w, r, existingTask, column) || !h.checkWIPLimit(w, r, existingTask, column) {
+			return
+		}
+	}
+
+	patched, err := h.store.PatchTask(r.Context(), taskID, existingTask.Version, task, changed)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if patched == 0 {
+		writeVersionConflict(w, r)
+		return
+	}
+
+	updatedTask, err := h.store.GetTaskByID(r.Context(), taskID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.recordEvent(r, history.ActionUpdate, existingTask, updatedTask)
+
+	setETag(w, updatedTask)
+	utils.WriteJson(w, http.StatusOK, updatedTask)
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
+
+func taskDocument(task *types.Task) types.TaskDocument {
+	return types.TaskDocument{
+		UserID:      task.UserID,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		DueDate:     task.DueDate,
+
+		ChecklistAutoComplete: task.ChecklistAutoComplete,
+		CustomFields:          task.CustomFields,
+		AssigneeIDs:           task.AssigneeIDs,
+		Labels:                task.Labels,
+	}
+}
+
+// changedFields returns the sorted JSON keys whose values differ, empty and null count as the same.
+func changedFields(before, after types.TaskDocument) ([]string, error) {
+	var a, b map[string]json.RawMessage
+	if err := remarshal(before, &a); err != nil {
+		return nil, err
+	}
+	if err := remarshal(after, &b); err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for key, value := range b {
+		if !bytes.Equal(emptyAsNull(a[key]), emptyAsNull(value)) {
+			changed = append(changed, key)
+		}
+	}
+	slices.Sort(changed)
+
+	return changed, nil
+}
+
+func emptyAsNull(value json.RawMessage) json.RawMessage {
+	if string(value) == "[]" || string(value) == "{}" {
+		return json.RawMessage("null")
+	}
+
+	return value
+}
+
+// remarshal converts v through JSON, e.g. a struct into generic maps.
+func remarshal(v any, out any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, out)
+}
+
+// PatchOperation is one step of an RFC 6902 JSON Patch. Value is nil when the member
+// is absent and holds "null" for an explicit null.
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// MergePatch applies an RFC 7396 merge patch: objects are merged recursively,
+// null removes a member and any other value replaces it.
+func MergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = MergePatch(t[key], value)
+		}
+	}
+
+	return t
+}
+
+// ApplyJSONPatch applies the operations in order and stops at the first failing one.
+// doc is modified in place, callers discard it on error.
+func ApplyJSONPatch(doc any, ops []PatchOperation) (any, error) {
+	var err error
+	for i, op := range ops {
+		if doc, err = applyOperation(doc, op); err != nil {
+			return nil, errPatchFailed.Errorf("operation %d (%s %s): %v", i, op.Op, op.Path, err)
+		}
+	}
+
+	return doc, nil
+}
+
+func applyOperation(doc any, op PatchOperation) (any, error) {
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("missing value")
+		}
+		var value any
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, err
+		}
+		if op.Op == "add" {
+			return add(doc, op.Path, value)
+		}
+		if op.Op == "replace" {
+			if op.Path == "" {
+				return value, nil
+			}
+			var err error
+			if doc, err = remove(doc, op.Path); err != nil {
+				return nil, err
+			}
+			// removing and adding again keeps replace on arrays at the same index
+			return replace(doc, op.Path, value)
+		}
+		current, err := get(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("test failed")
+		}
+		return doc, nil
+	case "remove":
+		return remove(doc, op.Path)
+	case "move", "copy":
+		value, err := get(doc, op.From)
+		if err != nil {
+			return nil, fmt.Errorf("from: %v", err)
+		}
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, fmt.Errorf("cannot move a value into itself")
+			}
+			if doc, err = remove(doc, op.From); err != nil {
+				return nil, err
+			}
+		} else if value, err = deepCopy(value); err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, value)
+	}
+
+	return nil, fmt.Errorf("unknown operation")
+}
+
+func add(doc any, path string, value any) (any, error) {
+	return modify(doc, path, func(container any, key string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			c[key] = value
+			return c, nil
+		case []any:
+			i := len(c)
+			if key != "-" {
+				var err error
+				if i, err = arrayIndex(key, len(c)+1); err != nil {
+					return nil, err
+				}
+			}
+			return append(c[:i], append([]any{value}, c[i:]...)...), nil
+		}
+		return nil, fmt.Errorf("cannot add to a %T", container)
+	}, value)
+}
+
+func replace(doc any, path string, value any) (any, error) {
+	return modify(doc, path, func(container any, key string) (any, error) {
+		if c, ok := container.([]any); ok {
+			i, err := arrayIndex(key, len(c)+1)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], append([]any{value}, c[i:]...)...), nil
+		}
+		return add(container, "/"+escapeToken(key), value)
+	}, value)
+}
+
+func remove(doc any, path string) (any, error) {
+	if path == "" {
+		return nil, fmt.Errorf("cannot remove the whole document")
+	}
+
+	return modify(doc, path, func(container any, key string) (any, error) {
+		switch c := container.(type) {
+		case map[string]any:
+			if _, ok := c[key]; !ok {
+				return nil, fmt.Errorf("path not found")
+			}
+			delete(c, key)
+			return c, nil
+		case []any:
+			i, err := arrayIndex(key, len(c))
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], c[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("path not found")
+	}, nil)
+}
+
+func get(doc any, path string) (any, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+
+	node := doc
+	for _, token := range tokens {
+		switch n := node.(type) {
+		case map[string]any:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("path not found")
+			}
+			node = child
+		case []any:
+			i, err := arrayIndex(token, len(n))
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("path not found")
+		}
+	}
+
+	return node, nil
+}
+
+// modify walks to the parent of the pointer's target and lets fn rebuild it,
+// parents are reassigned on the way back so arrays can grow and shrink.
+// The root itself is replaced by rootValue.
+func modify(doc any, path string, fn func(container any, key string) (any, error), rootValue any) (any, error) {
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return rootValue, nil
+	}
+
+	return modifyTokens(doc, tokens, fn)
+}
+
+func modifyTokens(node any, tokens []string, fn func(container any, key string) (any, error)) (any, error) {
+	if len(tokens) == 1 {
+		return fn(node, tokens[0])
+	}
+
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("path not found")
+		}
+		updated, err := modifyTokens(child, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[tokens[0]] = updated
+		return n, nil
+	case []any:
+		i, err := arrayIndex(tokens[0], len(n))
+		if err != nil {
+			return nil, err
+		}
+		updated, err := modifyTokens(n[i], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = updated
+		return n, nil
+	}
+
+	return nil, fmt.Errorf("path not found")
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into its unescaped tokens.
+func parsePointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", path)
+	}
+
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func escapeToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// arrayIndex parses an array index below max, leading zeros are not allowed.
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i >= max {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+
+	return i, nil
+}
+
+func deepCopy(value any) (any, error) {
+	var copied any
+	err := remarshal(value, &copied)
+
+	return copied, err
+}
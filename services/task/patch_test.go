@@ -0,0 +1,120 @@
+package task
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"todo/types"
+)
+
+func decode(t *testing.T, s string) any {
+	t.Helper()
+
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	return v
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b","c":1}`, `{"a":null}`, `{"c":1}`},
+		{`{"a":{"b":"c","d":1}}`, `{"a":{"b":null,"e":2}}`, `{"a":{"d":1,"e":2}}`},
+		{`{"a":[1,2]}`, `{"a":[3]}`, `{"a":[3]}`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`["a"]`, `{"a":"b"}`, `{"a":"b"}`},
+	}
+
+	for _, tt := range tests {
+		got := MergePatch(decode(t, tt.target), decode(t, tt.patch))
+		if !reflect.DeepEqual(got, decode(t, tt.want)) {
+			t.Errorf("MergePatch(%s, %s) = %v, want %s", tt.target, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":1}`, `[{"op":"add","path":"/b","value":null}]`, `{"a":1,"b":null}`},
+		{`{"a":[1,3]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2,3]}`},
+		{`{"a":[1]}`, `[{"op":"add","path":"/a/-","value":2}]`, `{"a":[1,2]}`},
+		{`{"a":1,"b":2}`, `[{"op":"remove","path":"/a"}]`, `{"b":2}`},
+		{`{"a":[1,2,3]}`, `[{"op":"remove","path":"/a/1"}]`, `{"a":[1,3]}`},
+		{`{"a":[1,2,3]}`, `[{"op":"replace","path":"/a/1","value":5}]`, `{"a":[1,5,3]}`},
+		{`[1,2]`, `[{"op":"replace","path":"/0","value":5}]`, `[5,2]`},
+		{`{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+		{`{"a":{"b":1}}`, `[{"op":"move","from":"/a/b","path":"/c"}]`, `{"a":{},"c":1}`},
+		{`{"a":[1]}`, `[{"op":"copy","from":"/a","path":"/b"},{"op":"add","path":"/b/-","value":2}]`, `{"a":[1],"b":[1,2]}`},
+		{`{"a/b":1,"m~n":2}`, `[{"op":"remove","path":"/a~1b"},{"op":"replace","path":"/m~0n","value":3}]`, `{"m~n":3}`},
+		{`{"a":null}`, `[{"op":"test","path":"/a","value":null},{"op":"add","path":"/b","value":1}]`, `{"a":null,"b":1}`},
+	}
+
+	for _, tt := range tests {
+		var ops []PatchOperation
+		if err := json.Unmarshal([]byte(tt.patch), &ops); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := ApplyJSONPatch(decode(t, tt.doc), ops)
+		if err != nil {
+			t.Errorf("ApplyJSONPatch(%s, %s): %v", tt.doc, tt.patch, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, decode(t, tt.want)) {
+			t.Errorf("ApplyJSONPatch(%s, %s) = %v, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestApplyJSONPatchErrors(t *testing.T) {
+	tests := []struct {
+		doc, patch string
+	}{
+		{`{"a":1}`, `[{"op":"test","path":"/a","value":2}]`},
+		{`{"a":1}`, `[{"op":"remove","path":"/b"}]`},
+		{`{"a":1}`, `[{"op":"replace","path":"/b","value":1}]`},
+		{`{"a":1}`, `[{"op":"add","path":"/b"}]`},
+		{`{"a":1}`, `[{"op":"add","path":"/b/c","value":1}]`},
+		{`{"a":[1]}`, `[{"op":"add","path":"/a/2","value":1}]`},
+		{`{"a":[1]}`, `[{"op":"remove","path":"/a/01"}]`},
+		{`{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/c"}]`},
+		{`{"a":1}`, `[{"op":"add","path":"a","value":1}]`},
+		{`{"a":1}`, `[{"op":"merge","path":"/a","value":1}]`},
+	}
+
+	for _, tt := range tests {
+		var ops []PatchOperation
+		if err := json.Unmarshal([]byte(tt.patch), &ops); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := ApplyJSONPatch(decode(t, tt.doc), ops); err == nil {
+			t.Errorf("ApplyJSONPatch(%s, %s) succeeded, want an error", tt.doc, tt.patch)
+		}
+	}
+}
+
+func TestChangedFields(t *testing.T) {
+	userID := 1
+	before := types.TaskDocument{UserID: &userID, Title: "a", Status: "pending", Priority: 1, Labels: []string{}}
+	after := before
+	after.UserID = nil
+	after.Title = "b"
+	after.Labels = nil
+
+	changed, err := changedFields(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(changed, []string{"title", "user_id"}) {
+		t.Errorf("changedFields() = %v", changed)
+	}
+}
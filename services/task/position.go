@@ -0,0 +1,84 @@
+package task
+
+import "strings"
+
+// Positions are fractional indexes written in base 62: a key is read as the
+// digits after the point of a number in (0, 1), so there is always room for
+// another key between two neighbors and moving a card only rewrites its own
+// row. Keys never end with the zero digit, otherwise "1" and "10" would name
+// the same number and nothing would fit between them.
+const positionDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// rebalanceThreshold is the key length past which a column gets evenly spaced keys again.
+const rebalanceThreshold = 32
+
+// positionBetween returns a key sorting strictly between before and after.
+// An empty before means the start of the column, an empty after its end.
+func positionBetween(before, after string) string {
+	if after != "" {
+		// skip the common prefix, a missing digit in before counts as zero
+		n := 0
+		for n < len(after) && digitAt(before, n) == after[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(before) {
+				rest = before[n:]
+			}
+			return after[:n] + positionBetween(rest, after[n:])
+		}
+	}
+
+	low := 0
+	if before != "" {
+		low = strings.IndexByte(positionDigits, before[0])
+	}
+	high := len(positionDigits)
+	if after != "" {
+		high = strings.IndexByte(positionDigits, after[0])
+	}
+
+	if high-low > 1 {
+		return string(positionDigits[(low+high+1)/2])
+	}
+
+	// the first digits are consecutive
+	if after != "" && len(after) > 1 {
+		return after[:1]
+	}
+
+	rest := ""
+	if before != "" {
+		rest = before[1:]
+	}
+	return string(positionDigits[low]) + positionBetween(rest, "")
+}
+
+// evenPositions returns n increasing keys of equal length, used when rebalancing a column.
+func evenPositions(n int) []string {
+	width := 1
+	for capacity := len(positionDigits); capacity <= n; capacity *= len(positionDigits) {
+		width++
+	}
+
+	keys := make([]string, n)
+	for i := range keys {
+		digits := make([]byte, width)
+		for j, v := width-1, i+1; j >= 0; j, v = j-1, v/len(positionDigits) {
+			digits[j] = positionDigits[v%len(positionDigits)]
+		}
+		// a trailing midpoint digit keeps keys free of trailing zeros and leaves room on both sides
+		keys[i] = string(digits) + "V"
+	}
+
+	return keys
+}
+
+func digitAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+
+	return positionDigits[0]
+}
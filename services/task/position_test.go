@@ -0,0 +1,67 @@
+package task
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPositionBetween(t *testing.T) {
+	cases := []struct{ before, after string }{
+		{"", ""},
+		{"", "1"},
+		{"V", ""},
+		{"z", ""},
+		{"0V", "1"},
+		{"00000010V", "00000011V"},
+		{"a", "a0V"},
+		{"Vz", "W"},
+	}
+
+	for _, c := range cases {
+		got := positionBetween(c.before, c.after)
+		if got <= c.before || (c.after != "" && got >= c.after) {
+			t.Errorf("positionBetween(%q, %q) = %q, not strictly between", c.before, c.after, got)
+		}
+		if strings.HasSuffix(got, "0") {
+			t.Errorf("positionBetween(%q, %q) = %q, ends with zero digit", c.before, c.after, got)
+		}
+	}
+}
+
+func TestPositionBetweenRepeatedInserts(t *testing.T) {
+	keys := []string{positionBetween("", "")}
+
+	// keep inserting at the front, the back and right after the first key
+	for i := 0; i < 200; i++ {
+		keys = append([]string{positionBetween("", keys[0])}, keys...)
+		keys = append(keys, positionBetween(keys[len(keys)-1], ""))
+		mid := positionBetween(keys[0], keys[1])
+		keys = append([]string{keys[0], mid}, keys[1:]...)
+	}
+
+	if !sort.StringsAreSorted(keys) {
+		t.Fatal("expected keys to stay sorted")
+	}
+	for i := 1; i < len(keys); i++ {
+		if keys[i] == keys[i-1] {
+			t.Fatalf("duplicate key %q", keys[i])
+		}
+	}
+}
+
+func TestEvenPositions(t *testing.T) {
+	keys := evenPositions(100)
+
+	if len(keys) != 100 {
+		t.Fatalf("expected 100 keys, got %d", len(keys))
+	}
+	if !sort.StringsAreSorted(keys) {
+		t.Error("expected keys to be sorted")
+	}
+	for _, k := range keys {
+		if len(k) != len(keys[0]) {
+			t.Errorf("expected keys of equal length, got %q and %q", keys[0], k)
+		}
+	}
+}
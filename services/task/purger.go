@@ -0,0 +1,72 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"todo/types"
+)
+
+// PurgeHook runs right before a task is removed for good so resources
+// living outside the database can be released. An error keeps the task.
+type PurgeHook func(ctx context.Context, taskID int) error
+
+// Purger periodically removes tasks that have been in the trash for longer than the retention period.
+type Purger struct {
+	store     types.TaskStore
+	retention time.Duration
+	interval  time.Duration
+	hooks     []PurgeHook
+}
+
+func NewPurger(store types.TaskStore, retention, interval time.Duration, hooks ...PurgeHook) *Purger {
+	return &Purger{store: store, retention: retention, interval: interval, hooks: hooks}
+}
+
+// Run blocks until ctx is cancelled.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Purger) purge(ctx context.Context) {
+	ids, err := p.store.GetPurgeableTaskIDs(ctx, p.retention)
+	if err != nil {
+		log.Printf("failed to list trashed tasks: %v", err)
+		return
+	}
+
+	purged := 0
+	for _, id := range ids {
+		if _, err := purgeTask(ctx, p.store, p.hooks, id); err != nil {
+			log.Printf("failed to purge task %d: %v", id, err)
+			continue
+		}
+		purged++
+	}
+
+	if purged > 0 {
+		log.Printf("purged %d trashed tasks", purged)
+	}
+}
+
+func purgeTask(ctx context.Context, store types.TaskStore, hooks []PurgeHook, taskID int) (int64, error) {
+	for _, hook := range hooks {
+		if err := hook(ctx, taskID); err != nil {
+			return 0, fmt.Errorf("purge hook: %v", err)
+		}
+	}
+
+	return store.PurgeTask(ctx, taskID)
+}
@@ -0,0 +1,48 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPurger(t *testing.T) {
+	s := newTestServer(t)
+	old := s.createTask("old", "")
+	recent := s.createTask("recent", "")
+	live := s.createTask("live", "")
+	kept := s.createTask("kept by a hook", "")
+
+	ctx := context.Background()
+	for _, id := range []int{old.ID, recent.ID, kept.ID} {
+		if _, err := s.store.DeleteTask(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := s.db.Exec("UPDATE tasks SET deleted_at = ? WHERE id IN (?, ?)", time.Now().UTC().Add(-48*time.Hour), old.ID, kept.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hook := func(ctx context.Context, taskID int) error {
+		if taskID == kept.ID {
+			return errors.New("blob store unavailable")
+		}
+		return nil
+	}
+	NewPurger(s.store, 24*time.Hour, time.Hour, hook).purge(ctx)
+
+	for _, c := range []struct {
+		id     int
+		exists bool
+	}{{old.ID, false}, {recent.ID, true}, {live.ID, true}, {kept.ID, true}} {
+		workspaceID, err := s.store.GetTaskWorkspaceID(ctx, c.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exists := workspaceID != 0; exists != c.exists {
+			t.Errorf("task %d exists = %v, want %v", c.id, exists, c.exists)
+		}
+	}
+}
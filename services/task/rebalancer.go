@@ -0,0 +1,49 @@
+package task
+
+import (
+	"context"
+	"log"
+	"time"
+	"todo/types"
+)
+
+// Rebalancer periodically gives board columns short, evenly spaced position keys
+// again once repeated moves made them long.
+type Rebalancer struct {
+	store    types.TaskStore
+	interval time.Duration
+}
+
+func NewRebalancer(store types.TaskStore, interval time.Duration) *Rebalancer {
+	return &Rebalancer{store: store, interval: interval}
+}
+
+// Run blocks until ctx is cancelled.
+func (b *Rebalancer) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		b.rebalance(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *Rebalancer) rebalance(ctx context.Context) {
+	columns, err := b.store.GetUnbalancedColumns(ctx, rebalanceThreshold)
+	if err != nil {
+		log.Printf("failed to find columns to rebalance: %v", err)
+		return
+	}
+
+	for _, column := range columns {
+		if err := b.store.RebalanceColumn(ctx, column); err != nil {
+			log.Printf("failed to rebalance column %s: %v", column.Status, err)
+		}
+	}
+}
@@ -2,45 +2,121 @@ package task
 
 import (
 	// "fmt"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"todo/errs"
 	"todo/services/auth"
+	"todo/services/board"
+	"todo/services/customfield"
+	"todo/services/history"
+	"todo/services/idempotency"
+	"todo/services/notification"
+	"todo/services/workflow"
+	"todo/services/workspace"
 	"todo/types"
 	"todo/utils"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 )
 
+// customFieldPrefix marks custom field keys in query parameters, e.g. ?cf.points=3&sort_by=cf.points
+const customFieldPrefix = "cf."
+
+var (
+	errNotInTrash      = errs.NotFound("task_not_in_trash", "task not found in trash")
+	errInvalidNeighbor = errs.Validation("invalid_neighbor", "invalid neighbor task")
+	errInvalidFilter   = errs.Validation("invalid_filter", "invalid task filter")
+)
+
 type Handler struct {
-	store     types.TaskStore
-	userStore types.UserStore
+	store          types.TaskStore
+	userStore      types.UserStore
+	eventStore     types.TaskEventStore
+	wipStore       types.WIPLimitStore
+	workflowStore  types.WorkflowStore
+	fieldStore     types.CustomFieldStore
+	notifier       *notification.Notifier
+	workspaceStore types.WorkspaceStore
+	keyStore       types.IdempotencyStore
+	purgeHooks     []PurgeHook
+}
+
+func NewHandler(store types.TaskStore, userStore types.UserStore, eventStore types.TaskEventStore, wipStore types.WIPLimitStore, workflowStore types.WorkflowStore, fieldStore types.CustomFieldStore, notifier *notification.Notifier, workspaceStore types.WorkspaceStore, keyStore types.IdempotencyStore) *Handler {
+	return &Handler{store: store, userStore: userStore, eventStore: eventStore, wipStore: wipStore, workflowStore: workflowStore, fieldStore: fieldStore, notifier: notifier, workspaceStore: workspaceStore, keyStore: keyStore}
 }
 
-func NewHandler(store types.TaskStore, userStore types.UserStore) *Handler {
-	return &Handler{store: store, userStore: userStore}
+// OnPurge registers a hook that runs before a task is permanently deleted.
+func (h *Handler) OnPurge(hook PurgeHook) {
+	h.purgeHooks = append(h.purgeHooks, hook)
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
-	router.HandleFunc("/tasks", auth.WithJWTAuth(h.handleGetTasks, h.userStore)).Methods(http.MethodGet)
-	router.HandleFunc("/tasks", auth.WithJWTAuth(h.handleCreateTask, h.userStore)).Methods(http.MethodPost)
-	router.HandleFunc("/tasks/{task_id}", auth.WithJWTAuth(h.handleUpdateTask, h.userStore)).Methods(http.MethodPut)
-	router.HandleFunc("/tasks/{task_id}", auth.WithJWTAuth(h.handleDeleteTask, h.userStore)).Methods(http.MethodDelete)
+	router.HandleFunc("/tasks", auth.WithJWTAuth(workspace.WithWorkspace(h.handleGetTasks, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/tasks", auth.WithJWTAuth(workspace.WithWorkspace(idempotency.WithIdempotencyKey(h.handleCreateTask, h.keyStore), h.workspaceStore), h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/bulk", utils.WithJSONOptions(auth.WithJWTAuth(workspace.WithWorkspace(idempotency.WithIdempotencyKey(h.handleBulk, h.keyStore), h.workspaceStore), h.userStore), utils.JSONOptions{MaxBytes: maxBulkBodyBytes})).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/trash", auth.WithJWTAuth(workspace.WithWorkspace(h.handleGetTrash, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/tasks/{task_id}", auth.WithJWTAuth(workspace.WithWorkspace(h.handleGetTask, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+	// a PUT may send back the task as it was read, including read-only fields like id and version
+	router.HandleFunc("/tasks/{task_id}", utils.WithJSONOptions(auth.WithJWTAuth(workspace.WithWorkspace(h.handleUpdateTask, h.workspaceStore), h.userStore), utils.JSONOptions{AllowUnknownFields: true})).Methods(http.MethodPut)
+	router.HandleFunc("/tasks/{task_id}", auth.WithJWTAuth(workspace.WithWorkspace(h.handlePatchTask, h.workspaceStore), h.userStore)).Methods(http.MethodPatch)
+	router.HandleFunc("/tasks/{task_id}", auth.WithJWTAuth(workspace.WithWorkspace(h.handleDeleteTask, h.workspaceStore), h.userStore)).Methods(http.MethodDelete)
+	router.HandleFunc("/tasks/{task_id}/move", auth.WithJWTAuth(workspace.WithWorkspace(h.handleMoveTask, h.workspaceStore), h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/{task_id}/restore", auth.WithJWTAuth(workspace.WithWorkspace(h.handleRestoreTask, h.workspaceStore), h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/{task_id}/permanent", auth.WithJWTAuth(workspace.WithWorkspace(h.handlePurgeTask, h.workspaceStore), h.userStore)).Methods(http.MethodDelete)
 }
 
+// handleGetTasks lists tasks, ?assignee= and ?watching= take a user ID or "me", ?label= one label. Custom fields
+// of the requesting user can be filtered on with ?cf.<key>=<value> and sorted by with sort_by=cf.<key>.
 func (h *Handler) handleGetTasks(w http.ResponseWriter, r *http.Request) {
-	allowedSortFields := []string{"user_id", "status", "priority", "due_date"}
+	fields, err := h.fieldStore.GetCustomFields(r.Context(), auth.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	allowedSortFields := []string{"user_id", "status", "priority", "due_date", "position"}
+	for _, f := range fields {
+		allowedSortFields = append(allowedSortFields, customFieldPrefix+f.Key)
+	}
 	pagination, err := utils.ParsePaginationParams(r, allowedSortFields)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
+	filter, err := parseTaskFilter(r, auth.GetUserIDFromContext(r.Context()), fields)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	filter.WorkspaceID = workspace.IDFromContext(r.Context())
+
 	// get paginated data from store
-	tasks, total, err := h.store.GetPaginatedTasks(pagination)
+	tasks, total, err := h.store.GetPaginatedTasks(r.Context(), filter, pagination)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get tasks: %w", err))
+		return
+	}
+
+	utils.WritePaginatedResponse(w, pagination.Page, pagination.Limit, total, tasks)
+}
+
+func (h *Handler) handleGetTrash(w http.ResponseWriter, r *http.Request) {
+	allowedSortFields := []string{"user_id", "status", "priority", "due_date", "deleted_at"}
+	pagination, err := utils.ParsePaginationParams(r, allowedSortFields)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	filter := types.TaskFilter{WorkspaceID: workspace.IDFromContext(r.Context())}
+	tasks, total, err := h.store.GetPaginatedTrashedTasks(r.Context(), filter, pagination)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get tasks: %v", err))
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get trashed tasks: %w", err))
 		return
 	}
 
@@ -55,18 +131,81 @@ func (h *Handler) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := utils.Validate.Struct(task); err != nil {
-		errors := err.(validator.ValidationErrors)
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	wf, err := h.workflowStore.GetWorkflow(r.Context(), task.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if task.Status == "" {
+		task.Status = workflow.InitialStatus(wf)
+	}
+	if err := workflow.ValidateStatusChange(wf, "", task.Status); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	customFields, ok := h.checkCustomFields(w, r, task.UserID, task.CustomFields)
+	if !ok {
+		return
+	}
+	task.CustomFields = customFields
+
+	if task.UserID != nil && !h.checkMembers(w, r, []int{*task.UserID}) {
+		return
+	}
+	if !h.checkMembers(w, r, task.AssigneeIDs) {
+		return
+	}
+	task.CreatorID = auth.GetUserIDFromContext(r.Context())
+	task.WorkspaceID = workspace.IDFromContext(r.Context())
+
+	createdTask, err := h.store.CreateTask(r.Context(), task)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.recordEvent(r, history.ActionCreate, nil, createdTask)
+
+	w.Header().Set("Location", fmt.Sprintf("%s/%d", strings.TrimSuffix(r.URL.Path, "/"), createdTask.ID))
+	setETag(w, createdTask)
+	utils.WriteJson(w, http.StatusCreated, createdTask)
+}
+
+func (h *Handler) handleGetTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	str, ok := vars["task_id"]
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("missing task ID"))
+		return
+	}
+
+	taskID, err := strconv.Atoi(str)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid task ID"))
 		return
 	}
 
-	err := h.store.CreateTask(task)
+	task, err := h.store.GetTaskByID(r.Context(), taskID)
+	if errors.Is(err, types.ErrTaskNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+	if !workspace.Contains(r.Context(), task) {
+		utils.WriteError(w, http.StatusNotFound, types.ErrTaskNotFound)
+		return
+	}
 
-	utils.WriteJson(w, http.StatusCreated, task)
+	setETag(w, task)
+	utils.WriteJson(w, http.StatusOK, task)
 }
 
 func (h *Handler) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
@@ -75,39 +214,52 @@ func (h *Handler) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	str, ok := vars["task_id"]
 	if !ok {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("missing task ID"))
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("missing task ID"))
 		return
 	}
 
 	taskID, err := strconv.Atoi(str)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid task ID"))
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid task ID"))
 		return
 	}
-	existingTask, err := h.store.GetTaskByID(taskID)
-	if err != nil {
+	existingTask, err := h.store.GetTaskByID(r.Context(), taskID)
+	if errors.Is(err, types.ErrTaskNotFound) {
 		utils.WriteError(w, http.StatusNotFound, err)
 		return
 	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !workspace.Contains(r.Context(), existingTask) {
+		utils.WriteError(w, http.StatusNotFound, types.ErrTaskNotFound)
+		return
+	}
+	if !checkIfMatch(w, r, existingTask) {
+		return
+	}
 
 	if err := utils.ParseJSON(r, &task); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	if err := utils.Validate.Struct(task); err != nil {
-		errors := err.(validator.ValidationErrors)
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+	// a due date that already passed is only rejected when the update changes it
+	err = utils.Validate.Struct(task)
+	if sameTime(existingTask.DueDate, task.DueDate) {
+		err = utils.Validate.StructExcept(task, "DueDate")
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
 		return
 	}
 
-	if task.UserID != nil {
-		_, err := h.userStore.GetUserByID(*task.UserID)
-		if err != nil {
-			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("user not found"))
-			return
-		}
-
+	if task.UserID != nil && !h.checkMembers(w, r, []int{*task.UserID}) {
+		return
+	}
+	if task.AssigneeIDs != nil && !h.checkMembers(w, r, *task.AssigneeIDs) {
+		return
 	}
 
 	if task.Title == nil {
@@ -125,40 +277,449 @@ func (h *Handler) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 	if task.DueDate == nil {
 		task.DueDate = existingTask.DueDate
 	}
+	if task.ChecklistAutoComplete == nil {
+		task.ChecklistAutoComplete = &existingTask.ChecklistAutoComplete
+	}
+	if task.AssigneeIDs == nil {
+		task.AssigneeIDs = &existingTask.AssigneeIDs
+	}
+	if task.Labels == nil {
+		task.Labels = &existingTask.Labels
+	}
+
+	customFields, ok := h.checkCustomFields(w, r, task.UserID, customfield.Merge(existingTask.CustomFields, task.CustomFields))
+	if !ok {
+		return
+	}
+	task.CustomFields = customFields
+
+	column := types.BoardColumn{WorkspaceID: existingTask.WorkspaceID, UserID: task.UserID, Status: *task.Status}
+	if !h.checkStatus(w, r, existingTask, column) || !h.checkWIPLimit(w, r, existingTask, column) {
+		return
+	}
 
-	err = h.store.UpdateTask(taskID, task)
+	updated, err := h.store.UpdateTask(r.Context(), taskID, existingTask.Version, task)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+	if updated == 0 {
+		writeVersionConflict(w, r)
+		return
+	}
+
+	updatedTask, err := h.store.GetTaskByID(r.Context(), taskID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.recordEvent(r, history.ActionUpdate, existingTask, updatedTask)
 
-	updatedTask, _ := h.store.GetTaskByID(taskID)
+	setETag(w, updatedTask)
 	utils.WriteJson(w, http.StatusOK, updatedTask)
 }
 
+// handleMoveTask drops the task into a status column between two neighbors,
+// a missing neighbor stands for the top or the bottom of the column.
+func (h *Handler) handleMoveTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	str, ok := vars["task_id"]
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("missing task ID"))
+		return
+	}
+
+	taskID, err := strconv.Atoi(str)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid task ID"))
+		return
+	}
+
+	existingTask, err := h.store.GetTaskByID(r.Context(), taskID)
+	if errors.Is(err, types.ErrTaskNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !workspace.Contains(r.Context(), existingTask) {
+		utils.WriteError(w, http.StatusNotFound, types.ErrTaskNotFound)
+		return
+	}
+	if !checkIfMatch(w, r, existingTask) {
+		return
+	}
+
+	var payload types.MoveTaskPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	column := types.BoardColumn{WorkspaceID: existingTask.WorkspaceID, UserID: existingTask.UserID, Status: payload.Status}
+
+	before, err := h.neighborPosition(r, taskID, payload.BeforeID, column)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	after, err := h.neighborPosition(r, taskID, payload.AfterID, column)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if payload.BeforeID == nil && payload.AfterID == nil {
+		before, err = h.store.GetLastPosition(r.Context(), column)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if after != "" && before >= after {
+		utils.WriteError(w, http.StatusBadRequest, errInvalidNeighbor.Errorf("before_id must be above after_id"))
+		return
+	}
+
+	if !h.checkStatus(w, r, existingTask, column) || !h.checkWIPLimit(w, r, existingTask, column) {
+		return
+	}
+
+	moved, err := h.store.MoveTask(r.Context(), taskID, existingTask.Version, payload.Status, positionBetween(before, after))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if moved == 0 {
+		writeVersionConflict(w, r)
+		return
+	}
+
+	movedTask, err := h.store.GetTaskByID(r.Context(), taskID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.recordEvent(r, history.ActionUpdate, existingTask, movedTask)
+
+	setETag(w, movedTask)
+	utils.WriteJson(w, http.StatusOK, movedTask)
+}
+
+// checkCustomFields validates the values against the field definitions of the task owner and
+// returns them normalized, tasks without an owner cannot carry custom fields.
+func (h *Handler) checkCustomFields(w http.ResponseWriter, r *http.Request, ownerID *int, values map[string]any) (map[string]any, bool) {
+	var fields []types.CustomField
+	if ownerID != nil {
+		var err error
+		if fields, err = h.fieldStore.GetCustomFields(r.Context(), *ownerID); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return nil, false
+		}
+	}
+
+	normalized, err := customfield.Validate(fields, values)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	return normalized, true
+}
+
+// checkStatus validates moving the task into column against the workflow of
+// the column's owner. Transition rules only apply while the owner stays the same.
+func (h *Handler) checkStatus(w http.ResponseWriter, r *http.Request, task *types.Task, column types.BoardColumn) bool {
+	wf, err := h.workflowStore.GetWorkflow(r.Context(), column.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return false
+	}
+
+	from := ""
+	if sameUser(task.UserID, column.UserID) {
+		from = task.Status
+	}
+
+	if err := workflow.ValidateStatusChange(wf, from, column.Status); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return false
+	}
+
+	return true
+}
+
+// checkWIPLimit enforces the target column's work-in-progress limit. A
+// rejection is written as 409, a warning travels in the X-WIP-Warning header.
+func (h *Handler) checkWIPLimit(w http.ResponseWriter, r *http.Request, task *types.Task, column types.BoardColumn) bool {
+	warning, err := board.CheckWIPLimit(r.Context(), h.wipStore, h.store, task, column)
+	if errors.Is(err, board.ErrWIPLimitReached) {
+		utils.WriteError(w, http.StatusConflict, err)
+		return false
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return false
+	}
+
+	if warning != "" {
+		w.Header().Set("X-WIP-Warning", warning)
+	}
+
+	return true
+}
+
+// neighborPosition returns the position of a neighbor that must sit in the target column, "" when there is none.
+func (h *Handler) neighborPosition(r *http.Request, taskID int, neighborID *int, column types.BoardColumn) (string, error) {
+	if neighborID == nil {
+		return "", nil
+	}
+	if *neighborID == taskID {
+		return "", errInvalidNeighbor.Errorf("a task cannot be its own neighbor")
+	}
+
+	neighbor, err := h.store.GetTaskByID(r.Context(), *neighborID)
+	if err != nil && !errors.Is(err, types.ErrTaskNotFound) {
+		return "", err
+	}
+	if err != nil || neighbor.WorkspaceID != column.WorkspaceID {
+		return "", errInvalidNeighbor.Errorf("neighbor task %d not found", *neighborID)
+	}
+
+	if !sameUser(neighbor.UserID, column.UserID) || neighbor.Status != column.Status {
+		return "", errInvalidNeighbor.Errorf("neighbor task %d is not in the target column", *neighborID)
+	}
+
+	return neighbor.Position, nil
+}
+
 func (h *Handler) handleDeleteTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	str, ok := vars["task_id"]
 	if !ok {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("missing task ID"))
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("missing task ID"))
+		return
+	}
+
+	taskID, err := strconv.Atoi(str)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid task ID"))
+		return
+	}
+
+	existingTask, err := h.store.GetTaskByID(r.Context(), taskID)
+	if errors.Is(err, types.ErrTaskNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !workspace.Contains(r.Context(), existingTask) {
+		utils.WriteError(w, http.StatusNotFound, types.ErrTaskNotFound)
+		return
+	}
+
+	rowsAffected, err := h.store.DeleteTask(r.Context(), taskID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete task: %w", err))
+		return
+	}
+	if rowsAffected == 0 {
+		utils.WriteError(w, http.StatusNotFound, types.ErrTaskNotFound)
+		return
+	}
+
+	h.recordEvent(r, history.ActionDelete, existingTask, existingTask)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleRestoreTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	str, ok := vars["task_id"]
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("missing task ID"))
+		return
+	}
+
+	taskID, err := strconv.Atoi(str)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid task ID"))
+		return
+	}
+
+	if !h.checkWorkspace(w, r, taskID) {
+		return
+	}
+
+	rowsAffected, err := h.store.RestoreTask(r.Context(), taskID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to restore task: %w", err))
+		return
+	}
+	if rowsAffected == 0 {
+		utils.WriteError(w, http.StatusNotFound, errNotInTrash)
+		return
+	}
+
+	restoredTask, err := h.store.GetTaskByID(r.Context(), taskID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.recordEvent(r, history.ActionRestore, restoredTask, restoredTask)
+
+	utils.WriteJson(w, http.StatusOK, restoredTask)
+}
+
+func (h *Handler) handlePurgeTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	str, ok := vars["task_id"]
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("missing task ID"))
 		return
 	}
 
 	taskID, err := strconv.Atoi(str)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid task ID"))
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid task ID"))
+		return
+	}
+
+	if !h.checkWorkspace(w, r, taskID) {
 		return
 	}
 
-	rowsAffected, err := h.store.DeleteTask(taskID)
+	rowsAffected, err := purgeTask(r.Context(), h.store, h.purgeHooks, taskID)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete task: %v", err))
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete task: %w", err))
 		return
 	}
 	if rowsAffected == 0 {
-		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("task not found"))
+		utils.WriteError(w, http.StatusNotFound, types.ErrTaskNotFound)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// recordEvent appends to the task's history and notifies the watchers. The change
+// itself already happened, so a failure here is logged rather than returned.
+func (h *Handler) recordEvent(r *http.Request, action string, before, after *types.Task) {
+	if after == nil {
+		return
+	}
+
+	event := history.NewTaskEvent(action, auth.GetUserIDFromContext(r.Context()), before, after)
+	if err := h.eventStore.CreateTaskEvent(r.Context(), event); err != nil {
+		log.Printf("failed to record task event: %v", err)
+	}
+	h.notifier.NotifyTaskEvent(event)
+}
+
+// checkMembers writes a 404 naming the first user who is not a member of the selected workspace.
+func (h *Handler) checkMembers(w http.ResponseWriter, r *http.Request, userIDs []int) bool {
+	workspaceID := workspace.IDFromContext(r.Context())
+	for _, id := range userIDs {
+		member, err := h.workspaceStore.GetMember(r.Context(), workspaceID, id)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return false
+		}
+		if member == nil {
+			utils.WriteError(w, http.StatusNotFound, workspace.ErrNotMember.Errorf("user %d not found in this workspace", id))
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkWorkspace writes a 404 unless the task, trashed or not, belongs to the selected workspace.
+func (h *Handler) checkWorkspace(w http.ResponseWriter, r *http.Request, taskID int) bool {
+	workspaceID, err := h.store.GetTaskWorkspaceID(r.Context(), taskID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	if workspaceID == 0 || workspaceID != workspace.IDFromContext(r.Context()) {
+		utils.WriteError(w, http.StatusNotFound, types.ErrTaskNotFound)
+		return false
+	}
+
+	return true
+}
+
+// parseTaskFilter reads the assignee, watching, label and cf.<key> query parameters,
+// keys have to be custom fields of the requesting user.
+func parseTaskFilter(r *http.Request, userID int, fields []types.CustomField) (types.TaskFilter, error) {
+	var filter types.TaskFilter
+	var err error
+	if filter.AssigneeID, err = parseUserParam(r, "assignee", userID); err != nil {
+		return filter, err
+	}
+	if filter.WatcherID, err = parseUserParam(r, "watching", userID); err != nil {
+		return filter, err
+	}
+	if label := r.URL.Query().Get("label"); label != "" {
+		filter.Label = &label
+	}
+
+	for param, values := range r.URL.Query() {
+		key, ok := strings.CutPrefix(param, customFieldPrefix)
+		if !ok {
+			continue
+		}
+
+		var field *types.CustomField
+		for i := range fields {
+			if fields[i].Key == key {
+				field = &fields[i]
+			}
+		}
+		if field == nil {
+			return filter, customfield.ErrInvalidValue.Errorf("unknown custom field: %s", key)
+		}
+
+		value, err := customfield.ParseFilterValue(*field, values[0])
+		if err != nil {
+			return filter, err
+		}
+		filter.CustomFields = append(filter.CustomFields, types.CustomFieldFilter{Key: key, Type: field.Type, Value: value})
+	}
+
+	return filter, nil
+}
+
+// parseUserParam reads a user ID or "me" from the query, nil when the parameter is absent.
+func parseUserParam(r *http.Request, name string, userID int) (*int, error) {
+	switch str := r.URL.Query().Get(name); str {
+	case "":
+		return nil, nil
+	case "me":
+		return &userID, nil
+	default:
+		id, err := strconv.Atoi(str)
+		if err != nil {
+			return nil, errInvalidFilter.Errorf("invalid %s: use a user ID or me", name)
+		}
+		return &id, nil
+	}
+}
+
+func sameUser(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
@@ -0,0 +1,540 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"todo/configs"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/services/assignment"
+	"todo/services/auth"
+	"todo/services/board"
+	"todo/services/customfield"
+	"todo/services/history"
+	"todo/services/idempotency"
+	"todo/services/notification"
+	"todo/services/storetest"
+	"todo/services/user"
+	"todo/services/workflow"
+	"todo/services/workspace"
+	"todo/types"
+
+	"github.com/gorilla/mux"
+)
+
+// testServer runs the task routes over a SQLite database. The first seeded user owns the
+// first workspace, the second one is a member of it.
+type testServer struct {
+	t       *testing.T
+	db      *sql.DB
+	store   *Store
+	handler *Handler
+	router  *mux.Router
+	userIDs [2]int
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	database := storetest.SQLite(t)
+	userIDs, workspaceIDs := storetest.Seed(t, database)
+	for i, role := range []string{workspace.RoleOwner, workspace.RoleMember} {
+		_, err := database.Exec("INSERT INTO workspace_members (workspace_id, user_id, role) VALUES (?, ?, ?)",
+			workspaceIDs[0], userIDs[i], role)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := NewStore(database)
+	handler := NewHandler(store, user.NewStore(database), history.NewStore(database), board.NewStore(database),
+		workflow.NewStore(database), customfield.NewStore(database),
+		notification.NewNotifier(assignment.NewStore(database), nil), workspace.NewStore(database), idempotency.NewStore(database))
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	return &testServer{t: t, db: database, store: store, handler: handler, router: router, userIDs: userIDs}
+}
+
+// do sends the request as the first seeded user, body is encoded as JSON unless it is a string.
+func (s *testServer) do(method, path string, body any) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var r io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		r = bytes.NewBufferString(body)
+	default:
+		b, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		r = bytes.NewReader(b)
+	}
+
+	token, err := auth.CreateJWT([]byte(configs.Envs.JWTSecret), s.userIDs[0])
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(method, path, r)
+	req.Header.Set("Authorization", token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	return w
+}
+
+// createTask adds a task owned by the first seeded user through the API.
+func (s *testServer) createTask(title string, status string) types.Task {
+	s.t.Helper()
+
+	w := s.do(http.MethodPost, "/tasks", map[string]any{
+		"user_id":     s.userIDs[0],
+		"title":       title,
+		"description": "",
+		"status":      status,
+		"priority":    2,
+	})
+	if w.Code != http.StatusCreated {
+		s.t.Fatalf("creating %q: %d %s", title, w.Code, w.Body)
+	}
+
+	var task types.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+		s.t.Fatal(err)
+	}
+
+	return task
+}
+
+func (s *testServer) events(taskID int, action string) int {
+	s.t.Helper()
+
+	var n int
+	err := dialect.Wrap(s.db).QueryRow("SELECT COUNT(*) FROM task_events WHERE task_id = ? AND action = ?", taskID, action).Scan(&n)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+
+	return n
+}
+
+func taskPath(taskID int, suffix string) string {
+	return "/tasks/" + strconv.Itoa(taskID) + suffix
+}
+
+func TestTrashRoutes(t *testing.T) {
+	s := newTestServer(t)
+	task := s.createTask("trash me", "")
+	kept := s.createTask("keep me", "")
+
+	if w := s.do(http.MethodDelete, taskPath(task.ID, ""), nil); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: %d %s", w.Code, w.Body)
+	}
+	if w := s.do(http.MethodGet, taskPath(task.ID, ""), nil); w.Code != http.StatusNotFound {
+		t.Errorf("get of a trashed task: %d, want 404", w.Code)
+	}
+	if w := s.do(http.MethodDelete, taskPath(task.ID, ""), nil); w.Code != http.StatusNotFound {
+		t.Errorf("second delete: %d, want 404", w.Code)
+	}
+
+	w := s.do(http.MethodGet, "/tasks/trash", nil)
+	var trash struct {
+		Data []types.Task `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &trash); err != nil {
+		t.Fatal(err)
+	}
+	if len(trash.Data) != 1 || trash.Data[0].ID != task.ID {
+		t.Errorf("trash = %s, want only task %d", w.Body, task.ID)
+	}
+
+	w = s.do(http.MethodPost, taskPath(task.ID, "/restore"), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore: %d %s", w.Code, w.Body)
+	}
+	var restored types.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &restored); err != nil || restored.ID != task.ID || restored.DeletedAt != nil {
+		t.Errorf("restored task = %s", w.Body)
+	}
+	if w := s.do(http.MethodPost, taskPath(task.ID, "/restore"), nil); w.Code != http.StatusNotFound {
+		t.Errorf("restore of a live task: %d, want 404", w.Code)
+	}
+	if n := s.events(task.ID, history.ActionRestore); n != 1 {
+		t.Errorf("recorded %d restore events, want 1", n)
+	}
+
+	// a task can be purged without going through the trash
+	purged := []int{}
+	s.handler.OnPurge(func(ctx context.Context, taskID int) error {
+		purged = append(purged, taskID)
+		return nil
+	})
+	if w := s.do(http.MethodDelete, taskPath(task.ID, "/permanent"), nil); w.Code != http.StatusNoContent {
+		t.Fatalf("purge: %d %s", w.Code, w.Body)
+	}
+	if w := s.do(http.MethodDelete, taskPath(task.ID, "/permanent"), nil); w.Code != http.StatusNotFound {
+		t.Errorf("second purge: %d, want 404", w.Code)
+	}
+	if w := s.do(http.MethodPost, taskPath(task.ID, "/restore"), nil); w.Code != http.StatusNotFound {
+		t.Errorf("restore of a purged task: %d, want 404", w.Code)
+	}
+	if fmt.Sprint(purged) != fmt.Sprint([]int{task.ID}) {
+		t.Errorf("purge hooks ran for %v, want [%d]", purged, task.ID)
+	}
+
+	if w := s.do(http.MethodGet, taskPath(kept.ID, ""), nil); w.Code != http.StatusOK {
+		t.Errorf("get of the other task: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestTaskETagFollowsChecklist(t *testing.T) {
+	s := newTestServer(t)
+	task := s.createTask("tagged", "")
+
+	get := func() string {
+		t.Helper()
+
+		w := s.do(http.MethodGet, taskPath(task.ID, ""), nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("get: %d %s", w.Code, w.Body)
+		}
+		return w.Header().Get("ETag")
+	}
+
+	before := get()
+	storetest.AddTaskItems(s.db)(t, task.ID, 1, false)
+	if after := get(); after == before {
+		t.Errorf("ETag stayed %s after a comment and a checklist item were added", after)
+	}
+}
+
+func TestMoveRoutes(t *testing.T) {
+	s := newTestServer(t)
+	a := s.createTask("a", "")
+	b := s.createTask("b", "")
+	c := s.createTask("c", "")
+
+	move := func(task types.Task, body map[string]any) int {
+		t.Helper()
+
+		body["status"] = "pending"
+		w := s.do(http.MethodPost, taskPath(task.ID, "/move"), body)
+		return w.Code
+	}
+	order := func() []string {
+		t.Helper()
+
+		titles := []string{}
+		tasks := []*types.Task{}
+		for _, id := range []int{a.ID, b.ID, c.ID} {
+			task, err := s.store.GetTaskByID(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tasks = append(tasks, task)
+		}
+		slices.SortFunc(tasks, func(x, y *types.Task) int { return strings.Compare(x.Position, y.Position) })
+		for _, task := range tasks {
+			titles = append(titles, task.Title)
+		}
+		return titles
+	}
+
+	steps := []struct {
+		name string
+		task types.Task
+		body map[string]any
+		want []string
+	}{
+		{"between two neighbors", c, map[string]any{"before_id": a.ID, "after_id": b.ID}, []string{"a", "c", "b"}},
+		{"above the first task", b, map[string]any{"after_id": a.ID}, []string{"b", "a", "c"}},
+		{"below the last task", b, map[string]any{"before_id": c.ID}, []string{"a", "c", "b"}},
+		{"to the bottom", a, map[string]any{}, []string{"c", "b", "a"}},
+	}
+	for _, step := range steps {
+		if code := move(step.task, step.body); code != http.StatusOK {
+			t.Fatalf("move %s: %d", step.name, code)
+		}
+		if got := order(); !slices.Equal(got, step.want) {
+			t.Errorf("order after the move %s = %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	if code := move(a, map[string]any{"before_id": b.ID, "after_id": c.ID}); code != http.StatusBadRequest {
+		t.Errorf("move between neighbors in the wrong order: %d, want 400", code)
+	}
+	if code := move(a, map[string]any{"before_id": a.ID}); code != http.StatusBadRequest {
+		t.Errorf("move next to itself: %d, want 400", code)
+	}
+}
+
+func TestWIPLimitRoutes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+	a := s.createTask("a", "")
+	b := s.createTask("b", "")
+	limits := board.NewStore(s.db)
+	if err := limits.SetWIPLimit(ctx, types.WIPLimit{UserID: s.userIDs[0], Status: "in_progress", MaxTasks: 1, Mode: board.ModeReject}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := s.do(http.MethodPut, taskPath(a.ID, ""), map[string]any{"user_id": s.userIDs[0], "status": "in_progress"}); w.Code != http.StatusOK || w.Header().Get("X-WIP-Warning") != "" {
+		t.Fatalf("move into an empty column: %d %s", w.Code, w.Body)
+	}
+	if w := s.do(http.MethodPut, taskPath(a.ID, ""), map[string]any{"user_id": s.userIDs[0], "title": "a2"}); w.Code != http.StatusOK {
+		t.Errorf("update within the full column: %d %s", w.Code, w.Body)
+	}
+	if w := s.do(http.MethodPost, taskPath(a.ID, "/move"), map[string]any{"status": "in_progress"}); w.Code != http.StatusOK {
+		t.Errorf("move within the full column: %d %s", w.Code, w.Body)
+	}
+
+	for _, w := range []*httptest.ResponseRecorder{
+		s.do(http.MethodPut, taskPath(b.ID, ""), map[string]any{"user_id": s.userIDs[0], "status": "in_progress"}),
+		s.do(http.MethodPost, taskPath(b.ID, "/move"), map[string]any{"status": "in_progress"}),
+		s.do(http.MethodPatch, taskPath(b.ID, ""), `{"status":"in_progress"}`),
+	} {
+		if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "wip_limit_reached") {
+			t.Errorf("move into the full column: %d %s, want 409", w.Code, w.Body)
+		}
+	}
+
+	if err := limits.SetWIPLimit(ctx, types.WIPLimit{UserID: s.userIDs[0], Status: "in_progress", MaxTasks: 1, Mode: board.ModeWarn}); err != nil {
+		t.Fatal(err)
+	}
+	w := s.do(http.MethodPut, taskPath(b.ID, ""), map[string]any{"user_id": s.userIDs[0], "status": "in_progress"})
+	if w.Code != http.StatusOK || w.Header().Get("X-WIP-Warning") == "" {
+		t.Errorf("move into a column with a warn limit: %d, warning %q", w.Code, w.Header().Get("X-WIP-Warning"))
+	}
+}
+
+// racingStore changes a task right before the bulk transaction starts.
+type racingStore struct {
+	types.TaskStore
+	race func()
+}
+
+func (s racingStore) BulkUpdateTasks(ctx context.Context, changes []types.BulkTaskChange, allOrNothing bool) ([]error, error) {
+	s.race()
+	return s.TaskStore.BulkUpdateTasks(ctx, changes, allOrNothing)
+}
+
+// reloadFailingStore fails to look up a task once the bulk transaction has committed.
+type reloadFailingStore struct {
+	types.TaskStore
+	taskID    int
+	committed bool
+}
+
+func (s *reloadFailingStore) BulkUpdateTasks(ctx context.Context, changes []types.BulkTaskChange, allOrNothing bool) ([]error, error) {
+	s.committed = true
+	return s.TaskStore.BulkUpdateTasks(ctx, changes, allOrNothing)
+}
+
+func (s *reloadFailingStore) GetTaskByID(ctx context.Context, taskID int) (*types.Task, error) {
+	if s.committed && taskID == s.taskID {
+		return nil, errors.New("driver: bad connection")
+	}
+	return s.TaskStore.GetTaskByID(ctx, taskID)
+}
+
+func TestBulkRoutes(t *testing.T) {
+	ctx := context.Background()
+	s := newTestServer(t)
+	a := s.createTask("a", "")
+	b := s.createTask("b", "")
+	c := s.createTask("c", "")
+	limits := board.NewStore(s.db)
+	if err := limits.SetWIPLimit(ctx, types.WIPLimit{UserID: s.userIDs[0], Status: "in_progress", MaxTasks: 2, Mode: board.ModeReject}); err != nil {
+		t.Fatal(err)
+	}
+
+	bulk := func(body string) (int, types.BulkTaskResponse) {
+		t.Helper()
+
+		w := s.do(http.MethodPost, "/tasks/bulk", body)
+		var response types.BulkTaskResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%d %s: %v", w.Code, w.Body, err)
+		}
+		return w.Code, response
+	}
+	statuses := func(response types.BulkTaskResponse) []int {
+		codes := []int{}
+		for _, result := range response.Results {
+			codes = append(codes, result.Status)
+		}
+		return codes
+	}
+	status := func(id int) string {
+		t.Helper()
+
+		task, err := s.store.GetTaskByID(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return task.Status
+	}
+
+	// the limit fills up within the batch, so the third task is rejected on its own
+	moveAll := fmt.Sprintf(`{"ids":[%d,%d,%d],"operation":"set_status","status":"in_progress","all_or_nothing":%%t}`, a.ID, b.ID, c.ID)
+	code, response := bulk(fmt.Sprintf(moveAll, true))
+	if code != http.StatusConflict || response.Applied != 0 || !slices.Equal(statuses(response), []int{409, 409, 409}) {
+		t.Errorf("all or nothing past the limit = %d %+v, want everything rolled back", code, response)
+	}
+	if got := status(a.ID); got != "pending" {
+		t.Errorf("first task is %s after the rollback", got)
+	}
+
+	code, response = bulk(fmt.Sprintf(moveAll, false))
+	if code != http.StatusOK || response.Applied != 2 || !slices.Equal(statuses(response), []int{200, 200, 409}) {
+		t.Errorf("partial bulk past the limit = %d %+v, want the third task rejected", code, response)
+	}
+	if got := status(c.ID); got != "pending" {
+		t.Errorf("rejected task is %s", got)
+	}
+
+	// a task changed between the checks and the transaction fails with a version conflict
+	s.handler.store = racingStore{TaskStore: s.store, race: func() {
+		if w := s.do(http.MethodPut, taskPath(a.ID, ""), map[string]any{"user_id": s.userIDs[0], "title": "a2"}); w.Code != http.StatusOK {
+			t.Fatalf("racing update: %d %s", w.Code, w.Body)
+		}
+	}}
+	code, response = bulk(fmt.Sprintf(`{"ids":[%d,%d],"operation":"set_priority","priority":3}`, a.ID, b.ID))
+	if code != http.StatusOK || response.Applied != 1 || !slices.Equal(statuses(response), []int{409, 200}) {
+		t.Errorf("bulk with a racing update = %d %+v, want a version conflict for the first task", code, response)
+	}
+
+	// the changes are committed, so a failed reload still answers with the summary
+	events := s.events(b.ID, history.ActionUpdate)
+	s.handler.store = &reloadFailingStore{TaskStore: s.store, taskID: a.ID}
+	code, response = bulk(fmt.Sprintf(`{"ids":[%d,%d],"operation":"set_priority","priority":1}`, a.ID, b.ID))
+	if code != http.StatusOK || response.Applied != 2 || !slices.Equal(statuses(response), []int{200, 200}) {
+		t.Errorf("bulk with a failed reload = %d %+v, want both tasks applied", code, response)
+	}
+	if n := s.events(b.ID, history.ActionUpdate); n != events+1 {
+		t.Errorf("%d update events for the second task, want %d", n, events+1)
+	}
+}
+
+// failingStore fails to look up tasks.
+type failingStore struct {
+	types.TaskStore
+	err error
+}
+
+func (s failingStore) GetTaskByID(ctx context.Context, taskID int) (*types.Task, error) {
+	return nil, s.err
+}
+
+func TestUpdateTaskLookupErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"not found", types.ErrTaskNotFound, http.StatusNotFound},
+		{"database error", errors.New("driver: bad connection"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			s.handler.store = failingStore{TaskStore: s.store, err: tt.err}
+
+			if w := s.do(http.MethodPut, taskPath(1, ""), map[string]any{"title": "x"}); w.Code != tt.wantStatus {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// timeoutAfterWriteStore times out reading tasks once one got updated.
+type timeoutAfterWriteStore struct {
+	types.TaskStore
+	written bool
+}
+
+func (s *timeoutAfterWriteStore) UpdateTask(ctx context.Context, taskID, version int, task types.UpdateTaskPayload) (int64, error) {
+	s.written = true
+	return s.TaskStore.UpdateTask(ctx, taskID, version, task)
+}
+
+func (s *timeoutAfterWriteStore) GetTaskByID(ctx context.Context, taskID int) (*types.Task, error) {
+	if s.written {
+		return nil, errs.ErrQueryTimeout
+	}
+	return s.TaskStore.GetTaskByID(ctx, taskID)
+}
+
+func TestUpdateTaskReadAfterWriteTimeout(t *testing.T) {
+	s := newTestServer(t)
+	task := s.createTask("slow", "")
+	s.handler.store = &timeoutAfterWriteStore{TaskStore: s.store}
+
+	if w := s.do(http.MethodPut, taskPath(task.ID, ""), map[string]any{"user_id": s.userIDs[0], "title": "x"}); w.Code != http.StatusGatewayTimeout {
+		t.Errorf("got %d %s, want 504", w.Code, w.Body)
+	}
+}
+
+func TestUpdateOverdueTask(t *testing.T) {
+	s := newTestServer(t)
+	task := s.createTask("overdue", "")
+	due := time.Now().UTC().Add(-48 * time.Hour).Truncate(time.Second)
+	if _, err := s.db.Exec("UPDATE tasks SET due_date = ? WHERE id = ?", due, task.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// the client sends back the task as it got it
+	w := s.do(http.MethodPut, taskPath(task.ID, ""), map[string]any{"user_id": s.userIDs[0], "title": "still overdue", "due_date": due})
+	if w.Code != http.StatusOK {
+		t.Errorf("update keeping the past due date: %d %s", w.Code, w.Body)
+	}
+
+	w = s.do(http.MethodPut, taskPath(task.ID, ""), map[string]any{"user_id": s.userIDs[0], "due_date": due.Add(time.Hour)})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("update moving the due date into the past: %d %s, want 400", w.Code, w.Body)
+	}
+}
+
+func TestPatchTaskDecodeErrors(t *testing.T) {
+	s := newTestServer(t)
+	task := s.createTask("patched", "")
+
+	tests := []struct {
+		name       string
+		body       string
+		wantDetail string
+	}{
+		{"empty", " ", "missing request body"},
+		{"syntax", "{\n  \"title\": }", "line 2, column 12"},
+		{"trailing data", `{"title":"x"} {}`, "unexpected data after the JSON value"},
+		{"type", `{"priority":"high"}`, "priority must be int"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.do(http.MethodPatch, taskPath(task.ID, ""), tt.body)
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), tt.wantDetail) {
+				t.Errorf("got %d %s, want 400 with %q", w.Code, w.Body, tt.wantDetail)
+			}
+		})
+	}
+}
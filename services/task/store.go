@@ -1,25 +1,64 @@
 package task
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/services/board"
+	"todo/services/customfield"
 	"todo/types"
 	"todo/utils"
 )
 
+// taskColumns lists the columns scanRowsIntoTask reads.
+func taskColumns(d dialect.Dialect) string {
+	return `id, workspace_id, user_id, creator_id, title, description, status, priority, due_date, created_at, updated_at, deleted_at,
+	checklist_auto_complete, position, version, custom_fields,
+	(SELECT COUNT(*) FROM task_comments c WHERE c.task_id = tasks.id AND c.deleted_at IS NULL) AS comment_count,
+	(SELECT COUNT(*) FROM task_checklist_items i WHERE i.task_id = tasks.id AND i.done) AS checklist_done,
+	(SELECT COUNT(*) FROM task_checklist_items i WHERE i.task_id = tasks.id) AS checklist_total,
+	(SELECT ` + d.JSONArrayAgg("a.user_id") + ` FROM task_assignees a WHERE a.task_id = tasks.id) AS assignee_ids,
+	(SELECT ` + d.JSONArrayAgg("w.user_id") + ` FROM task_watchers w WHERE w.task_id = tasks.id) AS watcher_ids,
+	(SELECT ` + d.JSONArrayAgg("l.label") + ` FROM task_labels l WHERE l.task_id = tasks.id) AS labels`
+}
+
 type Store struct {
-	db *sql.DB
+	db      *dialect.DB
+	columns string
+}
+
+// querier is implemented by both *dialect.DB and *dialect.Tx.
+type querier interface {
+	Dialect() dialect.Dialect
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *dialect.Row
 }
 
 func NewStore(db *sql.DB) *Store {
-	return &Store{db: db}
+	store := &Store{db: dialect.Wrap(db)}
+	store.columns = taskColumns(store.db.Dialect())
+
+	return store
 }
 
-func (s *Store) GetTaskByID(taskID int) (*types.Task, error) {
-	rows, err := s.db.Query("SELECT * FROM tasks WHERE id = ?", taskID)
+// GetTaskByID returns types.ErrTaskNotFound for missing and trashed tasks.
+func (s *Store) GetTaskByID(ctx context.Context, taskID int) (*types.Task, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+s.columns+" FROM tasks WHERE id = ? AND deleted_at IS NULL", taskID)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	t := new(types.Task)
 	for rows.Next() {
@@ -28,25 +67,110 @@ func (s *Store) GetTaskByID(taskID int) (*types.Task, error) {
 			return nil, err
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
+
+	if t.ID == 0 {
+		return nil, types.ErrTaskNotFound
+	}
 
 	return t, nil
 }
 
-func (s *Store) GetPaginatedTasks(pagination utils.PaginationParams) ([]types.Task, int, error) {
+// GetTaskWorkspaceID looks at trashed tasks too, it returns 0 when the task does not exist.
+func (s *Store) GetTaskWorkspaceID(ctx context.Context, taskID int) (int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	var workspaceID int
+	err := s.db.QueryRowContext(ctx, "SELECT workspace_id FROM tasks WHERE id = ?", taskID).Scan(&workspaceID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return workspaceID, err
+}
+
+func (s *Store) GetPaginatedTasks(ctx context.Context, filter types.TaskFilter, pagination utils.PaginationParams) ([]types.Task, int, error) {
+	where, args := workspaceCondition("deleted_at IS NULL", filter.WorkspaceID)
+	if filter.AssigneeID != nil {
+		where += " AND EXISTS (SELECT 1 FROM task_assignees a WHERE a.task_id = tasks.id AND a.user_id = ?)"
+		args = append(args, *filter.AssigneeID)
+	}
+	if filter.WatcherID != nil {
+		where += " AND EXISTS (SELECT 1 FROM task_watchers w WHERE w.task_id = tasks.id AND w.user_id = ?)"
+		args = append(args, *filter.WatcherID)
+	}
+	if filter.Label != nil {
+		where += " AND EXISTS (SELECT 1 FROM task_labels l WHERE l.task_id = tasks.id AND l.label = ?)"
+		args = append(args, *filter.Label)
+	}
+	for _, f := range filter.CustomFields {
+		condition, conditionArgs := customFieldCondition(s.db.Dialect(), f)
+		where += " AND " + condition
+		args = append(args, conditionArgs...)
+	}
+
+	return s.getPaginatedTasks(ctx, where, args, pagination)
+}
+
+func (s *Store) GetPaginatedTrashedTasks(ctx context.Context, filter types.TaskFilter, pagination utils.PaginationParams) ([]types.Task, int, error) {
+	where, args := workspaceCondition("deleted_at IS NOT NULL", filter.WorkspaceID)
+	return s.getPaginatedTasks(ctx, where, args, pagination)
+}
+
+func (s *Store) GetColumnTasks(ctx context.Context, column types.BoardColumn, pagination utils.PaginationParams) ([]types.Task, int, error) {
+	where, args := columnCondition(s.db.Dialect(), column)
+	return s.getPaginatedTasks(ctx, where, args, pagination)
+}
+
+func (s *Store) CountColumnTasks(ctx context.Context, column types.BoardColumn) (int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	where, args := columnCondition(s.db.Dialect(), column)
+
+	var count int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks WHERE "+where, args...).Scan(&count)
+
+	return count, err
+}
+
+// columnCondition selects the live tasks of a board column.
+func columnCondition(d dialect.Dialect, column types.BoardColumn) (string, []any) {
+	where, args := workspaceCondition(d.NullSafeEqual("user_id")+" AND status = ? AND deleted_at IS NULL", column.WorkspaceID)
+	return where, append([]any{column.UserID, column.Status}, args...)
+}
+
+// workspaceCondition narrows where to one workspace, 0 leaves it unchanged.
+func workspaceCondition(where string, workspaceID int) (string, []any) {
+	if workspaceID == 0 {
+		return where, nil
+	}
+
+	return where + " AND workspace_id = ?", []any{workspaceID}
+}
+
+func (s *Store) getPaginatedTasks(ctx context.Context, where string, args []any, pagination utils.PaginationParams) ([]types.Task, int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
 	// get total count
 	var total int
-	if err := s.db.QueryRow("SELECT COUNT(*) FROM tasks").Scan(&total); err != nil {
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks WHERE "+where, args...).Scan(&total); err != nil {
 		return nil, 0, err
 	}
 
 	// dynamic query with safe params
 	query := fmt.Sprintf(`
-	SELECT id, user_id, title, description, status, priority, due_date, created_at, updated_at
+	SELECT %s
 	FROM tasks
+	WHERE %s
 	ORDER BY %s %s
-	LIMIT ? OFFSET ?`, pagination.SortBy, pagination.Order)
+	LIMIT ? OFFSET ?`, s.columns, where, orderExpression(s.db.Dialect(), pagination.SortBy), pagination.Order)
 
-	rows, err := s.db.Query(query, pagination.Limit, pagination.Offset)
+	rows, err := s.db.QueryContext(ctx, query, append(args, pagination.Limit, pagination.Offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -61,32 +185,636 @@ func (s *Store) GetPaginatedTasks(pagination utils.PaginationParams) ([]types.Ta
 		}
 		tasks = append(tasks, *t)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, errs.FromContext(ctx, err)
+	}
 
 	return tasks, total, nil
 }
 
-func (s *Store) CreateTask(task types.CreateTaskPayload) error {
+// CreateTask returns the inserted task with its generated ID, timestamps and position.
+func (s *Store) CreateTask(ctx context.Context, task types.CreateTaskPayload) (*types.Task, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	id, err := createTask(ctx, tx, task)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
+
+	return s.GetTaskByID(ctx, id)
+}
+
+// CreateTasks creates every task in one transaction, in order. A task that would exceed the
+// reject WIP limit of its column fails with board.ErrWIPLimitReached and nothing is created.
+func (s *Store) CreateTasks(ctx context.Context, tasks []types.CreateTaskPayload) ([]types.Task, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids := make([]int, len(tasks))
+	for i, task := range tasks {
+		if task.Status == "" {
+			task.Status = "pending"
+		}
+		column := types.BoardColumn{WorkspaceID: task.WorkspaceID, UserID: task.UserID, Status: task.Status}
+		if err := checkWIPLimit(ctx, tx, types.BoardColumn{}, column); err != nil {
+			return nil, fmt.Errorf("task %d: %w", i+1, err)
+		}
+
+		if ids[i], err = createTask(ctx, tx, task); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
+
+	created := make([]types.Task, len(ids))
+	for i, id := range ids {
+		task, err := s.GetTaskByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		created[i] = *task
+	}
+
+	return created, nil
+}
+
+// createTask inserts the task at the bottom of its column and returns its ID.
+func createTask(ctx context.Context, tx *dialect.Tx, task types.CreateTaskPayload) (int, error) {
 	if task.Status == "" {
 		task.Status = "pending"
 	}
 
-	_, err := s.db.Exec(
-		"INSERT INTO tasks (user_id, title, description, status, priority, due_date) VALUES (?, ?, ?, ?, ?, ?)",
-		task.UserID, task.Title, task.Description, task.Status, task.Priority, task.DueDate)
+	customFields, err := marshalCustomFields(task.CustomFields)
+	if err != nil {
+		return 0, err
+	}
+
+	last, err := lastPosition(ctx, tx, types.BoardColumn{UserID: task.UserID, Status: task.Status})
+	if err != nil {
+		return 0, err
+	}
 
-	return err
+	var creatorID *int
+	if task.CreatorID > 0 {
+		creatorID = &task.CreatorID
+	}
+
+	id, err := tx.InsertContext(ctx,
+		"INSERT INTO tasks (workspace_id, user_id, creator_id, title, description, status, priority, due_date, checklist_auto_complete, position, custom_fields) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		task.WorkspaceID, task.UserID, creatorID, task.Title, task.Description, task.Status, task.Priority, task.DueDate, task.ChecklistAutoComplete, positionBetween(last, ""), customFields)
+	if err != nil {
+		return 0, err
+	}
+
+	// the creator watches the task from the start
+	if creatorID != nil {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO task_watchers (task_id, user_id) VALUES (?, ?)", id, *creatorID); err != nil {
+			return 0, err
+		}
+	}
+	if err := setAssignees(ctx, tx, int(id), task.AssigneeIDs); err != nil {
+		return 0, err
+	}
+	if err := setLabels(ctx, tx, int(id), task.Labels); err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
 }
 
-func (s *Store) UpdateTask(taskID int, task types.UpdateTaskPayload) error {
-	_, err := s.db.Exec(
-		"UPDATE tasks SET user_id = ?, title = ?, description = ?, status = ?, priority = ?, due_date = ? WHERE id = ?",
-		task.UserID, task.Title, task.Description, task.Status, task.Priority, task.DueDate, taskID)
+// UpdateTask overwrites every field, custom fields included, callers merge partial updates beforehand.
+// Assignees and labels are only replaced when AssigneeIDs and Labels are set. Nothing is written and
+// 0 is returned unless the task is still at version.
+func (s *Store) UpdateTask(ctx context.Context, taskID, version int, task types.UpdateTaskPayload) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
 
-	return err
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	n, err := updateTask(ctx, tx, taskID, version, task)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+
+	return n, errs.FromContext(ctx, tx.Commit())
+}
+
+// BulkUpdateTasks applies every change in one transaction and returns the error of each item.
+// With allOrNothing the first failure rolls back everything, otherwise failed items are skipped
+// through savepoints and the rest is committed.
+func (s *Store) BulkUpdateTasks(ctx context.Context, changes []types.BulkTaskChange, allOrNothing bool) ([]error, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	itemErrs := make([]error, len(changes))
+	for i, change := range changes {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT bulk_item"); err != nil {
+			return nil, err
+		}
+
+		if change.Update == nil {
+			var n int64
+			if n, itemErrs[i] = deleteTask(ctx, tx, change.TaskID); itemErrs[i] == nil && n == 0 {
+				itemErrs[i] = types.ErrTaskNotFound
+			}
+		} else {
+			var n int64
+			if n, itemErrs[i] = updateTask(ctx, tx, change.TaskID, change.Version, *change.Update); itemErrs[i] == nil && n == 0 {
+				itemErrs[i] = errVersionMismatch
+			}
+		}
+		if itemErrs[i] == nil {
+			continue
+		}
+
+		if allOrNothing {
+			return itemErrs, itemErrs[i]
+		}
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT bulk_item"); err != nil {
+			return nil, err
+		}
+	}
+
+	return itemErrs, errs.FromContext(ctx, tx.Commit())
 }
 
-func (s *Store) DeleteTask(taskID int) (int64, error) {
-	result, err := s.db.Exec("DELETE FROM tasks WHERE id = ?", taskID)
+func updateTask(ctx context.Context, tx *dialect.Tx, taskID, version int, task types.UpdateTaskPayload) (int64, error) {
+	customFields, err := marshalCustomFields(task.CustomFields)
+	if err != nil {
+		return 0, err
+	}
+
+	from, err := currentColumn(ctx, tx, taskID)
+	if errors.Is(err, types.ErrTaskNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	to := types.BoardColumn{WorkspaceID: from.WorkspaceID, UserID: task.UserID, Status: *task.Status}
+	if err := checkWIPLimit(ctx, tx, from, to); err != nil {
+		return 0, err
+	}
+
+	last, err := lastPosition(ctx, tx, types.BoardColumn{UserID: task.UserID, Status: *task.Status})
+	if err != nil {
+		return 0, err
+	}
+
+	// a task that changes column is appended to the new one, position is assigned
+	// first because mysql evaluates SET from left to right with the new values
+	result, err := tx.ExecContext(ctx, `
+	UPDATE tasks
+	SET position = CASE WHEN `+tx.Dialect().NullSafeEqual("user_id")+` AND status = ? THEN position ELSE ? END,
+		user_id = ?, title = ?, description = ?, status = ?, priority = ?, due_date = ?, checklist_auto_complete = ?,
+		custom_fields = ?, version = version + 1
+	WHERE id = ? AND version = ? AND deleted_at IS NULL`,
+		task.UserID, task.Status, positionBetween(last, ""),
+		task.UserID, task.Title, task.Description, task.Status, task.Priority, task.DueDate, task.ChecklistAutoComplete,
+		customFields, taskID, version)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil || n == 0 {
+		return 0, err
+	}
+
+	if task.AssigneeIDs != nil {
+		if err := setAssignees(ctx, tx, taskID, *task.AssigneeIDs); err != nil {
+			return 0, err
+		}
+	}
+	if task.Labels != nil {
+		if err := setLabels(ctx, tx, taskID, *task.Labels); err != nil {
+			return 0, err
+		}
+	}
+
+	return n, nil
+}
+
+// PatchTask writes only the changed fields, named by their JSON keys. A task that changes
+// column is appended to the new one. Like UpdateTask it returns 0 unless the task is still at version.
+func (s *Store) PatchTask(ctx context.Context, taskID, version int, task types.TaskDocument, changed []string) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	set := []string{"version = version + 1"}
+	var args []any
+	for _, field := range changed {
+		switch field {
+		case "user_id":
+			set, args = append(set, "user_id = ?"), append(args, task.UserID)
+		case "title":
+			set, args = append(set, "title = ?"), append(args, task.Title)
+		case "description":
+			set, args = append(set, "description = ?"), append(args, task.Description)
+		case "status":
+			set, args = append(set, "status = ?"), append(args, task.Status)
+		case "priority":
+			set, args = append(set, "priority = ?"), append(args, task.Priority)
+		case "due_date":
+			set, args = append(set, "due_date = ?"), append(args, task.DueDate)
+		case "checklist_auto_complete":
+			set, args = append(set, "checklist_auto_complete = ?"), append(args, task.ChecklistAutoComplete)
+		case "custom_fields":
+			customFields, err := marshalCustomFields(task.CustomFields)
+			if err != nil {
+				return 0, err
+			}
+			set, args = append(set, "custom_fields = ?"), append(args, customFields)
+		}
+	}
+
+	if slices.Contains(changed, "user_id") || slices.Contains(changed, "status") {
+		from, err := currentColumn(ctx, tx, taskID)
+		if errors.Is(err, types.ErrTaskNotFound) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		to := types.BoardColumn{WorkspaceID: from.WorkspaceID, UserID: task.UserID, Status: task.Status}
+		if err := checkWIPLimit(ctx, tx, from, to); err != nil {
+			return 0, err
+		}
+
+		last, err := lastPosition(ctx, tx, types.BoardColumn{UserID: task.UserID, Status: task.Status})
+		if err != nil {
+			return 0, err
+		}
+		set, args = append(set, "position = ?"), append(args, positionBetween(last, ""))
+	}
+
+	// the version is bumped even when only assignees or labels change
+	result, err := tx.ExecContext(ctx,
+		"UPDATE tasks SET "+strings.Join(set, ", ")+" WHERE id = ? AND version = ? AND deleted_at IS NULL",
+		append(args, taskID, version)...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil || n == 0 {
+		return 0, err
+	}
+
+	if slices.Contains(changed, "assignee_ids") {
+		if err := setAssignees(ctx, tx, taskID, task.AssigneeIDs); err != nil {
+			return 0, err
+		}
+	}
+	if slices.Contains(changed, "labels") {
+		if err := setLabels(ctx, tx, taskID, task.Labels); err != nil {
+			return 0, err
+		}
+	}
+
+	return n, errs.FromContext(ctx, tx.Commit())
+}
+
+// setAssignees replaces the assignees of the task, new assignees start watching it.
+func setAssignees(ctx context.Context, tx *dialect.Tx, taskID int, userIDs []int) error {
+	query := "DELETE FROM task_assignees WHERE task_id = ?"
+	args := []any{taskID}
+	if len(userIDs) > 0 {
+		query += " AND user_id NOT IN (?" + strings.Repeat(", ?", len(userIDs)-1) + ")"
+		for _, id := range userIDs {
+			args = append(args, id)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	for _, id := range userIDs {
+		if _, err := tx.ExecContext(ctx, tx.Dialect().InsertIgnore("INTO task_assignees (task_id, user_id) VALUES (?, ?)"), taskID, id); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, tx.Dialect().InsertIgnore("INTO task_watchers (task_id, user_id) VALUES (?, ?)"), taskID, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setWatchers replaces the watchers of the task, watchers that stay keep how they are notified.
+func setWatchers(ctx context.Context, tx *dialect.Tx, taskID int, userIDs []int) error {
+	query := "DELETE FROM task_watchers WHERE task_id = ?"
+	args := []any{taskID}
+	if len(userIDs) > 0 {
+		query += " AND user_id NOT IN (?" + strings.Repeat(", ?", len(userIDs)-1) + ")"
+		for _, id := range userIDs {
+			args = append(args, id)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	for _, id := range userIDs {
+		if _, err := tx.ExecContext(ctx, tx.Dialect().InsertIgnore("INTO task_watchers (task_id, user_id) VALUES (?, ?)"), taskID, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setLabels replaces the labels of the task.
+func setLabels(ctx context.Context, tx *dialect.Tx, taskID int, labels []string) error {
+	query := "DELETE FROM task_labels WHERE task_id = ?"
+	args := []any{taskID}
+	if len(labels) > 0 {
+		query += " AND label NOT IN (?" + strings.Repeat(", ?", len(labels)-1) + ")"
+		for _, label := range labels {
+			args = append(args, label)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	for _, label := range labels {
+		if _, err := tx.ExecContext(ctx, tx.Dialect().InsertIgnore("INTO task_labels (task_id, label) VALUES (?, ?)"), taskID, label); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MoveTask places the task in the given status column at the given position.
+// It returns 0 unless the task is still at version.
+func (s *Store) MoveTask(ctx context.Context, taskID, version int, status, position string) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	from, err := currentColumn(ctx, tx, taskID)
+	if errors.Is(err, types.ErrTaskNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	to := from
+	to.Status = status
+	if err := checkWIPLimit(ctx, tx, from, to); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE tasks SET status = ?, position = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL",
+		status, position, taskID, version)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil || n == 0 {
+		return 0, err
+	}
+
+	return n, errs.FromContext(ctx, tx.Commit())
+}
+
+// currentColumn returns the board column a live task is in, types.ErrTaskNotFound otherwise.
+func currentColumn(ctx context.Context, tx *dialect.Tx, taskID int) (types.BoardColumn, error) {
+	var column types.BoardColumn
+	err := tx.QueryRowContext(ctx, "SELECT workspace_id, user_id, status FROM tasks WHERE id = ? AND deleted_at IS NULL", taskID).
+		Scan(&column.WorkspaceID, &column.UserID, &column.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return column, types.ErrTaskNotFound
+	}
+
+	return column, err
+}
+
+// checkWIPLimit fails with board.ErrWIPLimitReached when a task moving from one column into
+// another would exceed the reject limit of the target. The limit row stays locked until the
+// transaction ends, so writers into a limited column queue up and each one counts what the
+// previous one committed. Warnings are left to board.CheckWIPLimit.
+func checkWIPLimit(ctx context.Context, tx *dialect.Tx, from, to types.BoardColumn) error {
+	if to.UserID == nil || (sameUser(from.UserID, to.UserID) && from.Status == to.Status) {
+		return nil
+	}
+
+	var maxTasks int
+	var mode string
+	err := tx.QueryRowContext(ctx,
+		"SELECT max_tasks, mode FROM board_wip_limits WHERE user_id = ? AND status = ?"+tx.Dialect().Lock("FOR UPDATE"),
+		*to.UserID, to.Status).Scan(&maxTasks, &mode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil || mode != board.ModeReject {
+		return err
+	}
+
+	// a locking read sees the latest commit on mysql too, rows are counted here because
+	// postgres locks no aggregates
+	where, args := columnCondition(tx.Dialect(), to)
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM tasks WHERE "+where+tx.Dialect().Lock("FOR UPDATE"), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return errs.FromContext(ctx, err)
+	}
+
+	if count >= maxTasks {
+		return fmt.Errorf("%w: column %s already holds %d of %d tasks", board.ErrWIPLimitReached, to.Status, count, maxTasks)
+	}
+
+	return nil
+}
+
+// GetLastPosition returns the highest position in the column, or an empty string for an empty column.
+func (s *Store) GetLastPosition(ctx context.Context, column types.BoardColumn) (string, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	return lastPosition(ctx, s.db, column)
+}
+
+func lastPosition(ctx context.Context, q querier, column types.BoardColumn) (string, error) {
+	var last sql.NullString
+	err := q.QueryRowContext(ctx,
+		"SELECT MAX(position) FROM tasks WHERE "+q.Dialect().NullSafeEqual("user_id")+" AND status = ? AND deleted_at IS NULL",
+		column.UserID, column.Status).Scan(&last)
+
+	return last.String, err
+}
+
+// GetUnbalancedColumns lists columns whose keys grew too long or collide.
+func (s *Store) GetUnbalancedColumns(ctx context.Context, maxKeyLength int) ([]types.BoardColumn, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	// keys are ascii, so LENGTH counts characters on every database
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT user_id, status
+	FROM tasks
+	WHERE deleted_at IS NULL
+	GROUP BY user_id, status
+	HAVING MAX(LENGTH(position)) > ? OR COUNT(*) <> COUNT(DISTINCT position)`, maxKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []types.BoardColumn
+	for rows.Next() {
+		var c types.BoardColumn
+		if err := rows.Scan(&c.UserID, &c.Status); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+
+	return columns, errs.FromContext(ctx, rows.Err())
+}
+
+// RebalanceColumn rewrites every position in the column with short, evenly spaced keys keeping the current order.
+func (s *Store) RebalanceColumn(ctx context.Context, column types.BoardColumn) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id FROM tasks WHERE "+tx.Dialect().NullSafeEqual("user_id")+" AND status = ? AND deleted_at IS NULL ORDER BY position, id"+tx.Dialect().Lock("FOR UPDATE"),
+		column.UserID, column.Status)
+	if err != nil {
+		return err
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return errs.FromContext(ctx, err)
+	}
+
+	for i, key := range evenPositions(len(ids)) {
+		if _, err := tx.ExecContext(ctx, "UPDATE tasks SET position = ? WHERE id = ?", key, ids[i]); err != nil {
+			return err
+		}
+	}
+
+	return errs.FromContext(ctx, tx.Commit())
+}
+
+// CopyTask writes the task as it is, ID, version and timestamps included, together with its
+// assignees, watchers and labels. The demo mode mirrors the tasks it keeps in memory with it
+// for the stores that join the tasks table.
+func (s *Store) CopyTask(ctx context.Context, task types.Task) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	customFields, err := marshalCustomFields(task.CustomFields)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, tx.Dialect().Upsert(
+		"INSERT INTO tasks (id, workspace_id, user_id, creator_id, title, description, status, priority, due_date, created_at, updated_at, deleted_at, checklist_auto_complete, position, version, custom_fields) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		[]string{"id"}, "workspace_id", "user_id", "creator_id", "title", "description", "status", "priority", "due_date",
+		"created_at", "updated_at", "deleted_at", "checklist_auto_complete", "position", "version", "custom_fields"),
+		task.ID, task.WorkspaceID, task.UserID, task.CreatorID, task.Title, task.Description, task.Status, task.Priority, task.DueDate,
+		task.CreatedAt, task.UpdatedAt, task.DeletedAt, task.ChecklistAutoComplete, task.Position, task.Version, customFields)
+	if err != nil {
+		return err
+	}
+
+	if err := setAssignees(ctx, tx, task.ID, task.AssigneeIDs); err != nil {
+		return err
+	}
+	if err := setWatchers(ctx, tx, task.ID, task.WatcherIDs); err != nil {
+		return err
+	}
+	if err := setLabels(ctx, tx, task.ID, task.Labels); err != nil {
+		return err
+	}
+
+	return errs.FromContext(ctx, tx.Commit())
+}
+
+// DeleteTask moves the task to the trash, it can be restored until it is purged.
+func (s *Store) DeleteTask(ctx context.Context, taskID int) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	return deleteTask(ctx, s.db, taskID)
+}
+
+func deleteTask(ctx context.Context, q querier, taskID int) (int64, error) {
+	result, err := q.ExecContext(ctx, "UPDATE tasks SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL", taskID)
 	if err != nil {
 		return 0, err
 	}
@@ -99,12 +827,154 @@ func (s *Store) DeleteTask(taskID int) (int64, error) {
 	return rowsAffected, nil
 }
 
+func (s *Store) RestoreTask(ctx context.Context, taskID int) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "UPDATE tasks SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", taskID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// PurgeTask removes the task for good, whether or not it is in the trash.
+func (s *Store) PurgeTask(ctx context.Context, taskID int) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM tasks WHERE id = ?", taskID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// GetPurgeableTaskIDs lists the tasks that have been in the trash for longer than retention.
+func (s *Store) GetPurgeableTaskIDs(ctx context.Context, retention time.Duration) ([]int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < "+s.db.Dialect().SecondsAgo(),
+		int64(retention.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, errs.FromContext(ctx, rows.Err())
+}
+
+// customFieldCondition builds the WHERE condition for one custom field filter.
+func customFieldCondition(d dialect.Dialect, f types.CustomFieldFilter) (string, []any) {
+	path := fmt.Sprintf(`$."%s"`, f.Key)
+
+	switch d {
+	case dialect.Postgres:
+		switch f.Type {
+		case customfield.TypeMultiSelect:
+			return "custom_fields -> ? @> to_jsonb(?::text)", []any{f.Key, f.Value}
+		case customfield.TypeNumber:
+			return "(custom_fields ->> ?)::numeric = ?", []any{f.Key, f.Value}
+		case customfield.TypeCheckbox:
+			return "custom_fields -> ? = ?::jsonb", []any{f.Key, fmt.Sprint(f.Value)}
+		}
+		return "custom_fields ->> ? = ?", []any{f.Key, f.Value}
+	case dialect.SQLite:
+		// json_extract returns checkboxes as 1 and 0, which is how booleans are bound
+		if f.Type == customfield.TypeMultiSelect {
+			return "EXISTS (SELECT 1 FROM json_each(custom_fields, ?) WHERE value = ?)", []any{path, f.Value}
+		}
+		return "json_extract(custom_fields, ?) = ?", []any{path, f.Value}
+	}
+
+	switch f.Type {
+	case customfield.TypeMultiSelect:
+		return "JSON_CONTAINS(custom_fields, JSON_QUOTE(?), ?)", []any{f.Value, path}
+	case customfield.TypeNumber:
+		return "JSON_EXTRACT(custom_fields, ?) = ?", []any{path, f.Value}
+	case customfield.TypeCheckbox:
+		return "JSON_EXTRACT(custom_fields, ?) = CAST(? AS JSON)", []any{path, fmt.Sprint(f.Value)}
+	}
+
+	return "JSON_UNQUOTE(JSON_EXTRACT(custom_fields, ?)) = ?", []any{path, f.Value}
+}
+
+// orderExpression maps a validated sort_by value to SQL, custom fields are sorted by their JSON value.
+func orderExpression(d dialect.Dialect, sortBy string) string {
+	if key, ok := strings.CutPrefix(sortBy, customFieldPrefix); ok {
+		switch d {
+		case dialect.Postgres:
+			return fmt.Sprintf(`custom_fields -> '%s'`, key)
+		case dialect.SQLite:
+			return fmt.Sprintf(`json_extract(custom_fields, '$."%s"')`, key)
+		}
+		return fmt.Sprintf(`JSON_EXTRACT(custom_fields, '$."%s"')`, key)
+	}
+
+	return sortBy
+}
+
+func marshalCustomFields(values map[string]any) (any, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// unmarshalIDs reads a JSON array aggregate, which is NULL for no rows and has no defined order.
+func unmarshalIDs(b []byte) ([]int, error) {
+	ids := []int{}
+	if b != nil {
+		if err := json.Unmarshal(b, &ids); err != nil {
+			return nil, err
+		}
+	}
+	sort.Ints(ids)
+
+	return ids, nil
+}
+
+// unmarshalLabels reads a JSON array aggregate like unmarshalIDs.
+func unmarshalLabels(b []byte) ([]string, error) {
+	labels := []string{}
+	if b != nil {
+		if err := json.Unmarshal(b, &labels); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(labels)
+
+	return labels, nil
+}
+
 func scanRowsIntoTask(rows *sql.Rows) (*types.Task, error) {
 	task := new(types.Task)
+	var customFields, assigneeIDs, watcherIDs, labels []byte
 
 	err := rows.Scan(
 		&task.ID,
+		&task.WorkspaceID,
 		&task.UserID,
+		&task.CreatorID,
 		&task.Title,
 		&task.Description,
 		&task.Status,
@@ -112,11 +982,39 @@ func scanRowsIntoTask(rows *sql.Rows) (*types.Task, error) {
 		&task.DueDate,
 		&task.CreatedAt,
 		&task.UpdatedAt,
+		&task.DeletedAt,
+		&task.ChecklistAutoComplete,
+		&task.Position,
+		&task.Version,
+		&customFields,
+		&task.CommentCount,
+		&task.Checklist.Done,
+		&task.Checklist.Total,
+		&assigneeIDs,
+		&watcherIDs,
+		&labels,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	task.CustomFields = map[string]any{}
+	if customFields != nil {
+		if err := json.Unmarshal(customFields, &task.CustomFields); err != nil {
+			return nil, err
+		}
+	}
+
+	if task.AssigneeIDs, err = unmarshalIDs(assigneeIDs); err != nil {
+		return nil, err
+	}
+	if task.WatcherIDs, err = unmarshalIDs(watcherIDs); err != nil {
+		return nil, err
+	}
+	if task.Labels, err = unmarshalLabels(labels); err != nil {
+		return nil, err
+	}
+
 	return task, nil
 }
@@ -0,0 +1,48 @@
+package task
+
+import (
+	"testing"
+	"todo/services/board"
+	"todo/services/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.TestTaskStore(t, func(t *testing.T) storetest.TaskFixture {
+		db := storetest.SQLite(t)
+		userIDs, workspaceIDs := storetest.Seed(t, db)
+
+		return storetest.TaskFixture{Store: NewStore(db), Limits: board.NewStore(db), UserIDs: userIDs, WorkspaceIDs: workspaceIDs, AddItems: storetest.AddTaskItems(db)}
+	})
+}
+
+func TestMySQLStore(t *testing.T) {
+	storetest.TestTaskStore(t, func(t *testing.T) storetest.TaskFixture {
+		db := storetest.MySQL(t)
+		userIDs, workspaceIDs := storetest.Seed(t, db)
+
+		return storetest.TaskFixture{Store: NewStore(db), Limits: board.NewStore(db), UserIDs: userIDs, WorkspaceIDs: workspaceIDs, AddItems: storetest.AddTaskItems(db)}
+	})
+}
+
+func TestPostgresStore(t *testing.T) {
+	storetest.TestTaskStore(t, func(t *testing.T) storetest.TaskFixture {
+		db := storetest.Postgres(t)
+		userIDs, workspaceIDs := storetest.Seed(t, db)
+
+		return storetest.TaskFixture{Store: NewStore(db), Limits: board.NewStore(db), UserIDs: userIDs, WorkspaceIDs: workspaceIDs, AddItems: storetest.AddTaskItems(db)}
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	storetest.TestTaskStore(t, func(t *testing.T) storetest.TaskFixture {
+		// limits are kept in the database in the demo mode too
+		db := storetest.SQLite(t)
+		userIDs, workspaceIDs := storetest.Seed(t, db)
+		limits := board.NewStore(db)
+
+		store := NewMemoryStore()
+		store.UseWIPLimits(limits)
+
+		return storetest.TaskFixture{Store: store, Limits: limits, UserIDs: userIDs, WorkspaceIDs: workspaceIDs}
+	})
+}
@@ -0,0 +1,103 @@
+package template
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"todo/errs"
+	"todo/types"
+)
+
+// variablePattern matches {{name}}, spaces inside the braces are allowed.
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+var errMissingVariables = errs.Validation("missing_template_variables", "missing template variables")
+
+// Render substitutes every {{variable}} in text, variables missing from vars are an error.
+func Render(text string, vars map[string]string) (string, error) {
+	missing := map[string]bool{}
+	rendered := variablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := variablePattern.FindStringSubmatch(match)[1]
+		value, ok := vars[name]
+		if !ok {
+			missing[name] = true
+			return match
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", errMissingVariables.Errorf("missing template variables: %s", strings.Join(names, ", "))
+	}
+
+	return rendered, nil
+}
+
+// RenderBlueprint renders every text of the blueprint.
+func RenderBlueprint(blueprint types.TaskBlueprint, vars map[string]string) (types.TaskBlueprint, error) {
+	var err error
+	rendered := blueprint
+
+	if rendered.Title, err = Render(blueprint.Title, vars); err != nil {
+		return rendered, err
+	}
+	if rendered.Description, err = Render(blueprint.Description, vars); err != nil {
+		return rendered, err
+	}
+
+	rendered.Checklist = make([]string, len(blueprint.Checklist))
+	for i, text := range blueprint.Checklist {
+		if rendered.Checklist[i], err = Render(text, vars); err != nil {
+			return rendered, err
+		}
+	}
+
+	rendered.Labels = make([]string, len(blueprint.Labels))
+	for i, label := range blueprint.Labels {
+		if rendered.Labels[i], err = Render(label, vars); err != nil {
+			return rendered, err
+		}
+	}
+
+	return rendered, nil
+}
+
+// DueDate resolves the relative due date of the blueprint, nil when it has none.
+func DueDate(blueprint types.TaskBlueprint, start time.Time) *time.Time {
+	if blueprint.DueOffsetHours == nil {
+		return nil
+	}
+
+	due := start.Add(time.Duration(*blueprint.DueOffsetHours) * time.Hour)
+	return &due
+}
+
+// FromTask turns an existing task into a blueprint, its due date becomes an offset from its creation.
+func FromTask(task *types.Task, checklist []types.ChecklistItem) types.TaskBlueprint {
+	blueprint := types.TaskBlueprint{
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+		Checklist:   make([]string, len(checklist)),
+		Labels:      append([]string{}, task.Labels...),
+	}
+	for i, item := range checklist {
+		blueprint.Checklist[i] = item.Text
+	}
+
+	if task.DueDate != nil {
+		hours := int(task.DueDate.Sub(task.CreatedAt).Round(time.Hour).Hours())
+		if hours < 0 {
+			hours = 0
+		}
+		blueprint.DueOffsetHours = &hours
+	}
+
+	return blueprint
+}
@@ -0,0 +1,82 @@
+package template
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"todo/types"
+)
+
+func TestRender(t *testing.T) {
+	vars := map[string]string{"name": "Ada", "team": "API"}
+
+	got, err := Render("Welcome {{name}} to {{ team }}, {{name}}!", vars)
+	if err != nil || got != "Welcome Ada to API, Ada!" {
+		t.Errorf("Render() = %q, %v", got, err)
+	}
+
+	if got, err := Render("no variables {{ }}", nil); err != nil || got != "no variables {{ }}" {
+		t.Errorf("Render() = %q, %v", got, err)
+	}
+
+	_, err = Render("{{b}} {{a}} {{name}} {{a}}", vars)
+	if err == nil || err.Error() != "missing template variables: a, b" {
+		t.Errorf("expected missing variables a and b, got %v", err)
+	}
+}
+
+func TestRenderBlueprint(t *testing.T) {
+	offset := 48
+	blueprint := types.TaskBlueprint{
+		Title:          "Onboard {{name}}",
+		Description:    "Laptop for {{name}}",
+		Priority:       2,
+		DueOffsetHours: &offset,
+		Checklist:      []string{"Create {{name}}'s account", "Book a desk"},
+		Labels:         []string{"onboarding", "{{team}}"},
+	}
+
+	rendered, err := RenderBlueprint(blueprint, map[string]string{"name": "Ada", "team": "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rendered.Title != "Onboard Ada" || rendered.Description != "Laptop for Ada" {
+		t.Errorf("unexpected texts: %q, %q", rendered.Title, rendered.Description)
+	}
+	if !reflect.DeepEqual(rendered.Checklist, []string{"Create Ada's account", "Book a desk"}) {
+		t.Errorf("unexpected checklist: %v", rendered.Checklist)
+	}
+	if !reflect.DeepEqual(rendered.Labels, []string{"onboarding", "api"}) {
+		t.Errorf("unexpected labels: %v", rendered.Labels)
+	}
+	if blueprint.Checklist[0] != "Create {{name}}'s account" {
+		t.Error("rendering changed the template")
+	}
+
+	if _, err := RenderBlueprint(blueprint, map[string]string{"name": "Ada"}); err == nil {
+		t.Error("expected an error for the missing team variable")
+	}
+
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	if due := DueDate(blueprint, start); due == nil || !due.Equal(start.Add(48*time.Hour)) {
+		t.Errorf("unexpected due date: %v", due)
+	}
+}
+
+func TestFromTask(t *testing.T) {
+	created := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	due := created.Add(72 * time.Hour)
+	task := &types.Task{Title: "Ship", Priority: 1, DueDate: &due, CreatedAt: created, Labels: []string{"release"}}
+
+	blueprint := FromTask(task, []types.ChecklistItem{{Text: "Tag"}, {Text: "Announce"}})
+
+	if blueprint.DueOffsetHours == nil || *blueprint.DueOffsetHours != 72 {
+		t.Errorf("unexpected due offset: %v", blueprint.DueOffsetHours)
+	}
+	if !reflect.DeepEqual(blueprint.Checklist, []string{"Tag", "Announce"}) {
+		t.Errorf("unexpected checklist: %v", blueprint.Checklist)
+	}
+	if !reflect.DeepEqual(blueprint.Labels, []string{"release"}) {
+		t.Errorf("unexpected labels: %v", blueprint.Labels)
+	}
+}
@@ -0,0 +1,368 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"todo/errs"
+	"todo/services/auth"
+	"todo/services/customfield"
+	"todo/services/history"
+	"todo/services/notification"
+	"todo/services/workflow"
+	"todo/services/workspace"
+	"todo/types"
+	"todo/utils"
+
+	"github.com/gorilla/mux"
+)
+
+var errRequiredFields = errs.Conflict("required_custom_fields", "the owner has required custom fields")
+
+type Handler struct {
+	store          types.TemplateStore
+	taskStore      types.TaskStore
+	checklistStore types.ChecklistStore
+	workflowStore  types.WorkflowStore
+	fieldStore     types.CustomFieldStore
+	eventStore     types.TaskEventStore
+	userStore      types.UserStore
+	workspaceStore types.WorkspaceStore
+	notifier       *notification.Notifier
+}
+
+func NewHandler(store types.TemplateStore, taskStore types.TaskStore, checklistStore types.ChecklistStore, workflowStore types.WorkflowStore, fieldStore types.CustomFieldStore, eventStore types.TaskEventStore, userStore types.UserStore, workspaceStore types.WorkspaceStore, notifier *notification.Notifier) *Handler {
+	return &Handler{store: store, taskStore: taskStore, checklistStore: checklistStore, workflowStore: workflowStore, fieldStore: fieldStore, eventStore: eventStore, userStore: userStore, workspaceStore: workspaceStore, notifier: notifier}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/templates", auth.WithJWTAuth(workspace.WithWorkspace(h.handleGetTemplates, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/templates", auth.WithJWTAuth(workspace.WithWorkspace(h.handleCreateTemplate, h.workspaceStore), h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/templates/{template_id}", auth.WithJWTAuth(workspace.WithWorkspace(h.handleGetTemplate, h.workspaceStore), h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/templates/{template_id}", auth.WithJWTAuth(workspace.WithWorkspace(h.handleUpdateTemplate, h.workspaceStore), h.userStore)).Methods(http.MethodPut)
+	router.HandleFunc("/templates/{template_id}", auth.WithJWTAuth(workspace.WithWorkspace(h.handleDeleteTemplate, h.workspaceStore), h.userStore)).Methods(http.MethodDelete)
+	router.HandleFunc("/templates/{template_id}/instantiate", auth.WithJWTAuth(workspace.WithWorkspace(h.handleInstantiate, h.workspaceStore), h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/tasks/{task_id}/template", auth.WithJWTAuth(workspace.WithWorkspace(h.handleSaveAsTemplate, h.workspaceStore), h.userStore)).Methods(http.MethodPost)
+}
+
+func (h *Handler) handleGetTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.store.GetTemplates(r.Context(), workspace.IDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to get templates: %v", err))
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, templates)
+}
+
+func (h *Handler) handleCreateTemplate(w http.ResponseWriter, r *http.Request) {
+	var payload types.TemplatePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	h.createTemplate(w, r, types.TaskTemplate{
+		WorkspaceID: workspace.IDFromContext(r.Context()),
+		Name:        payload.Name,
+		Tasks:       payload.Tasks,
+		CreatedBy:   &userID,
+	})
+}
+
+func (h *Handler) handleGetTemplate(w http.ResponseWriter, r *http.Request) {
+	template, ok := h.getTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, template)
+}
+
+func (h *Handler) handleUpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	template, ok := h.getTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	var payload types.TemplatePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	template.Name = payload.Name
+	template.Tasks = payload.Tasks
+	if err := h.store.UpdateTemplate(r.Context(), *template); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	updated, err := h.store.GetTemplateByID(r.Context(), template.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, updated)
+}
+
+func (h *Handler) handleDeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID, err := strconv.Atoi(mux.Vars(r)["template_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid template ID"))
+		return
+	}
+
+	rowsAffected, err := h.store.DeleteTemplate(r.Context(), workspace.IDFromContext(r.Context()), templateID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to delete template: %v", err))
+		return
+	}
+	if rowsAffected == 0 {
+		utils.WriteError(w, http.StatusNotFound, ErrTemplateNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleInstantiate creates one task per blueprint in the selected workspace. Every blueprint is
+// rendered and checked before the first task is created, so bad variables create nothing, and the
+// tasks are created together or not at all.
+func (h *Handler) handleInstantiate(w http.ResponseWriter, r *http.Request) {
+	template, ok := h.getTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	var payload types.InstantiateTemplatePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	owner := &userID
+	if payload.UserID != nil {
+		owner = payload.UserID
+	}
+	start := time.Now()
+	if payload.StartAt != nil {
+		start = *payload.StartAt
+	}
+
+	member, err := h.workspaceStore.GetMember(r.Context(), template.WorkspaceID, *owner)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if member == nil {
+		utils.WriteError(w, http.StatusNotFound, workspace.ErrNotMember.Errorf("user %d not found in this workspace", *owner))
+		return
+	}
+
+	wf, err := h.workflowStore.GetWorkflow(r.Context(), owner)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// template tasks carry no custom field values, which only works without required fields
+	fields, err := h.fieldStore.GetCustomFields(r.Context(), *owner)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if _, err := customfield.Validate(fields, nil); err != nil {
+		utils.WriteError(w, http.StatusConflict, errRequiredFields.Errorf("%v, template tasks carry no custom field values", err))
+		return
+	}
+
+	blueprints := make([]types.TaskBlueprint, len(template.Tasks))
+	for i, blueprint := range template.Tasks {
+		rendered, err := RenderBlueprint(blueprint, payload.Variables)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := utils.Validate.Struct(rendered); err != nil {
+			invalid := utils.ValidationError(r, err)
+			invalid.Message = fmt.Sprintf("invalid task %d after rendering", i+1)
+			for j := range invalid.Fields {
+				invalid.Fields[j].Field = fmt.Sprintf("tasks[%d].%s", i, invalid.Fields[j].Field)
+			}
+			utils.WriteError(w, http.StatusBadRequest, invalid)
+			return
+		}
+		blueprints[i] = rendered
+	}
+
+	payloads := make([]types.CreateTaskPayload, len(blueprints))
+	for i, blueprint := range blueprints {
+		payloads[i] = types.CreateTaskPayload{
+			UserID:      owner,
+			Title:       blueprint.Title,
+			Description: &blueprint.Description,
+			Status:      workflow.InitialStatus(wf),
+			Priority:    blueprint.Priority,
+			DueDate:     DueDate(blueprint, start),
+			Labels:      blueprint.Labels,
+			CreatorID:   userID,
+			WorkspaceID: workspace.IDFromContext(r.Context()),
+		}
+	}
+
+	// a full column with a reject limit fails the whole template with 409
+	created, err := h.taskStore.CreateTasks(r.Context(), payloads)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := h.addChecklists(r, created, blueprints); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	tasks := make([]types.Task, len(created))
+	for i := range created {
+		// reloaded for the checklist summary
+		task, err := h.taskStore.GetTaskByID(r.Context(), created[i].ID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		tasks[i] = *task
+
+		event := history.NewTaskEvent(history.ActionCreate, userID, nil, task)
+		if err := h.eventStore.CreateTaskEvent(r.Context(), event); err != nil {
+			log.Printf("failed to record task event: %v", err)
+		}
+		h.notifier.NotifyTaskEvent(event)
+	}
+
+	utils.WriteJson(w, http.StatusCreated, tasks)
+}
+
+// handleSaveAsTemplate creates a single-task template from an existing task and its checklist.
+func (h *Handler) handleSaveAsTemplate(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.Atoi(mux.Vars(r)["task_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid task ID"))
+		return
+	}
+
+	task, err := h.taskStore.GetTaskByID(r.Context(), taskID)
+	if errors.Is(err, types.ErrTaskNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !workspace.Contains(r.Context(), task) {
+		utils.WriteError(w, http.StatusNotFound, types.ErrTaskNotFound)
+		return
+	}
+
+	var payload types.SaveAsTemplatePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	checklist, err := h.checklistStore.GetChecklistItems(r.Context(), task.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	userID := auth.GetUserIDFromContext(r.Context())
+	h.createTemplate(w, r, types.TaskTemplate{
+		WorkspaceID: task.WorkspaceID,
+		Name:        payload.Name,
+		Tasks:       []types.TaskBlueprint{FromTask(task, checklist)},
+		CreatedBy:   &userID,
+	})
+}
+
+// addChecklists adds the checklist of each blueprint to the task created from it. Checklist items
+// live in their own store, so on failure the new tasks are purged again.
+func (h *Handler) addChecklists(r *http.Request, tasks []types.Task, blueprints []types.TaskBlueprint) error {
+	for i, blueprint := range blueprints {
+		for _, text := range blueprint.Checklist {
+			if _, err := h.checklistStore.CreateChecklistItem(r.Context(), tasks[i].ID, text); err != nil {
+				for _, task := range tasks {
+					if _, err := h.taskStore.PurgeTask(r.Context(), task.ID); err != nil {
+						log.Printf("failed to purge task %d of a failed template: %v", task.ID, err)
+					}
+				}
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (h *Handler) createTemplate(w http.ResponseWriter, r *http.Request, template types.TaskTemplate) {
+	templateID, err := h.store.CreateTemplate(r.Context(), template)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	created, err := h.store.GetTemplateByID(r.Context(), templateID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusCreated, created)
+}
+
+// getTemplate resolves the {template_id} route variable, writing the error response itself on failure.
+// Templates of other workspaces are reported as not found.
+func (h *Handler) getTemplate(w http.ResponseWriter, r *http.Request) (*types.TaskTemplate, bool) {
+	templateID, err := strconv.Atoi(mux.Vars(r)["template_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid template ID"))
+		return nil, false
+	}
+
+	template, err := h.store.GetTemplateByID(r.Context(), templateID)
+	if errors.Is(err, ErrTemplateNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return nil, false
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	if template.WorkspaceID != workspace.IDFromContext(r.Context()) {
+		utils.WriteError(w, http.StatusNotFound, ErrTemplateNotFound)
+		return nil, false
+	}
+
+	return template, true
+}
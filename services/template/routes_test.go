@@ -0,0 +1,179 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"todo/errs"
+	"todo/services/assignment"
+	"todo/services/auth"
+	"todo/services/board"
+	"todo/services/checklist"
+	"todo/services/customfield"
+	"todo/services/history"
+	"todo/services/notification"
+	"todo/services/storetest"
+	"todo/services/task"
+	"todo/services/workflow"
+	"todo/services/workspace"
+	"todo/types"
+
+	"github.com/gorilla/mux"
+)
+
+// memberStore makes everyone a member of every workspace.
+type memberStore struct {
+	types.WorkspaceStore
+}
+
+func (memberStore) GetMember(ctx context.Context, workspaceID, userID int) (*types.WorkspaceMember, error) {
+	return &types.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: workspace.RoleMember}, nil
+}
+
+// failingChecklists fails to add the item with the given text.
+type failingChecklists struct {
+	types.ChecklistStore
+	text string
+}
+
+func (s failingChecklists) CreateChecklistItem(ctx context.Context, taskID int, text string) (int, error) {
+	if text == s.text {
+		return 0, errors.New("driver: bad connection")
+	}
+	return s.ChecklistStore.CreateChecklistItem(ctx, taskID, text)
+}
+
+// failingStore fails to look up templates.
+type failingStore struct {
+	types.TemplateStore
+	err error
+}
+
+func (s failingStore) GetTemplateByID(ctx context.Context, templateID int) (*types.TaskTemplate, error) {
+	return nil, s.err
+}
+
+func TestHandleGetTemplateErrors(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.SQLite(t)
+	_, workspaceIDs := storetest.Seed(t, db)
+	store := NewStore(db)
+	foreign, err := store.CreateTemplate(ctx, types.TaskTemplate{
+		WorkspaceID: workspaceIDs[1],
+		Name:        "Not this workspace's",
+		Tasks:       []types.TaskBlueprint{{Title: "Secret", Priority: 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		store      types.TemplateStore
+		templateID int
+		wantStatus int
+	}{
+		{"not found", failingStore{err: ErrTemplateNotFound}, 1, http.StatusNotFound},
+		{"timeout", failingStore{err: errs.ErrQueryTimeout}, 1, http.StatusGatewayTimeout},
+		{"database error", failingStore{err: errors.New("driver: bad connection")}, 1, http.StatusInternalServerError},
+		{"of another workspace", store, foreign, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(tt.store, nil, nil, nil, nil, nil, nil, memberStore{}, nil)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(workspace.Header, strconv.Itoa(workspaceIDs[0]))
+			r = mux.SetURLVars(r, map[string]string{"template_id": strconv.Itoa(tt.templateID)})
+			r = r.WithContext(context.WithValue(r.Context(), auth.UserKey, 1))
+			w := httptest.NewRecorder()
+			workspace.WithWorkspace(h.handleGetTemplate, memberStore{})(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHandleInstantiate(t *testing.T) {
+	ctx := context.Background()
+	db := storetest.SQLite(t)
+	userIDs, workspaceIDs := storetest.Seed(t, db)
+	owner := userIDs[0]
+
+	templates := NewStore(db)
+	templateID, err := templates.CreateTemplate(ctx, types.TaskTemplate{
+		WorkspaceID: workspaceIDs[0],
+		Name:        "Onboarding",
+		Tasks: []types.TaskBlueprint{
+			{Title: "Laptop for {{name}}", Priority: 2, Checklist: []string{"order", "set up"}},
+			{Title: "Accounts for {{name}}", Priority: 2, Checklist: []string{"mail", "chat"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tasks := task.NewStore(db)
+	column := types.BoardColumn{UserID: &owner, Status: "pending"}
+	instantiate := func(checklists types.ChecklistStore) *httptest.ResponseRecorder {
+		h := NewHandler(templates, tasks, checklists, workflow.NewStore(db), customfield.NewStore(db), history.NewStore(db),
+			nil, memberStore{}, notification.NewNotifier(assignment.NewStore(db), nil))
+
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"variables":{"name":"Ada"}}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set(workspace.Header, strconv.Itoa(workspaceIDs[0]))
+		r = mux.SetURLVars(r, map[string]string{"template_id": strconv.Itoa(templateID)})
+		r = r.WithContext(context.WithValue(r.Context(), auth.UserKey, owner))
+		w := httptest.NewRecorder()
+		workspace.WithWorkspace(h.handleInstantiate, memberStore{})(w, r)
+
+		return w
+	}
+	count := func() int {
+		n, err := tasks.CountColumnTasks(context.Background(), column)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	limits := board.NewStore(db)
+	if err := limits.SetWIPLimit(ctx, types.WIPLimit{UserID: owner, Status: "pending", MaxTasks: 1, Mode: board.ModeReject}); err != nil {
+		t.Fatal(err)
+	}
+	if w := instantiate(checklist.NewStore(db)); w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "wip_limit_reached") {
+		t.Errorf("into a column with room for one task: %d %s, want 409", w.Code, w.Body)
+	}
+	if n := count(); n != 0 {
+		t.Errorf("%d tasks left by the rejected template, want 0", n)
+	}
+
+	if err := limits.SetWIPLimit(ctx, types.WIPLimit{UserID: owner, Status: "pending", MaxTasks: 10, Mode: board.ModeReject}); err != nil {
+		t.Fatal(err)
+	}
+	if w := instantiate(failingChecklists{checklist.NewStore(db), "chat"}); w.Code != http.StatusInternalServerError {
+		t.Errorf("with a failing checklist: %d %s, want 500", w.Code, w.Body)
+	}
+	if n := count(); n != 0 {
+		t.Errorf("%d tasks left by the failed template, want 0", n)
+	}
+
+	w := instantiate(checklist.NewStore(db))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("got %d %s, want 201", w.Code, w.Body)
+	}
+	if n := count(); n != 2 {
+		t.Errorf("%d tasks created, want 2", n)
+	}
+	if !strings.Contains(w.Body.String(), `"title":"Laptop for Ada"`) || !strings.Contains(w.Body.String(), `"checklist":{"done":0,"total":2}`) {
+		t.Errorf("created tasks = %s", w.Body)
+	}
+}
@@ -0,0 +1,140 @@
+package template
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/types"
+)
+
+var ErrTemplateNotFound = errs.NotFound("template_not_found", "template not found")
+
+const templateColumns = `id, workspace_id, name, tasks, created_by, created_at, updated_at`
+
+type Store struct {
+	db *dialect.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: dialect.Wrap(db)}
+}
+
+func (s *Store) GetTemplates(ctx context.Context, workspaceID int) ([]types.TaskTemplate, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+templateColumns+" FROM task_templates WHERE workspace_id = ? ORDER BY name, id", workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []types.TaskTemplate{}
+	for rows.Next() {
+		t, err := scanRowsIntoTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *t)
+	}
+
+	return templates, errs.FromContext(ctx, rows.Err())
+}
+
+func (s *Store) GetTemplateByID(ctx context.Context, templateID int) (*types.TaskTemplate, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+templateColumns+" FROM task_templates WHERE id = ?", templateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	t := new(types.TaskTemplate)
+	for rows.Next() {
+		t, err = scanRowsIntoTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
+
+	if t.ID == 0 {
+		return nil, ErrTemplateNotFound
+	}
+
+	return t, nil
+}
+
+func (s *Store) CreateTemplate(ctx context.Context, template types.TaskTemplate) (int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tasks, err := json.Marshal(template.Tasks)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := s.db.InsertContext(ctx,
+		"INSERT INTO task_templates (workspace_id, name, tasks, created_by) VALUES (?, ?, ?, ?)",
+		template.WorkspaceID, template.Name, string(tasks), template.CreatedBy)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s *Store) UpdateTemplate(ctx context.Context, template types.TaskTemplate) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tasks, err := json.Marshal(template.Tasks)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, "UPDATE task_templates SET name = ?, tasks = ? WHERE id = ?", template.Name, string(tasks), template.ID)
+	return err
+}
+
+func (s *Store) DeleteTemplate(ctx context.Context, workspaceID, templateID int) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM task_templates WHERE id = ? AND workspace_id = ?", templateID, workspaceID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func scanRowsIntoTemplate(rows *sql.Rows) (*types.TaskTemplate, error) {
+	t := new(types.TaskTemplate)
+	var tasks []byte
+
+	err := rows.Scan(
+		&t.ID,
+		&t.WorkspaceID,
+		&t.Name,
+		&tasks,
+		&t.CreatedBy,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(tasks, &t.Tasks); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
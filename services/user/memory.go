@@ -0,0 +1,92 @@
+package user
+
+import (
+	"context"
+	"sync"
+	"time"
+	"todo/errs"
+	"todo/types"
+)
+
+// MemoryStore keeps users in memory, for tests and the demo mode. It is safe for concurrent use.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	users    []types.User
+	nextID   int
+	onCreate []func(context.Context, types.User) error
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{nextID: 1}
+}
+
+// OnCreate registers a hook that runs with every new user, ID included, before it is stored.
+// An error from the hook fails CreateUser.
+func (s *MemoryStore) OnCreate(hook func(context.Context, types.User) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onCreate = append(s.onCreate, hook)
+}
+
+func (s *MemoryStore) GetUserByID(ctx context.Context, userID int) (*types.User, error) {
+	if err := errs.FromContext(ctx, ctx.Err()); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.ID == userID {
+			return &u, nil
+		}
+	}
+
+	return nil, types.ErrUserNotFound
+}
+
+func (s *MemoryStore) GetUserByEmail(ctx context.Context, email string) (*types.User, error) {
+	if err := errs.FromContext(ctx, ctx.Err()); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+
+	return nil, types.ErrUserNotFound
+}
+
+// CreateUser fails for an email that is taken, like the unique key of the users table.
+func (s *MemoryStore) CreateUser(ctx context.Context, user types.User) error {
+	if err := errs.FromContext(ctx, ctx.Err()); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Email == user.Email {
+			return errEmailTaken.Errorf("user with email %s already exists", user.Email)
+		}
+	}
+
+	user.ID = s.nextID
+	user.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	for _, hook := range s.onCreate {
+		if err := hook(ctx, user); err != nil {
+			return err
+		}
+	}
+	s.users = append(s.users, user)
+	s.nextID++
+
+	return nil
+}
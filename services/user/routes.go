@@ -1,31 +1,39 @@
 package user
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
 	"strconv"
 	"todo/configs"
+	"todo/errs"
 	"todo/services/auth"
+	"todo/services/idempotency"
 	"todo/types"
 	"todo/utils"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 )
 
+var (
+	errInvalidLogin = errs.Validation("invalid_credentials", "invalid email or password")
+	errEmailTaken   = errs.Validation("email_taken", "user with this email already exists")
+)
+
 type Handler struct {
-	store types.UserStore
+	store    types.UserStore
+	keyStore types.IdempotencyStore
 }
 
-func NewHandler(store types.UserStore) *Handler {
+func NewHandler(store types.UserStore, keyStore types.IdempotencyStore) *Handler {
 	return &Handler{
-		store: store,
+		store:    store,
+		keyStore: keyStore,
 	}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/login", h.handleLogin).Methods("POST")
-	router.HandleFunc("/register", h.handleRegister).Methods("POST")
+	router.HandleFunc("/register", idempotency.WithIdempotencyKey(h.handleRegister, h.keyStore)).Methods("POST")
 
 	// admin routes
 	router.HandleFunc("users/{userID}", auth.WithJWTAuth(h.handleGetUser, h.store)).Methods(http.MethodGet)
@@ -39,19 +47,22 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := utils.Validate.Struct(user); err != nil {
-		errors := err.(validator.ValidationErrors)
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid syntax: %v", errors))
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
 		return
 	}
 
-	u, err := h.store.GetUserByEmail(user.Email)
+	u, err := h.store.GetUserByEmail(r.Context(), user.Email)
+	if errors.Is(err, types.ErrUserNotFound) {
+		utils.WriteError(w, http.StatusBadRequest, errInvalidLogin.Errorf("not found, invalid email or password"))
+		return
+	}
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("not found, invalid email or password"))
+		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	if !auth.ComparePasswords(u.Password, []byte(user.Password)) {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid email or password"))
+		utils.WriteError(w, http.StatusBadRequest, errInvalidLogin)
 		return
 	}
 
@@ -73,15 +84,18 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := utils.Validate.Struct(user); err != nil {
-		errors := err.(validator.ValidationErrors)
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid payload: %v", errors))
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
 		return
 	}
 
 	// check if user exists
-	_, err := h.store.GetUserByEmail(user.Email)
+	_, err := h.store.GetUserByEmail(r.Context(), user.Email)
 	if err == nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user with email %s already exists", user.Email))
+		utils.WriteError(w, http.StatusBadRequest, errEmailTaken.Errorf("user with email %s already exists", user.Email))
+		return
+	}
+	if !errors.Is(err, types.ErrUserNotFound) {
+		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -92,7 +106,7 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.store.CreateUser(types.User{
+	err = h.store.CreateUser(r.Context(), types.User{
 		FirstName: user.FirstName,
 		LastName:  user.LastName,
 		Email:     user.Email,
@@ -110,16 +124,21 @@ func (h *Handler) handleGetUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	str, ok := vars["userID"]
 	if !ok {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("missing user ID"))
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("missing user ID"))
 		return
 	}
 
 	userID, err := strconv.Atoi(str)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid user ID"))
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid user ID"))
+		return
 	}
 
-	user, err := h.store.GetUserByID(userID)
+	user, err := h.store.GetUserByID(r.Context(), userID)
+	if errors.Is(err, types.ErrUserNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
@@ -0,0 +1,81 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo/types"
+
+	"github.com/gorilla/mux"
+)
+
+// failingStore fails to look up users.
+type failingStore struct {
+	types.UserStore
+	err error
+}
+
+func (s failingStore) GetUserByID(ctx context.Context, userID int) (*types.User, error) {
+	return nil, s.err
+}
+
+func (s failingStore) GetUserByEmail(ctx context.Context, email string) (*types.User, error) {
+	return nil, s.err
+}
+
+func TestHandleGetUserErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		store      types.UserStore
+		userID     string
+		wantStatus int
+	}{
+		{"invalid ID", NewMemoryStore(), "ada", http.StatusBadRequest},
+		{"not found", NewMemoryStore(), "1", http.StatusNotFound},
+		{"database error", failingStore{err: errors.New("driver: bad connection")}, "1", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(tt.store, nil)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r = mux.SetURLVars(r, map[string]string{"userID": tt.userID})
+			w := httptest.NewRecorder()
+			h.handleGetUser(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHandleLoginErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		store      types.UserStore
+		wantStatus int
+	}{
+		{"unknown email", NewMemoryStore(), http.StatusBadRequest},
+		{"database error", failingStore{err: errors.New("driver: bad connection")}, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(tt.store, nil)
+
+			r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"email":"ada@example.com","password":"secret"}`))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			h.handleLogin(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+		})
+	}
+}
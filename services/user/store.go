@@ -1,26 +1,32 @@
 package user
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
+	"todo/db/dialect"
+	"todo/errs"
 	"todo/types"
 )
 
 type Store struct {
-	db *sql.DB
+	db *dialect.DB
 }
 
 func NewStore(db *sql.DB) *Store {
 	return &Store{
-		db: db,
+		db: dialect.Wrap(db),
 	}
 }
 
-func (s *Store) GetUserByID(userID int) (*types.User, error) {
-	rows, err := s.db.Query("SELECT * FROM users WHERE id = ?", userID)
+func (s *Store) GetUserByID(ctx context.Context, userID int) (*types.User, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT * FROM users WHERE id = ?", userID)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	u := new(types.User)
 	for rows.Next() {
@@ -29,19 +35,26 @@ func (s *Store) GetUserByID(userID int) (*types.User, error) {
 			return nil, err
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
 
 	if u.ID == 0 {
-		return nil, fmt.Errorf("user not found")
+		return nil, types.ErrUserNotFound
 	}
 
 	return u, nil
 }
 
-func (s *Store) GetUserByEmail(email string) (*types.User, error) {
-	rows, err := s.db.Query("SELECT * FROM users WHERE email = ?", email)
+func (s *Store) GetUserByEmail(ctx context.Context, email string) (*types.User, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT * FROM users WHERE email = ?", email)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	u := new(types.User)
 	for rows.Next() {
@@ -50,9 +63,12 @@ func (s *Store) GetUserByEmail(email string) (*types.User, error) {
 			return nil, err
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
 
 	if u.ID == 0 {
-		return nil, fmt.Errorf("user not found")
+		return nil, types.ErrUserNotFound
 	}
 
 	return u, nil
@@ -76,11 +92,27 @@ func scanRowsIntoUser(rows *sql.Rows) (*types.User, error) {
 	return user, nil
 }
 
-func (s *Store) CreateUser(user types.User) error {
-	_, err := s.db.Exec("INSERT INTO users (firstName, lastName, email, password) VALUES (?, ?, ?, ?)", user.FirstName, user.LastName, user.Email, user.Password)
+func (s *Store) CreateUser(ctx context.Context, user types.User) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "INSERT INTO users (firstName, lastName, email, password) VALUES (?, ?, ?, ?)", user.FirstName, user.LastName, user.Email, user.Password)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// CopyUser inserts a user that is kept by another store with the same ID, so that the
+// tables joining users work with it.
+func (s *Store) CopyUser(ctx context.Context, user types.User) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO users (id, firstName, lastName, email, password) VALUES (?, ?, ?, ?, ?)",
+		user.ID, user.FirstName, user.LastName, user.Email, user.Password)
+
+	return err
+}
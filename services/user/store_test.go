@@ -0,0 +1,31 @@
+package user
+
+import (
+	"testing"
+	"todo/services/storetest"
+	"todo/types"
+)
+
+func TestStore(t *testing.T) {
+	storetest.TestUserStore(t, func(t *testing.T) types.UserStore {
+		return NewStore(storetest.SQLite(t))
+	})
+}
+
+func TestMySQLStore(t *testing.T) {
+	storetest.TestUserStore(t, func(t *testing.T) types.UserStore {
+		return NewStore(storetest.MySQL(t))
+	})
+}
+
+func TestPostgresStore(t *testing.T) {
+	storetest.TestUserStore(t, func(t *testing.T) types.UserStore {
+		return NewStore(storetest.Postgres(t))
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	storetest.TestUserStore(t, func(t *testing.T) types.UserStore {
+		return NewMemoryStore()
+	})
+}
@@ -0,0 +1,218 @@
+package workflow
+
+import (
+	"net/http"
+	"todo/services/auth"
+	"todo/types"
+	"todo/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	store     types.WorkflowStore
+	userStore types.UserStore
+}
+
+func NewHandler(store types.WorkflowStore, userStore types.UserStore) *Handler {
+	return &Handler{store: store, userStore: userStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/workflow", auth.WithJWTAuth(h.handleGetWorkflow, h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/workflow/statuses", auth.WithJWTAuth(h.handleCreateStatus, h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/workflow/statuses/{name}", auth.WithJWTAuth(h.handleUpdateStatus, h.userStore)).Methods(http.MethodPut)
+	router.HandleFunc("/workflow/statuses/{name}", auth.WithJWTAuth(h.handleDeleteStatus, h.userStore)).Methods(http.MethodDelete)
+	router.HandleFunc("/workflow/transitions", auth.WithJWTAuth(h.handleSetTransitions, h.userStore)).Methods(http.MethodPut)
+}
+
+func (h *Handler) handleGetWorkflow(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	wf, err := h.store.GetWorkflow(r.Context(), &userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, wf)
+}
+
+func (h *Handler) handleCreateStatus(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	payload, ok := parseStatusPayload(w, r)
+	if !ok {
+		return
+	}
+
+	wf, err := h.store.GetWorkflow(r.Context(), &userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if FindStatus(wf, payload.Name) != nil {
+		utils.WriteError(w, http.StatusConflict, errStatusExists.Errorf("status %s already exists", payload.Name))
+		return
+	}
+
+	err = h.store.CreateStatus(r.Context(), types.TaskStatus{
+		UserID:   &userID,
+		Name:     payload.Name,
+		Category: payload.Category,
+		Color:    colorOrDefault(payload.Color),
+		Order:    payload.Order,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.writeWorkflow(w, r, userID, http.StatusCreated)
+}
+
+func (h *Handler) handleUpdateStatus(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	name := mux.Vars(r)["name"]
+
+	payload, ok := parseStatusPayload(w, r)
+	if !ok {
+		return
+	}
+
+	wf, err := h.store.GetWorkflow(r.Context(), &userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if FindStatus(wf, name) == nil {
+		utils.WriteError(w, http.StatusNotFound, errStatusNotFound.Errorf("status %s not found", name))
+		return
+	}
+	if payload.Name != name && FindStatus(wf, payload.Name) != nil {
+		utils.WriteError(w, http.StatusConflict, errStatusExists.Errorf("status %s already exists", payload.Name))
+		return
+	}
+
+	err = h.store.UpdateStatus(r.Context(), userID, name, types.TaskStatus{
+		Name:     payload.Name,
+		Category: payload.Category,
+		Color:    colorOrDefault(payload.Color),
+		Order:    payload.Order,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.writeWorkflow(w, r, userID, http.StatusOK)
+}
+
+func (h *Handler) handleDeleteStatus(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+	name := mux.Vars(r)["name"]
+
+	wf, err := h.store.GetWorkflow(r.Context(), &userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if FindStatus(wf, name) == nil {
+		utils.WriteError(w, http.StatusNotFound, errStatusNotFound.Errorf("status %s not found", name))
+		return
+	}
+	if len(wf.Statuses) == 1 {
+		utils.WriteError(w, http.StatusConflict, errLastStatus)
+		return
+	}
+
+	count, err := h.store.CountTasksWithStatus(r.Context(), userID, name)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if count > 0 {
+		utils.WriteError(w, http.StatusConflict, errStatusInUse.Errorf("status %s is still used by %d tasks", name, count))
+		return
+	}
+
+	if err := h.store.DeleteStatus(r.Context(), userID, name); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSetTransitions replaces the allowed transitions, an empty list allows every transition.
+func (h *Handler) handleSetTransitions(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	var payload types.SetTransitionsPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	wf, err := h.store.GetWorkflow(r.Context(), &userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	for _, t := range payload.Transitions {
+		if FindStatus(wf, t.From) == nil || FindStatus(wf, t.To) == nil {
+			utils.WriteError(w, http.StatusBadRequest, ErrUnknownStatus.Errorf("unknown status in transition %s -> %s", t.From, t.To))
+			return
+		}
+	}
+
+	if err := h.store.SetTransitions(r.Context(), userID, payload.Transitions); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.writeWorkflow(w, r, userID, http.StatusOK)
+}
+
+func (h *Handler) writeWorkflow(w http.ResponseWriter, r *http.Request, userID int, status int) {
+	wf, err := h.store.GetWorkflow(r.Context(), &userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, status, wf)
+}
+
+func parseStatusPayload(w http.ResponseWriter, r *http.Request) (*types.TaskStatusPayload, bool) {
+	var payload types.TaskStatusPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return nil, false
+	}
+
+	if !statusNamePattern.MatchString(payload.Name) {
+		utils.WriteError(w, http.StatusBadRequest, errInvalidStatusName)
+		return nil, false
+	}
+
+	return &payload, true
+}
+
+func colorOrDefault(color string) string {
+	if color == "" {
+		return "#9e9e9e"
+	}
+
+	return color
+}
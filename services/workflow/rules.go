@@ -0,0 +1,77 @@
+package workflow
+
+import (
+	"regexp"
+	"todo/errs"
+	"todo/types"
+)
+
+const (
+	CategoryTodo  = "todo"
+	CategoryDoing = "doing"
+	CategoryDone  = "done"
+)
+
+var statusNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+var (
+	errStatusNotFound       = errs.NotFound("status_not_found", "status not found")
+	ErrUnknownStatus        = errs.Validation("unknown_status", "unknown status")
+	ErrTransitionNotAllowed = errs.Validation("transition_not_allowed", "transition is not allowed")
+	errStatusExists         = errs.Conflict("status_exists", "status already exists")
+	errStatusInUse          = errs.Conflict("status_in_use", "status is still used by tasks")
+	errLastStatus           = errs.Conflict("last_status", "a workflow needs at least one status")
+	errInvalidStatusName    = errs.Validation("invalid_status_name", "status names use lowercase letters, digits and underscores")
+)
+
+func FindStatus(wf *types.Workflow, name string) *types.TaskStatus {
+	for i := range wf.Statuses {
+		if wf.Statuses[i].Name == name {
+			return &wf.Statuses[i]
+		}
+	}
+
+	return nil
+}
+
+// FirstStatusIn returns the first status of the category in workflow order, "" if there is none.
+func FirstStatusIn(wf *types.Workflow, category string) string {
+	for _, s := range wf.Statuses {
+		if s.Category == category {
+			return s.Name
+		}
+	}
+
+	return ""
+}
+
+// InitialStatus is the status new tasks get when none is given.
+func InitialStatus(wf *types.Workflow) string {
+	if name := FirstStatusIn(wf, CategoryTodo); name != "" {
+		return name
+	}
+	if len(wf.Statuses) > 0 {
+		return wf.Statuses[0].Name
+	}
+
+	return ""
+}
+
+// ValidateStatusChange checks that to exists in the workflow and may follow
+// from. An empty from skips the transition rules, e.g. when a task changes owner.
+func ValidateStatusChange(wf *types.Workflow, from, to string) error {
+	if FindStatus(wf, to) == nil {
+		return ErrUnknownStatus.Errorf("unknown status: %s", to)
+	}
+	if from == "" || from == to || len(wf.Transitions) == 0 {
+		return nil
+	}
+
+	for _, t := range wf.Transitions {
+		if t.From == from && t.To == to {
+			return nil
+		}
+	}
+
+	return ErrTransitionNotAllowed.Errorf("transition from %s to %s is not allowed", from, to)
+}
@@ -0,0 +1,59 @@
+package workflow
+
+import (
+	"testing"
+	"todo/types"
+)
+
+func TestValidateStatusChange(t *testing.T) {
+	wf := &types.Workflow{
+		Statuses: []types.TaskStatus{
+			{Name: "backlog", Category: CategoryTodo},
+			{Name: "review", Category: CategoryDoing},
+			{Name: "shipped", Category: CategoryDone},
+		},
+		Transitions: []types.StatusTransition{
+			{From: "backlog", To: "review"},
+			{From: "review", To: "shipped"},
+		},
+	}
+
+	cases := []struct {
+		from, to string
+		ok       bool
+	}{
+		{"", "backlog", true},
+		{"", "unknown", false},
+		{"backlog", "review", true},
+		{"backlog", "backlog", true},
+		{"backlog", "shipped", false},
+		{"shipped", "backlog", false},
+	}
+
+	for _, c := range cases {
+		err := ValidateStatusChange(wf, c.from, c.to)
+		if (err == nil) != c.ok {
+			t.Errorf("ValidateStatusChange(%q, %q) = %v, want ok=%v", c.from, c.to, err, c.ok)
+		}
+	}
+
+	wf.Transitions = nil
+	if err := ValidateStatusChange(wf, "shipped", "backlog"); err != nil {
+		t.Errorf("without transitions every change should be allowed, got %v", err)
+	}
+}
+
+func TestInitialStatus(t *testing.T) {
+	wf := &types.Workflow{Statuses: []types.TaskStatus{
+		{Name: "triage", Category: CategoryDoing},
+		{Name: "open", Category: CategoryTodo},
+	}}
+	if got := InitialStatus(wf); got != "open" {
+		t.Errorf("InitialStatus() = %q, want open", got)
+	}
+
+	wf.Statuses = wf.Statuses[:1]
+	if got := InitialStatus(wf); got != "triage" {
+		t.Errorf("InitialStatus() = %q, want triage", got)
+	}
+}
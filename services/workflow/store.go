@@ -0,0 +1,243 @@
+package workflow
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/types"
+)
+
+type Store struct {
+	db *dialect.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: dialect.Wrap(db)}
+}
+
+// GetWorkflow returns the user's own workflow, or the default one when the
+// user never customized theirs. A nil userID always yields the default.
+func (s *Store) GetWorkflow(ctx context.Context, userID *int) (*types.Workflow, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	statuses, err := s.getStatuses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := userID
+	if len(statuses) == 0 && userID != nil {
+		owner = nil
+		if statuses, err = s.getStatuses(ctx, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT f.name, t.name
+	FROM task_status_transitions tr
+	JOIN task_statuses f ON f.id = tr.from_status_id
+	JOIN task_statuses t ON t.id = tr.to_status_id
+	WHERE `+s.db.Dialect().NullSafeEqual("f.user_id")+`
+	ORDER BY f.sort_order, t.sort_order`, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transitions := []types.StatusTransition{}
+	for rows.Next() {
+		var t types.StatusTransition
+		if err := rows.Scan(&t.From, &t.To); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, t)
+	}
+
+	return &types.Workflow{UserID: owner, Statuses: statuses, Transitions: transitions}, errs.FromContext(ctx, rows.Err())
+}
+
+func (s *Store) CreateStatus(ctx context.Context, status types.TaskStatus) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := ensureCustomWorkflow(ctx, tx, *status.UserID); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO task_statuses (user_id, name, category, color, sort_order) VALUES (?, ?, ?, ?, ?)",
+		status.UserID, status.Name, status.Category, status.Color, status.Order)
+	if err != nil {
+		return err
+	}
+
+	return errs.FromContext(ctx, tx.Commit())
+}
+
+// UpdateStatus changes the user's status called name, renaming it carries the user's tasks and WIP limit along.
+func (s *Store) UpdateStatus(ctx context.Context, userID int, name string, status types.TaskStatus) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := ensureCustomWorkflow(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE task_statuses SET name = ?, category = ?, color = ?, sort_order = ? WHERE user_id = ? AND name = ?",
+		status.Name, status.Category, status.Color, status.Order, userID, name)
+	if err != nil {
+		return err
+	}
+
+	if status.Name != name {
+		if _, err := tx.ExecContext(ctx, "UPDATE tasks SET status = ? WHERE user_id = ? AND status = ?", status.Name, userID, name); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE board_wip_limits SET status = ? WHERE user_id = ? AND status = ?", status.Name, userID, name); err != nil {
+			return err
+		}
+	}
+
+	return errs.FromContext(ctx, tx.Commit())
+}
+
+func (s *Store) DeleteStatus(ctx context.Context, userID int, name string) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := ensureCustomWorkflow(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM task_statuses WHERE user_id = ? AND name = ?", userID, name); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM board_wip_limits WHERE user_id = ? AND status = ?", userID, name); err != nil {
+		return err
+	}
+
+	return errs.FromContext(ctx, tx.Commit())
+}
+
+// SetTransitions replaces every allowed transition of the user's workflow.
+func (s *Store) SetTransitions(ctx context.Context, userID int, transitions []types.StatusTransition) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := ensureCustomWorkflow(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE tr FROM task_status_transitions tr
+	JOIN task_statuses f ON f.id = tr.from_status_id
+	WHERE f.user_id = ?`, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range transitions {
+		result, err := tx.ExecContext(ctx, `
+		INSERT INTO task_status_transitions (from_status_id, to_status_id)
+		SELECT f.id, t.id FROM task_statuses f, task_statuses t
+		WHERE f.user_id = ? AND f.name = ? AND t.user_id = ? AND t.name = ?`,
+			userID, t.From, userID, t.To)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return fmt.Errorf("unknown status in transition %s -> %s", t.From, t.To)
+		}
+	}
+
+	return errs.FromContext(ctx, tx.Commit())
+}
+
+func (s *Store) CountTasksWithStatus(ctx context.Context, userID int, name string) (int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	var count int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks WHERE user_id = ? AND status = ?", userID, name).Scan(&count)
+
+	return count, err
+}
+
+func (s *Store) getStatuses(ctx context.Context, userID *int) ([]types.TaskStatus, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, user_id, name, category, color, sort_order FROM task_statuses WHERE "+s.db.Dialect().NullSafeEqual("user_id")+" ORDER BY sort_order, id",
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := []types.TaskStatus{}
+	for rows.Next() {
+		var st types.TaskStatus
+		if err := rows.Scan(&st.ID, &st.UserID, &st.Name, &st.Category, &st.Color, &st.Order); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, st)
+	}
+
+	return statuses, errs.FromContext(ctx, rows.Err())
+}
+
+// ensureCustomWorkflow copies the default statuses to the user the first
+// time they customize their workflow, so edits never touch the shared rows.
+func ensureCustomWorkflow(ctx context.Context, tx *dialect.Tx, userID int) error {
+	query := "SELECT COUNT(*) FROM task_statuses WHERE user_id = ?" + tx.Dialect().Lock("FOR UPDATE")
+	if tx.Dialect() == dialect.Postgres {
+		// postgres locks neither aggregates nor gaps, the user's row serializes the first copy instead
+		if _, err := tx.ExecContext(ctx, "SELECT id FROM users WHERE id = ? FOR UPDATE", userID); err != nil {
+			return err
+		}
+		query = "SELECT COUNT(*) FROM task_statuses WHERE user_id = ?"
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+	INSERT INTO task_statuses (user_id, name, category, color, sort_order)
+	SELECT ?, name, category, color, sort_order FROM task_statuses WHERE user_id IS NULL`, userID)
+
+	return err
+}
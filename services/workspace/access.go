@@ -0,0 +1,152 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"todo/errs"
+	"todo/services/auth"
+	"todo/types"
+	"todo/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+	RoleGuest  = "guest"
+)
+
+// Header selects the workspace a task route works in.
+const Header = "X-Workspace-ID"
+
+// defaultName is given to the workspace created for users without any membership.
+const defaultName = "Personal"
+
+var (
+	ErrWorkspaceNotFound  = errs.NotFound("workspace_not_found", "workspace not found")
+	ErrInvitationNotFound = errs.NotFound("invitation_not_found", "invitation not found")
+	ErrNotMember          = errs.NotFound("member_not_found", "user not found in this workspace")
+	errInvalidHeader      = errs.Validation("invalid_workspace_header", "invalid "+Header+" header")
+	errReadOnly           = errs.Forbidden("read_only", "guests have read-only access")
+	errRoleRequired       = errs.Forbidden("role_required", "a higher workspace role is required")
+)
+
+var roleRanks = map[string]int{
+	RoleGuest:  1,
+	RoleMember: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+type contextKey string
+
+const memberKey contextKey = "workspaceMember"
+
+// HasRole reports whether role grants at least the rights of required.
+func HasRole(role, required string) bool {
+	return roleRanks[role] >= roleRanks[required]
+}
+
+// WithWorkspace goes inside auth.WithJWTAuth. It resolves the workspace selected with the
+// X-Workspace-ID header, or the user's default one, and only lets members through.
+// Guests are limited to reads.
+func WithWorkspace(handlerFunc http.HandlerFunc, store types.WorkspaceStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := auth.GetUserIDFromContext(r.Context())
+
+		var workspaceID int
+		if str := r.Header.Get(Header); str != "" {
+			id, err := strconv.Atoi(str)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, errInvalidHeader)
+				return
+			}
+			workspaceID = id
+		} else {
+			id, err := defaultWorkspaceID(r.Context(), store, userID)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, err)
+				return
+			}
+			workspaceID = id
+		}
+
+		member, err := store.GetMember(r.Context(), workspaceID, userID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if member == nil {
+			utils.WriteError(w, http.StatusNotFound, ErrWorkspaceNotFound)
+			return
+		}
+
+		required := RoleMember
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			required = RoleGuest
+		}
+		if !HasRole(member.Role, required) {
+			utils.WriteError(w, http.StatusForbidden, errReadOnly)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), memberKey, member)
+		handlerFunc(w, r.WithContext(ctx))
+	}
+}
+
+// IDFromContext returns the workspace selected by WithWorkspace, 0 outside of it.
+func IDFromContext(ctx context.Context) int {
+	member, ok := ctx.Value(memberKey).(*types.WorkspaceMember)
+	if !ok {
+		return 0
+	}
+
+	return member.WorkspaceID
+}
+
+// Contains reports whether the task belongs to the workspace of the request.
+func Contains(ctx context.Context, task *types.Task) bool {
+	workspaceID := IDFromContext(ctx)
+	return task.ID != 0 && workspaceID != 0 && task.WorkspaceID == workspaceID
+}
+
+// GetTask resolves the {task_id} route variable to a task of the selected workspace,
+// writing the error response itself on failure.
+func GetTask(w http.ResponseWriter, r *http.Request, store types.TaskStore) (*types.Task, bool) {
+	taskID, err := strconv.Atoi(mux.Vars(r)["task_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid task ID"))
+		return nil, false
+	}
+
+	task, err := store.GetTaskByID(r.Context(), taskID)
+	if errors.Is(err, types.ErrTaskNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return nil, false
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	if !Contains(r.Context(), task) {
+		utils.WriteError(w, http.StatusNotFound, types.ErrTaskNotFound)
+		return nil, false
+	}
+
+	return task, true
+}
+
+// defaultWorkspaceID picks the user's oldest membership, users without any get a personal workspace.
+func defaultWorkspaceID(ctx context.Context, store types.WorkspaceStore, userID int) (int, error) {
+	workspaceID, err := store.GetDefaultWorkspaceID(ctx, userID)
+	if err != nil || workspaceID != 0 {
+		return workspaceID, err
+	}
+
+	return store.CreateWorkspace(ctx, defaultName, userID)
+}
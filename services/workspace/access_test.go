@@ -0,0 +1,44 @@
+package workspace
+
+import (
+	"context"
+	"testing"
+	"todo/types"
+)
+
+func TestHasRole(t *testing.T) {
+	cases := []struct {
+		role, required string
+		ok             bool
+	}{
+		{RoleOwner, RoleAdmin, true},
+		{RoleAdmin, RoleAdmin, true},
+		{RoleMember, RoleAdmin, false},
+		{RoleGuest, RoleMember, false},
+		{RoleGuest, RoleGuest, true},
+		{"", RoleGuest, false},
+	}
+
+	for _, c := range cases {
+		if got := HasRole(c.role, c.required); got != c.ok {
+			t.Errorf("HasRole(%q, %q) = %v, want %v", c.role, c.required, got, c.ok)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	ctx := context.WithValue(context.Background(), memberKey, &types.WorkspaceMember{WorkspaceID: 2})
+
+	if !Contains(ctx, &types.Task{ID: 1, WorkspaceID: 2}) {
+		t.Error("task of the selected workspace is not contained")
+	}
+	if Contains(ctx, &types.Task{ID: 1, WorkspaceID: 3}) {
+		t.Error("task of another workspace is contained")
+	}
+	if Contains(ctx, &types.Task{}) {
+		t.Error("missing task is contained")
+	}
+	if Contains(context.Background(), &types.Task{ID: 1}) {
+		t.Error("task is contained outside of a workspace")
+	}
+}
@@ -0,0 +1,479 @@
+package workspace
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"todo/errs"
+	"todo/services/auth"
+	"todo/services/notification"
+	"todo/types"
+	"todo/utils"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	errWorkspaceNotEmpty = errs.Conflict("workspace_not_empty", "workspace still holds tasks")
+	errLastOwner         = errs.Conflict("last_owner", "a workspace needs at least one owner")
+	errOwnerRequired     = errs.Forbidden("owner_required", "only owners can change ownership")
+	errAdminRequired     = errs.Forbidden("admin_required", "only admins can remove other members")
+	errInvitationEmail   = errs.Forbidden("invitation_email_mismatch", "invitation was sent to another email address")
+	errInvitationExpired = errs.New(http.StatusGone, "invitation_expired", "invitation is no longer valid")
+)
+
+type Handler struct {
+	store     types.WorkspaceStore
+	userStore types.UserStore
+	mailer    notification.Channel // nil when email is not configured
+	inviteTTL time.Duration
+}
+
+func NewHandler(store types.WorkspaceStore, userStore types.UserStore, mailer notification.Channel, inviteTTL time.Duration) *Handler {
+	return &Handler{store: store, userStore: userStore, mailer: mailer, inviteTTL: inviteTTL}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/workspaces", auth.WithJWTAuth(h.handleGetWorkspaces, h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/workspaces", auth.WithJWTAuth(h.handleCreateWorkspace, h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/workspaces/{workspace_id}", auth.WithJWTAuth(h.handleGetWorkspace, h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/workspaces/{workspace_id}", auth.WithJWTAuth(h.handleUpdateWorkspace, h.userStore)).Methods(http.MethodPut)
+	router.HandleFunc("/workspaces/{workspace_id}", auth.WithJWTAuth(h.handleDeleteWorkspace, h.userStore)).Methods(http.MethodDelete)
+	router.HandleFunc("/workspaces/{workspace_id}/members", auth.WithJWTAuth(h.handleGetMembers, h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/workspaces/{workspace_id}/members/{user_id}", auth.WithJWTAuth(h.handleSetMemberRole, h.userStore)).Methods(http.MethodPut)
+	router.HandleFunc("/workspaces/{workspace_id}/members/{user_id}", auth.WithJWTAuth(h.handleRemoveMember, h.userStore)).Methods(http.MethodDelete)
+	router.HandleFunc("/workspaces/{workspace_id}/invitations", auth.WithJWTAuth(h.handleGetInvitations, h.userStore)).Methods(http.MethodGet)
+	router.HandleFunc("/workspaces/{workspace_id}/invitations", auth.WithJWTAuth(h.handleInvite, h.userStore)).Methods(http.MethodPost)
+	router.HandleFunc("/workspaces/{workspace_id}/invitations/{invitation_id}", auth.WithJWTAuth(h.handleDeleteInvitation, h.userStore)).Methods(http.MethodDelete)
+	router.HandleFunc("/invitations/{token}/accept", auth.WithJWTAuth(h.handleAcceptInvitation, h.userStore)).Methods(http.MethodPost)
+}
+
+func (h *Handler) handleGetWorkspaces(w http.ResponseWriter, r *http.Request) {
+	workspaces, err := h.store.GetWorkspacesByUserID(r.Context(), auth.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, workspaces)
+}
+
+func (h *Handler) handleCreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	var payload types.WorkspacePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	workspaceID, err := h.store.CreateWorkspace(r.Context(), payload.Name, auth.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.writeWorkspace(w, r, workspaceID, RoleOwner, http.StatusCreated)
+}
+
+func (h *Handler) handleGetWorkspace(w http.ResponseWriter, r *http.Request) {
+	member, ok := h.getMembership(w, r, RoleGuest)
+	if !ok {
+		return
+	}
+
+	h.writeWorkspace(w, r, member.WorkspaceID, member.Role, http.StatusOK)
+}
+
+func (h *Handler) handleUpdateWorkspace(w http.ResponseWriter, r *http.Request) {
+	member, ok := h.getMembership(w, r, RoleAdmin)
+	if !ok {
+		return
+	}
+
+	var payload types.WorkspacePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	if err := h.store.UpdateWorkspace(r.Context(), member.WorkspaceID, payload.Name); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.writeWorkspace(w, r, member.WorkspaceID, member.Role, http.StatusOK)
+}
+
+// handleDeleteWorkspace only deletes empty workspaces, tasks have to be moved or purged first.
+func (h *Handler) handleDeleteWorkspace(w http.ResponseWriter, r *http.Request) {
+	member, ok := h.getMembership(w, r, RoleOwner)
+	if !ok {
+		return
+	}
+
+	count, err := h.store.CountWorkspaceTasks(r.Context(), member.WorkspaceID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if count > 0 {
+		utils.WriteError(w, http.StatusConflict, errWorkspaceNotEmpty.Errorf("workspace still holds %d tasks", count))
+		return
+	}
+
+	if err := h.store.DeleteWorkspace(r.Context(), member.WorkspaceID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleGetMembers(w http.ResponseWriter, r *http.Request) {
+	member, ok := h.getMembership(w, r, RoleGuest)
+	if !ok {
+		return
+	}
+
+	members, err := h.store.GetMembers(r.Context(), member.WorkspaceID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, members)
+}
+
+// handleSetMemberRole lets admins manage roles, granting or taking away ownership needs an owner.
+func (h *Handler) handleSetMemberRole(w http.ResponseWriter, r *http.Request) {
+	requester, ok := h.getMembership(w, r, RoleAdmin)
+	if !ok {
+		return
+	}
+
+	target, ok := h.getTargetMember(w, r, requester.WorkspaceID)
+	if !ok {
+		return
+	}
+
+	var payload types.SetMemberRolePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+
+	if (payload.Role == RoleOwner || target.Role == RoleOwner) && requester.Role != RoleOwner {
+		utils.WriteError(w, http.StatusForbidden, errOwnerRequired)
+		return
+	}
+	if target.Role == RoleOwner && payload.Role != RoleOwner && !h.hasOtherOwner(w, r, target) {
+		return
+	}
+
+	if err := h.store.SetMemberRole(r.Context(), target.WorkspaceID, target.UserID, payload.Role); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	target.Role = payload.Role
+	utils.WriteJson(w, http.StatusOK, target)
+}
+
+// handleRemoveMember lets every member leave, removing someone else needs an admin.
+func (h *Handler) handleRemoveMember(w http.ResponseWriter, r *http.Request) {
+	requester, ok := h.getMembership(w, r, RoleGuest)
+	if !ok {
+		return
+	}
+
+	target, ok := h.getTargetMember(w, r, requester.WorkspaceID)
+	if !ok {
+		return
+	}
+
+	if target.UserID != requester.UserID {
+		if !HasRole(requester.Role, RoleAdmin) {
+			utils.WriteError(w, http.StatusForbidden, errAdminRequired)
+			return
+		}
+		if target.Role == RoleOwner && requester.Role != RoleOwner {
+			utils.WriteError(w, http.StatusForbidden, errOwnerRequired.Errorf("only owners can remove an owner"))
+			return
+		}
+	}
+	if target.Role == RoleOwner && !h.hasOtherOwner(w, r, target) {
+		return
+	}
+
+	if _, err := h.store.RemoveMember(r.Context(), target.WorkspaceID, target.UserID); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleGetInvitations(w http.ResponseWriter, r *http.Request) {
+	member, ok := h.getMembership(w, r, RoleAdmin)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.store.GetInvitations(r.Context(), member.WorkspaceID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	utils.WriteJson(w, http.StatusOK, invitations)
+}
+
+// handleInvite emails a single-use token to the invitee. The token is also part of the
+// response, it is not stored and cannot be looked up again.
+func (h *Handler) handleInvite(w http.ResponseWriter, r *http.Request) {
+	member, ok := h.getMembership(w, r, RoleAdmin)
+	if !ok {
+		return
+	}
+
+	var payload types.InvitePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ValidationError(r, err))
+		return
+	}
+	if payload.Role == "" {
+		payload.Role = RoleMember
+	}
+
+	token, err := newToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	invitation := types.WorkspaceInvitation{
+		WorkspaceID: member.WorkspaceID,
+		Email:       strings.ToLower(payload.Email),
+		Role:        payload.Role,
+		InvitedBy:   &member.UserID,
+		ExpiresAt:   time.Now().Add(h.inviteTTL),
+	}
+	invitationID, err := h.store.CreateInvitation(r.Context(), invitation, hashToken(token))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	invitation.ID = invitationID
+
+	h.sendInvitation(r.Context(), invitation, token)
+
+	utils.WriteJson(w, http.StatusCreated, map[string]any{
+		"invitation": invitation,
+		"token":      token,
+	})
+}
+
+func (h *Handler) handleDeleteInvitation(w http.ResponseWriter, r *http.Request) {
+	member, ok := h.getMembership(w, r, RoleAdmin)
+	if !ok {
+		return
+	}
+
+	invitationID, err := strconv.Atoi(mux.Vars(r)["invitation_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid invitation ID"))
+		return
+	}
+
+	rowsAffected, err := h.store.DeleteInvitation(r.Context(), member.WorkspaceID, invitationID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if rowsAffected == 0 {
+		utils.WriteError(w, http.StatusNotFound, ErrInvitationNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAcceptInvitation adds the requesting user, whose email has to match the invited address.
+func (h *Handler) handleAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromContext(r.Context())
+
+	invitation, err := h.store.GetInvitationByTokenHash(r.Context(), hashToken(mux.Vars(r)["token"]))
+	if errors.Is(err, ErrInvitationNotFound) {
+		utils.WriteError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if invitation.AcceptedAt != nil || time.Now().After(invitation.ExpiresAt) {
+		utils.WriteError(w, http.StatusGone, errInvitationExpired)
+		return
+	}
+
+	u, err := h.userStore.GetUserByID(r.Context(), userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !strings.EqualFold(u.Email, invitation.Email) {
+		utils.WriteError(w, http.StatusForbidden, errInvitationEmail)
+		return
+	}
+
+	if err := h.store.AcceptInvitation(r.Context(), invitation.ID, userID, invitation.Role); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	member, err := h.store.GetMember(r.Context(), invitation.WorkspaceID, userID)
+	if err != nil || member == nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to join workspace"))
+		return
+	}
+
+	h.writeWorkspace(w, r, member.WorkspaceID, member.Role, http.StatusOK)
+}
+
+func (h *Handler) sendInvitation(ctx context.Context, invitation types.WorkspaceInvitation, token string) {
+	if h.mailer == nil {
+		return
+	}
+
+	ws, err := h.store.GetWorkspaceByID(ctx, invitation.WorkspaceID)
+	if err != nil {
+		log.Printf("failed to get workspace %d: %v", invitation.WorkspaceID, err)
+		return
+	}
+
+	err = h.mailer.Send(ctx, notification.Message{
+		Target:  invitation.Email,
+		Subject: fmt.Sprintf("You are invited to %s", ws.Name),
+		Body: fmt.Sprintf("You are invited to join %s as %s until %s.\n\nInvitation token: %s",
+			ws.Name, invitation.Role, invitation.ExpiresAt.Format(time.RFC1123), token),
+	})
+	if err != nil {
+		log.Printf("failed to email invitation %d: %v", invitation.ID, err)
+	}
+}
+
+func (h *Handler) writeWorkspace(w http.ResponseWriter, r *http.Request, workspaceID int, role string, status int) {
+	ws, err := h.store.GetWorkspaceByID(r.Context(), workspaceID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	ws.Role = role
+
+	utils.WriteJson(w, status, ws)
+}
+
+// getMembership resolves {workspace_id} and checks the requester's role, writing the error response itself on failure.
+// Workspaces the requester does not belong to are reported as not found.
+func (h *Handler) getMembership(w http.ResponseWriter, r *http.Request, required string) (*types.WorkspaceMember, bool) {
+	workspaceID, err := strconv.Atoi(mux.Vars(r)["workspace_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid workspace ID"))
+		return nil, false
+	}
+
+	member, err := h.store.GetMember(r.Context(), workspaceID, auth.GetUserIDFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	if member == nil {
+		utils.WriteError(w, http.StatusNotFound, ErrWorkspaceNotFound)
+		return nil, false
+	}
+	if !HasRole(member.Role, required) {
+		utils.WriteError(w, http.StatusForbidden, errRoleRequired.Errorf("requires the %s role", required))
+		return nil, false
+	}
+
+	return member, true
+}
+
+func (h *Handler) getTargetMember(w http.ResponseWriter, r *http.Request, workspaceID int) (*types.WorkspaceMember, bool) {
+	userID, err := strconv.Atoi(mux.Vars(r)["user_id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidID.Errorf("invalid user ID"))
+		return nil, false
+	}
+
+	member, err := h.store.GetMember(r.Context(), workspaceID, userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	if member == nil {
+		utils.WriteError(w, http.StatusNotFound, ErrNotMember)
+		return nil, false
+	}
+
+	return member, true
+}
+
+// hasOtherOwner keeps a workspace from losing its last owner.
+func (h *Handler) hasOtherOwner(w http.ResponseWriter, r *http.Request, owner *types.WorkspaceMember) bool {
+	members, err := h.store.GetMembers(r.Context(), owner.WorkspaceID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return false
+	}
+
+	for _, m := range members {
+		if m.Role == RoleOwner && m.UserID != owner.UserID {
+			return true
+		}
+	}
+
+	utils.WriteError(w, http.StatusConflict, errLastOwner)
+	return false
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,50 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"todo/services/auth"
+	"todo/types"
+
+	"github.com/gorilla/mux"
+)
+
+// failingStore fails to look up invitations.
+type failingStore struct {
+	types.WorkspaceStore
+	err error
+}
+
+func (s failingStore) GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*types.WorkspaceInvitation, error) {
+	return nil, s.err
+}
+
+func TestHandleAcceptInvitationLookupErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"not found", ErrInvitationNotFound, http.StatusNotFound},
+		{"database error", errors.New("driver: bad connection"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(failingStore{err: tt.err}, nil, nil, 0)
+
+			r := httptest.NewRequest(http.MethodPost, "/invitations/token/accept", nil)
+			r = mux.SetURLVars(r, map[string]string{"token": "token"})
+			r = r.WithContext(context.WithValue(r.Context(), auth.UserKey, 1))
+			w := httptest.NewRecorder()
+			h.handleAcceptInvitation(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+		})
+	}
+}
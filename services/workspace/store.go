@@ -0,0 +1,352 @@
+package workspace
+
+import (
+	"context"
+	"database/sql"
+	"todo/db/dialect"
+	"todo/errs"
+	"todo/types"
+)
+
+const memberColumns = `m.workspace_id, m.user_id, u.firstName, u.lastName, u.email, m.role, m.created_at`
+
+const invitationColumns = `id, workspace_id, email, role, invited_by, expires_at, accepted_at, created_at`
+
+type Store struct {
+	db *dialect.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: dialect.Wrap(db)}
+}
+
+func (s *Store) GetWorkspacesByUserID(ctx context.Context, userID int) ([]types.Workspace, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT w.id, w.name, m.role, w.created_at
+	FROM workspaces w
+	JOIN workspace_members m ON m.workspace_id = w.id
+	WHERE m.user_id = ?
+	ORDER BY m.created_at, w.id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := []types.Workspace{}
+	for rows.Next() {
+		var ws types.Workspace
+		if err := rows.Scan(&ws.ID, &ws.Name, &ws.Role, &ws.CreatedAt); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, ws)
+	}
+
+	return workspaces, errs.FromContext(ctx, rows.Err())
+}
+
+func (s *Store) GetWorkspaceByID(ctx context.Context, workspaceID int) (*types.Workspace, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	ws := new(types.Workspace)
+	err := s.db.QueryRowContext(ctx, "SELECT id, name, created_at FROM workspaces WHERE id = ?", workspaceID).
+		Scan(&ws.ID, &ws.Name, &ws.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrWorkspaceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+// CreateWorkspace creates the workspace with ownerID as its first owner.
+func (s *Store) CreateWorkspace(ctx context.Context, name string, ownerID int) (int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	id, err := tx.InsertContext(ctx, "INSERT INTO workspaces (name) VALUES (?)", name)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO workspace_members (workspace_id, user_id, role) VALUES (?, ?, ?)", id, ownerID, RoleOwner)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), errs.FromContext(ctx, tx.Commit())
+}
+
+func (s *Store) UpdateWorkspace(ctx context.Context, workspaceID int, name string) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE workspaces SET name = ? WHERE id = ?", name, workspaceID)
+	return err
+}
+
+// DeleteWorkspace removes the workspace with its members and invitations, tasks keep it from being deleted.
+func (s *Store) DeleteWorkspace(ctx context.Context, workspaceID int) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM workspaces WHERE id = ?", workspaceID)
+	return err
+}
+
+// CountWorkspaceTasks counts trashed tasks too, they still reference the workspace.
+func (s *Store) CountWorkspaceTasks(ctx context.Context, workspaceID int) (int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	var count int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks WHERE workspace_id = ?", workspaceID).Scan(&count)
+
+	return count, err
+}
+
+// GetDefaultWorkspaceID returns the workspace the user joined first, 0 when there is none.
+func (s *Store) GetDefaultWorkspaceID(ctx context.Context, userID int) (int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	var workspaceID int
+	err := s.db.QueryRowContext(ctx,
+		"SELECT workspace_id FROM workspace_members WHERE user_id = ? ORDER BY created_at, workspace_id LIMIT 1",
+		userID).Scan(&workspaceID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return workspaceID, err
+}
+
+// GetMember returns nil without an error when the user is not a member.
+func (s *Store) GetMember(ctx context.Context, workspaceID, userID int) (*types.WorkspaceMember, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+memberColumns+" FROM workspace_members m JOIN users u ON u.id = m.user_id WHERE m.workspace_id = ? AND m.user_id = ?",
+		workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var member *types.WorkspaceMember
+	for rows.Next() {
+		member, err = scanRowsIntoMember(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return member, errs.FromContext(ctx, rows.Err())
+}
+
+func (s *Store) GetMembers(ctx context.Context, workspaceID int) ([]types.WorkspaceMember, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+memberColumns+" FROM workspace_members m JOIN users u ON u.id = m.user_id WHERE m.workspace_id = ? ORDER BY m.created_at, m.user_id",
+		workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []types.WorkspaceMember{}
+	for rows.Next() {
+		m, err := scanRowsIntoMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, *m)
+	}
+
+	return members, errs.FromContext(ctx, rows.Err())
+}
+
+func (s *Store) SetMemberRole(ctx context.Context, workspaceID, userID int, role string) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	_, err := s.db.ExecContext(ctx, "UPDATE workspace_members SET role = ? WHERE workspace_id = ? AND user_id = ?", role, workspaceID, userID)
+	return err
+}
+
+func (s *Store) RemoveMember(ctx context.Context, workspaceID, userID int) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM workspace_members WHERE workspace_id = ? AND user_id = ?", workspaceID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// CreateInvitation stores only the hash of the token, the token itself is handed out once.
+func (s *Store) CreateInvitation(ctx context.Context, invitation types.WorkspaceInvitation, tokenHash string) (int, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	id, err := s.db.InsertContext(ctx,
+		"INSERT INTO workspace_invitations (workspace_id, email, role, token_hash, invited_by, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		invitation.WorkspaceID, invitation.Email, invitation.Role, tokenHash, invitation.InvitedBy, invitation.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func (s *Store) GetInvitations(ctx context.Context, workspaceID int) ([]types.WorkspaceInvitation, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+invitationColumns+" FROM workspace_invitations WHERE workspace_id = ? ORDER BY id DESC",
+		workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []types.WorkspaceInvitation{}
+	for rows.Next() {
+		inv, err := scanRowsIntoInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *inv)
+	}
+
+	return invitations, errs.FromContext(ctx, rows.Err())
+}
+
+func (s *Store) GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*types.WorkspaceInvitation, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+invitationColumns+" FROM workspace_invitations WHERE token_hash = ?", tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	inv := new(types.WorkspaceInvitation)
+	for rows.Next() {
+		inv, err = scanRowsIntoInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errs.FromContext(ctx, err)
+	}
+
+	if inv.ID == 0 {
+		return nil, ErrInvitationNotFound
+	}
+
+	return inv, nil
+}
+
+// AcceptInvitation marks the invitation as used and adds the user, existing members keep their role.
+func (s *Store) AcceptInvitation(ctx context.Context, invitationID, userID int, role string) error {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE workspace_invitations SET accepted_at = CURRENT_TIMESTAMP WHERE id = ? AND accepted_at IS NULL AND expires_at > CURRENT_TIMESTAMP",
+		invitationID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		if err == nil {
+			err = errInvitationExpired
+		}
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, tx.Dialect().InsertIgnore(`INTO workspace_members (workspace_id, user_id, role)
+	SELECT workspace_id, ?, ? FROM workspace_invitations WHERE id = ?`), userID, role, invitationID)
+	if err != nil {
+		return err
+	}
+
+	return errs.FromContext(ctx, tx.Commit())
+}
+
+func (s *Store) DeleteInvitation(ctx context.Context, workspaceID, invitationID int) (int64, error) {
+	ctx, cancel := s.db.Timeout(ctx)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM workspace_invitations WHERE id = ? AND workspace_id = ?", invitationID, workspaceID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func scanRowsIntoMember(rows *sql.Rows) (*types.WorkspaceMember, error) {
+	m := new(types.WorkspaceMember)
+
+	err := rows.Scan(
+		&m.WorkspaceID,
+		&m.UserID,
+		&m.FirstName,
+		&m.LastName,
+		&m.Email,
+		&m.Role,
+		&m.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func scanRowsIntoInvitation(rows *sql.Rows) (*types.WorkspaceInvitation, error) {
+	inv := new(types.WorkspaceInvitation)
+
+	err := rows.Scan(
+		&inv.ID,
+		&inv.WorkspaceID,
+		&inv.Email,
+		&inv.Role,
+		&inv.InvitedBy,
+		&inv.ExpiresAt,
+		&inv.AcceptedAt,
+		&inv.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
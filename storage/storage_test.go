@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"todo/types"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("error creating local store: %v", err)
+	}
+
+	testBlobStore(t, store)
+}
+
+func TestLocalStoreRejectsTraversal(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("error creating local store: %v", err)
+	}
+
+	if err := store.Put(context.Background(), "../escape", strings.NewReader("x"), 1, "text/plain"); err == nil {
+		t.Error("expected key with .. to be rejected")
+	}
+}
+
+// TestS3Store runs against any S3-compatible endpoint, e.g. a local MinIO:
+// S3_TEST_ENDPOINT=127.0.0.1:9000 S3_TEST_ACCESS_KEY=minioadmin S3_TEST_SECRET_KEY=minioadmin
+func TestS3Store(t *testing.T) {
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT not set")
+	}
+
+	store, err := NewS3Store(context.Background(), S3Config{
+		Endpoint:  endpoint,
+		AccessKey: os.Getenv("S3_TEST_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_TEST_SECRET_KEY"),
+		Bucket:    "todolist-test",
+		Region:    "us-east-1",
+	})
+	if err != nil {
+		t.Fatalf("error creating s3 store: %v", err)
+	}
+
+	testBlobStore(t, store)
+}
+
+func testBlobStore(t *testing.T, store types.BlobStore) {
+	ctx := context.Background()
+	key := "tasks/1/blob"
+	content := "hello attachment"
+
+	if err := store.Put(ctx, key, strings.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("error putting blob: %v", err)
+	}
+
+	blob, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("error getting blob: %v", err)
+	}
+
+	if _, err := blob.Seek(6, io.SeekStart); err != nil {
+		t.Fatalf("error seeking blob: %v", err)
+	}
+	rest, err := io.ReadAll(blob)
+	blob.Close()
+	if err != nil {
+		t.Fatalf("error reading blob: %v", err)
+	}
+	if string(rest) != "attachment" {
+		t.Errorf("expected %q after seeking, got %q", "attachment", rest)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("error deleting blob: %v", err)
+	}
+	if _, err := store.Get(ctx, key); err == nil {
+		t.Error("expected deleted blob to be gone")
+	}
+}
@@ -1,7 +1,10 @@
 package types
 
 import (
+	"context"
+	"io"
 	"time"
+	"todo/errs"
 	"todo/utils"
 )
 
@@ -15,9 +18,9 @@ type User struct {
 }
 
 type UserStore interface {
-	GetUserByID(userID int) (*User, error)
-	GetUserByEmail(email string) (*User, error)
-	CreateUser(User) error
+	GetUserByID(ctx context.Context, userID int) (*User, error)
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	CreateUser(ctx context.Context, user User) error
 }
 
 type LoginUserPayload struct {
@@ -34,38 +37,665 @@ type RegisterUserPayload struct {
 
 type Task struct {
 	ID          int        `json:"id"`
-	UserID      *int       `json:"user_id"` // Fixed tag
+	WorkspaceID int        `json:"workspace_id"`
+	UserID      *int       `json:"user_id"`    // owner, decides the board, workflow and custom fields
+	CreatorID   *int       `json:"creator_id"` // nil for tasks created before it was tracked
 	Title       string     `json:"title"`
 	Description string     `json:"description"`
-	Status      string     `json:"status"`   // pending, in_progress, completed
+	Status      string     `json:"status"`   // name of a status in the owner's workflow
 	Priority    int        `json:"priority"` // 1 - low, 2 - medium, 3 - high
 	DueDate     *time.Time `json:"due_date"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+
+	// move the task to its workflow's done status once every checklist item is done
+	ChecklistAutoComplete bool `json:"checklist_auto_complete"`
+
+	// fractional index of the task inside its (user_id, status) board column
+	Position string `json:"position"`
+
+	// incremented on every write of the task, checked by updates against lost writes
+	Version int `json:"version"`
+
+	// values keyed by the custom field definitions of the owner
+	CustomFields map[string]any `json:"custom_fields"`
+
+	AssigneeIDs []int    `json:"assignee_ids"`
+	WatcherIDs  []int    `json:"watcher_ids"`
+	Labels      []string `json:"labels"`
+
+	CommentCount int              `json:"comment_count"`
+	Checklist    ChecklistSummary `json:"checklist"`
 }
 
+type ChecklistSummary struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// ErrTaskNotFound is returned by TaskStore for tasks that do not exist or are in the trash.
+var ErrTaskNotFound = errs.NotFound("task_not_found", "task not found")
+
+// ErrUserNotFound is returned by UserStore for users that do not exist.
+var ErrUserNotFound = errs.NotFound("user_not_found", "user not found")
+
 type TaskStore interface {
-	GetTaskByID(taskID int) (*Task, error)
-	GetPaginatedTasks(pagination utils.PaginationParams) ([]Task, int, error)
-	CreateTask(task CreateTaskPayload) error
-	UpdateTask(taskID int, task UpdateTaskPayload) error
-	DeleteTask(taskID int) (int64, error)
+	GetTaskByID(ctx context.Context, taskID int) (*Task, error)
+	GetTaskWorkspaceID(ctx context.Context, taskID int) (int, error)
+	GetPaginatedTasks(ctx context.Context, filter TaskFilter, pagination utils.PaginationParams) ([]Task, int, error)
+	GetPaginatedTrashedTasks(ctx context.Context, filter TaskFilter, pagination utils.PaginationParams) ([]Task, int, error)
+	CreateTask(ctx context.Context, task CreateTaskPayload) (*Task, error)
+	CreateTasks(ctx context.Context, tasks []CreateTaskPayload) ([]Task, error)
+	UpdateTask(ctx context.Context, taskID, version int, task UpdateTaskPayload) (int64, error)
+	PatchTask(ctx context.Context, taskID, version int, task TaskDocument, changed []string) (int64, error)
+	BulkUpdateTasks(ctx context.Context, changes []BulkTaskChange, allOrNothing bool) ([]error, error)
+	DeleteTask(ctx context.Context, taskID int) (int64, error)
+	RestoreTask(ctx context.Context, taskID int) (int64, error)
+	PurgeTask(ctx context.Context, taskID int) (int64, error)
+	GetPurgeableTaskIDs(ctx context.Context, retention time.Duration) ([]int, error)
+	MoveTask(ctx context.Context, taskID, version int, status, position string) (int64, error)
+	GetLastPosition(ctx context.Context, column BoardColumn) (string, error)
+	GetUnbalancedColumns(ctx context.Context, maxKeyLength int) ([]BoardColumn, error)
+	RebalanceColumn(ctx context.Context, column BoardColumn) error
+	GetColumnTasks(ctx context.Context, column BoardColumn, pagination utils.PaginationParams) ([]Task, int, error)
+	CountColumnTasks(ctx context.Context, column BoardColumn) (int, error)
+}
+
+// TaskFilter narrows the task list, every condition has to match.
+type TaskFilter struct {
+	WorkspaceID  int // 0 matches every workspace
+	AssigneeID   *int
+	WatcherID    *int
+	Label        *string
+	CustomFields []CustomFieldFilter
+}
+
+// CustomFieldFilter matches tasks whose value for Key equals Value, for
+// multi_select fields Value has to be one of the selected options.
+type CustomFieldFilter struct {
+	Key   string
+	Type  string
+	Value any
+}
+
+// BoardColumn groups the tasks of one owner in one status, positions are only comparable inside a column.
+// A non-zero WorkspaceID narrows the column to the tasks of that workspace.
+type BoardColumn struct {
+	WorkspaceID int
+	UserID      *int
+	Status      string
+}
+
+// BulkTaskChange is one item of a bulk operation, a nil Update moves the task to the trash.
+// Updates only apply while the task is still at Version.
+type BulkTaskChange struct {
+	TaskID  int
+	Version int
+	Update  *UpdateTaskPayload
+}
+
+// BulkTaskPayload selects tasks by IDs or by filter. The operation's value is
+// validated like the matching field of UpdateTaskPayload.
+type BulkTaskPayload struct {
+	IDs          []int           `json:"ids" validate:"omitempty,unique,dive,gt=0"`
+	Filter       *BulkTaskFilter `json:"filter"`
+	Operation    string          `json:"operation" validate:"required,oneof=set_status set_priority reassign add_label delete"`
+	Status       *string         `json:"status"`
+	Priority     *int            `json:"priority"`
+	UserID       *int            `json:"user_id"`
+	Label        *string         `json:"label"`
+	AllOrNothing bool            `json:"all_or_nothing"`
+}
+
+type BulkTaskFilter struct {
+	AssigneeID *int    `json:"assignee_id"`
+	WatcherID  *int    `json:"watcher_id"`
+	Label      *string `json:"label"`
+}
+
+type BulkTaskResult struct {
+	TaskID int    `json:"task_id"`
+	Status int    `json:"status"` // HTTP status the item would have on its own
+	Error  string `json:"error,omitempty"`
+}
+
+type BulkTaskResponse struct {
+	Applied int              `json:"applied"`
+	Results []BulkTaskResult `json:"results"`
+}
+
+type MoveTaskPayload struct {
+	Status   string `json:"status" validate:"required"`
+	BeforeID *int   `json:"before_id"` // task that ends up right above the moved one
+	AfterID  *int   `json:"after_id"`  // task that ends up right below the moved one
+}
+
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+type TaskEvent struct {
+	ID        int                    `json:"id"`
+	TaskID    int                    `json:"task_id"`
+	ActorID   *int                   `json:"actor_id"`
+	Action    string                 `json:"action"` // create, update, delete, restore, revert
+	Changes   map[string]FieldChange `json:"changes"`
+	Snapshot  Task                   `json:"snapshot"` // state of the task after the event
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+type TaskEventStore interface {
+	GetTaskEventByID(ctx context.Context, eventID int) (*TaskEvent, error)
+	GetPaginatedTaskEvents(ctx context.Context, taskID int, pagination utils.PaginationParams) ([]TaskEvent, int, error)
+	CreateTaskEvent(ctx context.Context, event TaskEvent) error
 }
 
 type CreateTaskPayload struct {
 	UserID      *int       `json:"user_id"`
 	Title       string     `json:"title" validate:"required"`
 	Description *string    `json:"description"`
-	Status      string     `json:"status,omitempty" validate:"omitempty,oneof=pending in_progress completed"`
-	Priority    int        `json:"priority" validate:"required"`
-	DueDate     *time.Time `json:"due_date"`
+	Status      string     `json:"status,omitempty"`
+	Priority    int        `json:"priority" validate:"required,priority"`
+	DueDate     *time.Time `json:"due_date" validate:"omitempty,notpast"`
+
+	ChecklistAutoComplete bool           `json:"checklist_auto_complete"`
+	CustomFields          map[string]any `json:"custom_fields"`
+	AssigneeIDs           []int          `json:"assignee_ids" validate:"unique"`
+	Labels                []string       `json:"labels" validate:"unique,dive,required,max=50"`
+
+	CreatorID   int `json:"-"` // set from the authenticated user
+	WorkspaceID int `json:"-"` // set from the selected workspace
 }
 
 type UpdateTaskPayload struct {
 	UserID      *int       `json:"user_id,omitempty"`
 	Title       *string    `json:"title,omitempty"`
 	Description *string    `json:"description,omitempty"`
-	Status      *string    `json:"status,omitempty" validate:"omitempty,oneof=pending in_progress completed"`
-	Priority    *int       `json:"priority,omitempty" validate:"omitempty,oneof=1 2 3"`
-	DueDate     *time.Time `json:"due_date,omitempty"`
+	Status      *string    `json:"status,omitempty"`
+	Priority    *int       `json:"priority,omitempty" validate:"omitempty,priority"`
+	DueDate     *time.Time `json:"due_date,omitempty" validate:"omitempty,notpast"`
+
+	ChecklistAutoComplete *bool `json:"checklist_auto_complete,omitempty"`
+
+	// merged into the current values, a null value clears the field
+	CustomFields map[string]any `json:"custom_fields,omitempty"`
+
+	// replaces every assignee when given
+	AssigneeIDs *[]int `json:"assignee_ids,omitempty" validate:"omitempty,unique"`
+
+	// replaces every label when given
+	Labels *[]string `json:"labels,omitempty" validate:"omitempty,unique,dive,required,max=50"`
+}
+
+// TaskDocument holds the editable fields of a task, PATCH requests are applied to it.
+// Unlike UpdateTaskPayload a null value clears the field.
+type TaskDocument struct {
+	UserID      *int       `json:"user_id"`
+	Title       string     `json:"title" validate:"required"`
+	Description string     `json:"description"`
+	Status      string     `json:"status" validate:"required"`
+	Priority    int        `json:"priority" validate:"priority"`
+	DueDate     *time.Time `json:"due_date" validate:"omitempty,notpast"`
+
+	ChecklistAutoComplete bool           `json:"checklist_auto_complete"`
+	CustomFields          map[string]any `json:"custom_fields"`
+	AssigneeIDs           []int          `json:"assignee_ids" validate:"unique"`
+	Labels                []string       `json:"labels" validate:"unique,dive,required,max=50"`
+}
+
+type Watcher struct {
+	TaskID    int       `json:"task_id"`
+	UserID    int       `json:"user_id"`
+	Channel   string    `json:"channel"` // email, webhook, in_app
+	Target    string    `json:"target"`  // email address or webhook URL
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AssigneeStore manages who works on a task, assignees start watching the task when they are added.
+type AssigneeStore interface {
+	GetAssignees(ctx context.Context, taskID int) ([]User, error)
+	AddAssignee(ctx context.Context, taskID, userID int) error
+	RemoveAssignee(ctx context.Context, taskID, userID int) (int64, error)
+}
+
+type WatcherStore interface {
+	GetWatchers(ctx context.Context, taskID int) ([]Watcher, error)
+	SetWatcher(ctx context.Context, watcher Watcher) error
+	RemoveWatcher(ctx context.Context, taskID, userID int) (int64, error)
+}
+
+type AssignPayload struct {
+	UserID int `json:"user_id" validate:"required"`
+}
+
+type WatchPayload struct {
+	UserID  *int   `json:"user_id"` // defaults to the requesting user
+	Channel string `json:"channel" validate:"omitempty,oneof=email webhook in_app"`
+	Target  string `json:"target"`
+}
+
+type Reminder struct {
+	ID            int        `json:"id"`
+	TaskID        int        `json:"task_id"`
+	UserID        int        `json:"user_id"`
+	RemindAt      *time.Time `json:"remind_at"`      // absolute time, nil when relative to due_date
+	OffsetMinutes *int       `json:"offset_minutes"` // minutes before the task's due_date
+	Channel       string     `json:"channel"`        // email, webhook, in_app
+	Target        string     `json:"target"`         // email address or webhook URL
+	Status        string     `json:"status"`         // pending, sent, failed
+	Attempts      int        `json:"attempts"`
+	LastError     *string    `json:"last_error"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// DueReminder is a reminder claimed by the scheduler together with the task it belongs to.
+type DueReminder struct {
+	Reminder Reminder
+	Task     Task
+}
+
+type ReminderStore interface {
+	GetReminderByID(ctx context.Context, reminderID int) (*Reminder, error)
+	GetRemindersByTaskID(ctx context.Context, taskID, userID int) ([]Reminder, error)
+	CreateReminder(ctx context.Context, reminder Reminder) (int, error)
+	DeleteReminder(ctx context.Context, reminderID int) (int64, error)
+	ClaimDueReminders(ctx context.Context, limit int, lease time.Duration) ([]DueReminder, error)
+	ReleaseReminder(ctx context.Context, reminderID int, deliverErr error, maxAttempts int) error
+}
+
+type CreateReminderPayload struct {
+	RemindAt      *time.Time `json:"remind_at" validate:"required_without=OffsetMinutes,excluded_with=OffsetMinutes"`
+	OffsetMinutes *int       `json:"offset_minutes" validate:"omitempty,min=0"`
+	Channel       string     `json:"channel" validate:"required,oneof=email webhook in_app"`
+	Target        string     `json:"target"`
+}
+
+type Notification struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	TaskID    *int       `json:"task_id"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type NotificationStore interface {
+	GetNotificationsByUserID(ctx context.Context, userID int, pagination utils.PaginationParams) ([]Notification, int, error)
+	CreateNotification(ctx context.Context, notification Notification) error
+	MarkNotificationRead(ctx context.Context, notificationID, userID int) (int64, error)
+}
+
+type Comment struct {
+	ID        int        `json:"id"`
+	TaskID    int        `json:"task_id"`
+	AuthorID  *int       `json:"author_id"`
+	GuestName *string    `json:"guest_name,omitempty"` // set for comments left through a share link
+	Body      string     `json:"body"`                 // markdown
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// CommentRevision holds the body a comment had before an edit.
+type CommentRevision struct {
+	ID        int       `json:"id"`
+	CommentID int       `json:"comment_id"`
+	EditorID  *int      `json:"editor_id"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CommentStore interface {
+	GetCommentByID(ctx context.Context, commentID int) (*Comment, error)
+	GetPaginatedComments(ctx context.Context, taskID int, pagination utils.PaginationParams) ([]Comment, int, error)
+	GetCommentRevisions(ctx context.Context, commentID int) ([]CommentRevision, error)
+	CreateComment(ctx context.Context, comment Comment) (int, error)
+	UpdateComment(ctx context.Context, commentID, editorID int, body string) error
+	DeleteComment(ctx context.Context, commentID int) (int64, error)
+}
+
+type CommentPayload struct {
+	Body string `json:"body" validate:"required,max=10000"`
+}
+
+type Attachment struct {
+	ID          int       `json:"id"`
+	TaskID      int       `json:"task_id"`
+	UploaderID  *int      `json:"uploader_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	StorageKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type AttachmentStore interface {
+	GetAttachmentByID(ctx context.Context, attachmentID int) (*Attachment, error)
+	GetAttachmentsByTaskID(ctx context.Context, taskID int) ([]Attachment, error)
+	CreateAttachment(ctx context.Context, attachment Attachment) (int, error)
+	DeleteAttachment(ctx context.Context, attachmentID int) (int64, error)
+}
+
+// BlobStore keeps the raw bytes of attachments, keyed by an opaque storage key.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type ChecklistItem struct {
+	ID        int       `json:"id"`
+	TaskID    int       `json:"task_id"`
+	Text      string    `json:"text"`
+	Done      bool      `json:"done"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ChecklistStore interface {
+	GetChecklistItemByID(ctx context.Context, itemID int) (*ChecklistItem, error)
+	GetChecklistItems(ctx context.Context, taskID int) ([]ChecklistItem, error)
+	CreateChecklistItem(ctx context.Context, taskID int, text string) (int, error)
+	ToggleChecklistItem(ctx context.Context, itemID int) error
+	ReorderChecklistItems(ctx context.Context, taskID int, itemIDs []int) error
+	DeleteChecklistItem(ctx context.Context, itemID int) (int64, error)
+}
+
+type CreateChecklistItemPayload struct {
+	Text string `json:"text" validate:"required,max=500"`
+}
+
+type ReorderChecklistPayload struct {
+	ItemIDs []int `json:"item_ids" validate:"required,min=1,dive,gt=0"`
+}
+
+// WIPLimit caps how many tasks a user's board column may hold.
+type WIPLimit struct {
+	UserID   int    `json:"user_id"`
+	Status   string `json:"status"`
+	MaxTasks int    `json:"max_tasks"`
+	Mode     string `json:"mode"` // reject, warn
+}
+
+type WIPLimitStore interface {
+	GetWIPLimits(ctx context.Context, userID int) ([]WIPLimit, error)
+	GetWIPLimit(ctx context.Context, userID int, status string) (*WIPLimit, error)
+	SetWIPLimit(ctx context.Context, limit WIPLimit) error
+	DeleteWIPLimit(ctx context.Context, userID int, status string) (int64, error)
+}
+
+type SetWIPLimitPayload struct {
+	MaxTasks int    `json:"max_tasks" validate:"required,min=1"`
+	Mode     string `json:"mode" validate:"omitempty,oneof=reject warn"`
+}
+
+type BoardColumnPage struct {
+	Status     string    `json:"status"`
+	Count      int       `json:"count"`
+	WIPLimit   *WIPLimit `json:"wip_limit"`
+	Page       int       `json:"page"`
+	Limit      int       `json:"limit"`
+	TotalPages int       `json:"total_pages"`
+	Tasks      []Task    `json:"tasks"`
+}
+
+type Board struct {
+	UserID  *int              `json:"user_id"`
+	Columns []BoardColumnPage `json:"columns"`
+}
+
+type TaskStatus struct {
+	ID       int    `json:"id"`
+	UserID   *int   `json:"user_id"` // nil for the default workflow
+	Name     string `json:"name"`
+	Category string `json:"category"` // todo, doing, done
+	Color    string `json:"color"`
+	Order    int    `json:"order"`
+}
+
+type StatusTransition struct {
+	From string `json:"from" validate:"required"`
+	To   string `json:"to" validate:"required"`
+}
+
+// Workflow is the set of statuses a task owner works with. Without any
+// transitions every status can follow every other one.
+type Workflow struct {
+	UserID      *int               `json:"user_id"`
+	Statuses    []TaskStatus       `json:"statuses"`
+	Transitions []StatusTransition `json:"transitions"`
+}
+
+type WorkflowStore interface {
+	GetWorkflow(ctx context.Context, userID *int) (*Workflow, error)
+	CreateStatus(ctx context.Context, status TaskStatus) error
+	UpdateStatus(ctx context.Context, userID int, name string, status TaskStatus) error
+	DeleteStatus(ctx context.Context, userID int, name string) error
+	SetTransitions(ctx context.Context, userID int, transitions []StatusTransition) error
+	CountTasksWithStatus(ctx context.Context, userID int, name string) (int, error)
+}
+
+type TaskStatusPayload struct {
+	Name     string `json:"name" validate:"required,max=64"`
+	Category string `json:"category" validate:"required,oneof=todo doing done"`
+	Color    string `json:"color" validate:"omitempty,hexcolor"`
+	Order    int    `json:"order"`
+}
+
+type SetTransitionsPayload struct {
+	Transitions []StatusTransition `json:"transitions" validate:"dive"`
+}
+
+type CustomField struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Key       string    `json:"key"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`              // text, number, date, select, multi_select, checkbox
+	Options   []string  `json:"options,omitempty"` // choices of select and multi_select fields
+	Required  bool      `json:"required"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CustomFieldStore interface {
+	GetCustomFields(ctx context.Context, userID int) ([]CustomField, error)
+	GetCustomFieldByKey(ctx context.Context, userID int, key string) (*CustomField, error)
+	CreateCustomField(ctx context.Context, field CustomField) (int, error)
+	UpdateCustomField(ctx context.Context, field CustomField) error
+	DeleteCustomField(ctx context.Context, userID int, key string) (int64, error)
+}
+
+type CreateCustomFieldPayload struct {
+	Key      string   `json:"key" validate:"required,max=64"`
+	Name     string   `json:"name" validate:"required,max=255"`
+	Type     string   `json:"type" validate:"required,oneof=text number date select multi_select checkbox"`
+	Options  []string `json:"options" validate:"required_if=Type select,required_if=Type multi_select,dive,required,max=255"`
+	Required bool     `json:"required"`
+}
+
+// UpdateCustomFieldPayload leaves out key and type, values already stored for a field keep their meaning.
+type UpdateCustomFieldPayload struct {
+	Name     string   `json:"name" validate:"required,max=255"`
+	Options  []string `json:"options" validate:"dive,required,max=255"`
+	Required bool     `json:"required"`
+}
+
+type Workspace struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role,omitempty"` // role of the requesting user
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WorkspaceMember struct {
+	WorkspaceID int       `json:"workspace_id"`
+	UserID      int       `json:"user_id"`
+	FirstName   string    `json:"first_name"`
+	LastName    string    `json:"last_name"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"` // owner, admin, member, guest
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type WorkspaceInvitation struct {
+	ID          int        `json:"id"`
+	WorkspaceID int        `json:"workspace_id"`
+	Email       string     `json:"email"`
+	Role        string     `json:"role"`
+	InvitedBy   *int       `json:"invited_by"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	AcceptedAt  *time.Time `json:"accepted_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type WorkspaceStore interface {
+	GetWorkspacesByUserID(ctx context.Context, userID int) ([]Workspace, error)
+	GetWorkspaceByID(ctx context.Context, workspaceID int) (*Workspace, error)
+	CreateWorkspace(ctx context.Context, name string, ownerID int) (int, error)
+	UpdateWorkspace(ctx context.Context, workspaceID int, name string) error
+	DeleteWorkspace(ctx context.Context, workspaceID int) error
+	CountWorkspaceTasks(ctx context.Context, workspaceID int) (int, error)
+	GetDefaultWorkspaceID(ctx context.Context, userID int) (int, error)
+	GetMember(ctx context.Context, workspaceID, userID int) (*WorkspaceMember, error)
+	GetMembers(ctx context.Context, workspaceID int) ([]WorkspaceMember, error)
+	SetMemberRole(ctx context.Context, workspaceID, userID int, role string) error
+	RemoveMember(ctx context.Context, workspaceID, userID int) (int64, error)
+	CreateInvitation(ctx context.Context, invitation WorkspaceInvitation, tokenHash string) (int, error)
+	GetInvitations(ctx context.Context, workspaceID int) ([]WorkspaceInvitation, error)
+	GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*WorkspaceInvitation, error)
+	AcceptInvitation(ctx context.Context, invitationID, userID int, role string) error
+	DeleteInvitation(ctx context.Context, workspaceID, invitationID int) (int64, error)
+}
+
+type WorkspacePayload struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type SetMemberRolePayload struct {
+	Role string `json:"role" validate:"required,oneof=owner admin member guest"`
+}
+
+type InvitePayload struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"omitempty,oneof=admin member guest"`
+}
+
+type ShareLink struct {
+	ID           int        `json:"id"`
+	TaskID       int        `json:"task_id"`
+	Token        string     `json:"token"`
+	Permission   string     `json:"permission"` // read or comment
+	PasswordHash string     `json:"-"`
+	HasPassword  bool       `json:"has_password"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedBy    *int       `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type ShareLinkStore interface {
+	GetShareLinks(ctx context.Context, taskID int) ([]ShareLink, error)
+	GetShareLinkByID(ctx context.Context, linkID int) (*ShareLink, error)
+	CreateShareLink(ctx context.Context, link ShareLink) (int, error)
+	RevokeShareLink(ctx context.Context, taskID, linkID int) (int64, error)
+}
+
+type CreateShareLinkPayload struct {
+	Permission string     `json:"permission" validate:"omitempty,oneof=read comment"`
+	Password   string     `json:"password" validate:"omitempty,min=6,max=72"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// SharedTask is what a share link exposes, it leaves out owners, watchers and other internals.
+type SharedTask struct {
+	Title        string          `json:"title"`
+	Description  string          `json:"description"`
+	Status       string          `json:"status"`
+	Priority     int             `json:"priority"`
+	DueDate      *time.Time      `json:"due_date"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	Checklist    []ChecklistItem `json:"checklist"`
+	Comments     []Comment       `json:"comments"`
+	CommentCount int             `json:"comment_count"`
+	Permission   string          `json:"permission"`
+}
+
+type SharedCommentPayload struct {
+	Name string `json:"name" validate:"required,max=100"`
+	Body string `json:"body" validate:"required,max=10000"`
+}
+
+type TaskTemplate struct {
+	ID          int             `json:"id"`
+	WorkspaceID int             `json:"workspace_id"`
+	Name        string          `json:"name"`
+	Tasks       []TaskBlueprint `json:"tasks"`
+	CreatedBy   *int            `json:"created_by"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// TaskBlueprint describes one task of a template, every text may contain {{variables}}.
+type TaskBlueprint struct {
+	Title          string   `json:"title" validate:"required,max=255"`
+	Description    string   `json:"description"`
+	Priority       int      `json:"priority" validate:"required,priority"`
+	DueOffsetHours *int     `json:"due_offset_hours,omitempty" validate:"omitempty,min=0"` // due date relative to the instantiation
+	Checklist      []string `json:"checklist" validate:"dive,required,max=500"`
+	Labels         []string `json:"labels" validate:"unique,dive,required,max=50"`
+}
+
+type TemplateStore interface {
+	GetTemplates(ctx context.Context, workspaceID int) ([]TaskTemplate, error)
+	GetTemplateByID(ctx context.Context, templateID int) (*TaskTemplate, error)
+	CreateTemplate(ctx context.Context, template TaskTemplate) (int, error)
+	UpdateTemplate(ctx context.Context, template TaskTemplate) error
+	DeleteTemplate(ctx context.Context, workspaceID, templateID int) (int64, error)
+}
+
+type TemplatePayload struct {
+	Name  string          `json:"name" validate:"required,max=255"`
+	Tasks []TaskBlueprint `json:"tasks" validate:"required,min=1,max=100,dive"`
+}
+
+type InstantiateTemplatePayload struct {
+	Variables map[string]string `json:"variables"`
+	UserID    *int              `json:"user_id"`  // owner of the new tasks, the requesting user by default
+	StartAt   *time.Time        `json:"start_at"` // due offsets count from here, now by default
+}
+
+type SaveAsTemplatePayload struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+// IdempotencyKey remembers the response to a request sent with an Idempotency-Key header.
+// StatusCode is 0 while the first request is still running.
+type IdempotencyKey struct {
+	UserID      int // 0 for unauthenticated requests
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Headers     map[string]string
+	Body        []byte
+	ExpiresAt   time.Time
+	LockedUntil time.Time // a claim without a response may be taken over afterwards
+	Token       string    // identifies the claim, only its request saves or releases the key
+	CreatedAt   time.Time
+}
+
+type IdempotencyStore interface {
+	// GetIdempotencyKey returns nil when the key is unknown or expired.
+	GetIdempotencyKey(ctx context.Context, userID int, key string) (*IdempotencyKey, error)
+	// ClaimIdempotencyKey stores a key without a response, it returns false when the key is already taken.
+	// An expired key or a claim without a response past its LockedUntil is taken over.
+	ClaimIdempotencyKey(ctx context.Context, key IdempotencyKey) (bool, error)
+	// SaveIdempotencyResponse and DeleteIdempotencyKey leave a key alone that another claim took over.
+	SaveIdempotencyResponse(ctx context.Context, key IdempotencyKey) error
+	DeleteIdempotencyKey(ctx context.Context, userID int, key, token string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
 }
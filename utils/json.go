@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"todo/configs"
+	"todo/errs"
+)
+
+// JSONOptions tunes how ParseJSON reads the body of one route, see WithJSONOptions.
+type JSONOptions struct {
+	MaxBytes           int64 // 0 uses configs.Envs.RequestMaxBytes
+	AllowUnknownFields bool
+}
+
+type jsonOptionsKey struct{}
+
+var (
+	errMissingBody     = errs.Validation("missing_body", "missing request body")
+	errInvalidJSON     = errs.Validation("invalid_json", "invalid JSON")
+	errBodyTooLarge    = errs.New(http.StatusRequestEntityTooLarge, "body_too_large", "request body is too large")
+	errUnsupportedType = errs.New(http.StatusUnsupportedMediaType, "unsupported_media_type", "Content-Type must be application/json")
+)
+
+// WithJSONOptions sets the decoding options of a route. Wrap it around the other middlewares
+// so bodies they buffer, e.g. for an Idempotency-Key, are held to the same limit.
+func WithJSONOptions(handlerFunc http.HandlerFunc, opts JSONOptions) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), jsonOptionsKey{}, opts)
+		handlerFunc(w, r.WithContext(ctx))
+	}
+}
+
+func jsonOptions(r *http.Request) JSONOptions {
+	opts, _ := r.Context().Value(jsonOptionsKey{}).(JSONOptions)
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = configs.Envs.RequestMaxBytes
+	}
+
+	return opts
+}
+
+// ReadBody reads the whole request body, a body over the route's limit is a 413.
+func ReadBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+
+	limit := jsonOptions(r).MaxBytes
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, limit))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, errBodyTooLarge.Errorf("request body is larger than %d bytes", limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// ParseJSON decodes a request body holding exactly one JSON value into v. The body must be
+// sent as application/json or another +json type and must fit the route's size limit, unknown
+// fields are rejected unless the route allows them. The errors carry a 400, 413 or 415 status
+// and point at the offending position.
+func ParseJSON(r *http.Request, v any) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return errUnsupportedType
+	}
+
+	body, err := ReadBody(r)
+	if err != nil {
+		return err
+	}
+
+	return decodeJSON(body, v, !jsonOptions(r).AllowUnknownFields)
+}
+
+// DecodeJSON decodes a body read with ReadBody like ParseJSON, for handlers that accept other
+// media types. Unknown fields are rejected.
+func DecodeJSON(body []byte, v any) error {
+	return decodeJSON(body, v, true)
+}
+
+func decodeJSON(body []byte, v any, disallowUnknownFields bool) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return errMissingBody
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(v); err != nil {
+		return decodeError(body, err)
+	}
+
+	end := decoder.InputOffset()
+	if rest := bytes.TrimLeft(body[end:], " \t\r\n"); len(rest) > 0 {
+		return invalidJSON(body, int64(len(body)-len(rest)), "unexpected data after the JSON value")
+	}
+
+	return nil
+}
+
+// decodeError adds the position to an error of json.Decoder.Decode.
+func decodeError(body []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		return invalidJSON(body, syntaxErr.Offset-1, syntaxErr.Error())
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return invalidJSON(body, int64(len(body)), "unexpected end of JSON")
+	case errors.As(err, &typeErr):
+		e := invalidJSON(body, typeErr.Offset-1, fmt.Sprintf("%s must be %s, got a JSON %s", typeErr.Field, typeErr.Type, typeErr.Value))
+		e.Fields = []errs.FieldError{{Field: typeErr.Field, Rule: "type", Param: typeErr.Type.String(), Message: e.Message}}
+		return e
+	}
+
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		name, _ = strconv.Unquote(name)
+		e := invalidJSON(body, keyOffset(body, name), fmt.Sprintf("unknown field %q", name))
+		e.Fields = []errs.FieldError{{Field: name, Rule: "unknown", Message: e.Message}}
+		return e
+	}
+
+	return errInvalidJSON.Errorf("invalid JSON: %v", err)
+}
+
+// invalidJSON reports a problem at a byte offset of the body as line and column.
+func invalidJSON(body []byte, offset int64, reason string) *errs.Error {
+	offset = max(0, min(offset, int64(len(body))))
+	before := body[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+
+	return errInvalidJSON.Errorf("invalid JSON at line %d, column %d (offset %d): %s", line, column, offset, reason)
+}
+
+// keyOffset finds the first object key called name, the decoder does not report where it was.
+func keyOffset(body []byte, name string) int64 {
+	key := []byte(strconv.Quote(name))
+	for start := 0; ; {
+		i := bytes.Index(body[start:], key)
+		if i < 0 {
+			return 0
+		}
+		i += start
+		start = i + len(key)
+		if rest := bytes.TrimLeft(body[start:], " \t\r\n"); len(rest) > 0 && rest[0] == ':' {
+			return int64(i)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo/errs"
+)
+
+func TestParseJSON(t *testing.T) {
+	type payload struct {
+		Title    string `json:"title"`
+		Priority int    `json:"priority"`
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        *JSONOptions
+		wantStatus  int
+		wantDetail  string
+	}{
+		{"valid", "application/json; charset=utf-8", `{"title":"x","priority":1}`, nil, 0, ""},
+		{"json suffix", "application/vnd.todo+json", `{"title":"x"}`, nil, 0, ""},
+		{"missing content type", "", `{"title":"x"}`, nil, http.StatusUnsupportedMediaType, ""},
+		{"form", "application/x-www-form-urlencoded", `title=x`, nil, http.StatusUnsupportedMediaType, ""},
+		{"empty", "application/json", ` `, nil, http.StatusBadRequest, "missing request body"},
+		{"too large", "application/json", `{"title":"xxxxxxxx"}`, &JSONOptions{MaxBytes: 10}, http.StatusRequestEntityTooLarge, "larger than 10 bytes"},
+		{"at the limit", "application/json", `{"title":"xxxx"}`, &JSONOptions{MaxBytes: 16}, 0, ""},
+		{"syntax", "application/json", "{\n  \"title\": }", nil, http.StatusBadRequest, "line 2, column 12 (offset 13)"},
+		{"truncated", "application/json", `{"title":"x"`, nil, http.StatusBadRequest, "unexpected end of JSON"},
+		{"type", "application/json", `{"priority":"high"}`, nil, http.StatusBadRequest, "priority must be int, got a JSON string"},
+		{"unknown field", "application/json", `{"title":"owner","owner":1}`, nil, http.StatusBadRequest, `column 18 (offset 17): unknown field "owner"`},
+		{"unknown field allowed", "application/json", `{"title":"x","owner":1}`, &JSONOptions{AllowUnknownFields: true}, 0, ""},
+		{"trailing data", "application/json", `{"title":"x"} {}`, nil, http.StatusBadRequest, "offset 14): unexpected data after the JSON value"},
+		{"trailing whitespace", "application/json", "{\"title\":\"x\"}\n", nil, 0, ""},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			r.Header.Set("Content-Type", tt.contentType)
+		}
+		if tt.opts != nil {
+			WithJSONOptions(func(w http.ResponseWriter, req *http.Request) { r = req }, *tt.opts)(nil, r)
+		}
+
+		var v payload
+		err := ParseJSON(r, &v)
+		if tt.wantStatus == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+
+		var e *errs.Error
+		if !errors.As(err, &e) || e.Status != tt.wantStatus || !strings.Contains(e.Message, tt.wantDetail) {
+			t.Errorf("%s: ParseJSON() = %v, want %d %q", tt.name, err, tt.wantStatus, tt.wantDetail)
+		}
+	}
+}
@@ -1,12 +1,14 @@
 package utils
 
 import (
-	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"todo/errs"
 )
 
+var errInvalidPagination = errs.Validation("invalid_pagination", "invalid pagination")
+
 type PaginationParams struct {
 	Page   int
 	Limit  int
@@ -29,7 +31,7 @@ func ParsePaginationParams(r *http.Request, allowedSortFields []string) (Paginat
 	// default values
 	page := 1
 	limit := 10
-	sortBy := "createdAt"
+	sortBy := "created_at"
 	order := "asc"
 
 	// parse page
@@ -56,7 +58,7 @@ func ParsePaginationParams(r *http.Request, allowedSortFields []string) (Paginat
 			}
 		}
 		if !valid {
-			return PaginationParams{}, fmt.Errorf("invalid sort_by field: %s", sortByQuery)
+			return PaginationParams{}, errInvalidPagination.Errorf("invalid sort_by field: %s", sortByQuery)
 		}
 
 		sortBy = sortByQuery
@@ -67,7 +69,7 @@ func ParsePaginationParams(r *http.Request, allowedSortFields []string) (Paginat
 	if orderQuery == "desc" {
 		order = "desc"
 	} else if orderQuery != "" && orderQuery != "asc" {
-		return PaginationParams{}, fmt.Errorf("invalid order: must be 'asc' or 'desc'")
+		return PaginationParams{}, errInvalidPagination.Errorf("invalid order: must be 'asc' or 'desc'")
 	}
 
 	// offset calculation
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"todo/errs"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+	RequestIDHeader    = "X-Request-ID"
+)
+
+// Problem is an RFC 7807 problem details body. Code is stable across releases, Detail is for humans.
+type Problem struct {
+	Type          string `json:"type"`
+	Title         string `json:"title"`
+	Status        int    `json:"status"`
+	Detail        string `json:"detail"`
+	Code          string `json:"code"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+
+	Errors []errs.FieldError `json:"errors,omitempty"`
+}
+
+// WriteError writes err as problem details. A domain error from package errs brings its own
+// status and code, status is used for any other error. Server errors are logged under a
+// correlation id and only the message of a domain error is sent to the client.
+func WriteError(w http.ResponseWriter, status int, err error) {
+	problem := NewProblem(status, err)
+	if problem.Status >= http.StatusInternalServerError {
+		problem.CorrelationID = w.Header().Get(RequestIDHeader)
+		if problem.CorrelationID == "" {
+			problem.CorrelationID = newRequestID()
+		}
+		log.Printf("[%s] %d: %v", problem.CorrelationID, problem.Status, err)
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// NewProblem maps err to problem details without writing them.
+func NewProblem(status int, err error) Problem {
+	code := statusCode(status)
+	var fields []errs.FieldError
+	var e *errs.Error
+	if errors.As(err, &e) {
+		status, code, fields = e.Status, e.Code, e.Fields
+	}
+
+	detail := err.Error()
+	if status >= http.StatusInternalServerError {
+		detail = "the server failed to handle the request, report the correlation id if it persists"
+		if e != nil {
+			detail = e.Message
+		}
+	}
+
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+		Errors: fields,
+	}
+}
+
+// statusCode is the default code of errors that are not domain errors, e.g. "not_found".
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
+
+// WithRequestID tags every request and its response with an X-Request-ID, a valid incoming one is kept.
+func WithRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = newRequestID()
+			r.Header.Set(RequestIDHeader, id)
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WithRequestTimeout gives every request d to finish. The stores stop once it has passed and
+// fail with errs.ErrRequestTimeout, which the handlers write as 503. 0 turns the deadline off.
+func WithRequestTimeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if d <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeoutCause(r.Context(), d, errs.ErrRequestTimeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"todo/errs"
+)
+
+func TestWriteError(t *testing.T) {
+	notFound := errs.NotFound("task_not_found", "task not found")
+
+	tests := []struct {
+		status     int
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{http.StatusBadRequest, fmt.Errorf("invalid task ID"), http.StatusBadRequest, "bad_request", "invalid task ID"},
+		{http.StatusInternalServerError, notFound, http.StatusNotFound, "task_not_found", "task not found"},
+		{http.StatusConflict, fmt.Errorf("%w: column is full", errs.Conflict("wip_limit_reached", "limit reached")), http.StatusConflict, "wip_limit_reached", "limit reached: column is full"},
+		{http.StatusForbidden, errs.Forbidden("role_required", "x").Errorf("requires the %s role", "admin"), http.StatusForbidden, "role_required", "requires the admin role"},
+		{http.StatusInternalServerError, errs.ErrQueryTimeout, http.StatusGatewayTimeout, "query_timeout", "the database did not answer in time"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		WriteError(w, tt.status, tt.err)
+
+		var problem Problem
+		if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != tt.wantStatus || problem.Status != tt.wantStatus || problem.Code != tt.wantCode || problem.Detail != tt.wantDetail {
+			t.Errorf("WriteError(%d, %v) = %d %+v", tt.status, tt.err, w.Code, problem)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+			t.Errorf("Content-Type = %q", ct)
+		}
+	}
+}
+
+func TestWriteErrorHidesServerErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set(RequestIDHeader, "abc")
+	WriteError(w, http.StatusInternalServerError, fmt.Errorf("Error 1146: Table 'tasks' doesn't exist"))
+
+	body := w.Body.String()
+	if strings.Contains(body, "1146") {
+		t.Errorf("server error leaked: %s", body)
+	}
+
+	var problem Problem
+	json.Unmarshal([]byte(body), &problem)
+	if problem.CorrelationID != "abc" || problem.Code != "internal_server_error" {
+		t.Errorf("unexpected problem: %+v", problem)
+	}
+}
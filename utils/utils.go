@@ -2,13 +2,12 @@ package utils
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
-
-	"github.com/go-playground/validator/v10"
+	"todo/errs"
 )
 
-var Validate = validator.New()
+// ErrInvalidID answers a route variable that holds no ID, Errorf names the ID.
+var ErrInvalidID = errs.Validation("invalid_id", "invalid ID")
 
 func WriteJson(w http.ResponseWriter, status int, v any) error {
 	w.Header().Add("Content-Type", "application/json")
@@ -16,18 +15,6 @@ func WriteJson(w http.ResponseWriter, status int, v any) error {
 	return json.NewEncoder(w).Encode(v)
 }
 
-func WriteError(w http.ResponseWriter, status int, err error) {
-	WriteJson(w, status, map[string]string{"error": err.Error()})
-}
-
-func ParseJSON(r *http.Request, v any) error {
-	if r.Body == nil {
-		return fmt.Errorf("missing request body")
-	}
-
-	return json.NewDecoder(r.Body).Decode(v)
-}
-
 func GetTokenFromRequest(r *http.Request) string {
 	tokenAuth := r.Header.Get("Authorization")
 	tokenQuery := r.URL.Query().Get("token")
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+	"todo/errs"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+)
+
+var Validate = validator.New()
+
+// translators holds the messages of every supported language, English is the fallback.
+var translators = ut.New(en.New(), en.New(), es.New(), fr.New())
+
+// customMessages translates the rules registered below, {0} is the field.
+var customMessages = map[string]map[string]string{
+	"notpast": {
+		"en": "{0} must not be in the past",
+		"es": "{0} no puede estar en el pasado",
+		"fr": "{0} ne doit pas être dans le passé",
+	},
+	"priority": {
+		"en": "{0} must be 1 (low), 2 (medium) or 3 (high)",
+		"es": "{0} debe ser 1 (baja), 2 (media) o 3 (alta)",
+		"fr": "{0} doit être 1 (basse), 2 (moyenne) ou 3 (haute)",
+	},
+}
+
+func init() {
+	// errors name fields like the JSON a client sent, "-" fields are never decoded
+	Validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	Validate.RegisterValidation("notpast", notPast)
+	Validate.RegisterValidation("priority", priority)
+
+	register := map[locales.Translator]func(*validator.Validate, ut.Translator) error{
+		en.New(): en_translations.RegisterDefaultTranslations,
+		es.New(): es_translations.RegisterDefaultTranslations,
+		fr.New(): fr_translations.RegisterDefaultTranslations,
+	}
+	for locale, defaults := range register {
+		trans, _ := translators.GetTranslator(locale.Locale())
+		if err := defaults(Validate, trans); err != nil {
+			panic(err)
+		}
+
+		for tag, messages := range customMessages {
+			message := messages[locale.Locale()]
+			err := Validate.RegisterTranslation(tag, trans,
+				func(t ut.Translator) error { return t.Add(tag, message, true) },
+				func(t ut.Translator, fe validator.FieldError) string {
+					translated, _ := t.T(tag, fe.Field())
+					return translated
+				})
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+// notPast accepts times that are not before now, leave it out for nil pointers with omitempty.
+func notPast(fl validator.FieldLevel) bool {
+	t, ok := fl.Field().Interface().(time.Time)
+	return ok && !t.Before(time.Now())
+}
+
+// priority accepts 1 - low, 2 - medium and 3 - high.
+func priority(fl validator.FieldLevel) bool {
+	p := fl.Field().Int()
+	return p >= 1 && p <= 3
+}
+
+// ValidationError turns an error of Validate into a validation error listing every invalid
+// field, messages are in the first supported language of the request's Accept-Language.
+func ValidationError(r *http.Request, err error) *errs.Error {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return errs.Validation("invalid_payload", err.Error())
+	}
+
+	trans := Translator(r)
+	fields := make([]errs.FieldError, len(invalid))
+	messages := make([]string, len(invalid))
+	for i, fe := range invalid {
+		fields[i] = errs.FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fe.Translate(trans),
+		}
+		messages[i] = fields[i].Message
+	}
+
+	e := errs.Validation("invalid_payload", "invalid payload: "+strings.Join(messages, "; "))
+	e.Fields = fields
+
+	return e
+}
+
+// Translator picks the translator for the request's Accept-Language header, quality values are ignored.
+func Translator(r *http.Request) ut.Translator {
+	var languages []string
+	for _, tag := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		language, _, _ := strings.Cut(strings.TrimSpace(tag), ";")
+		language, _, _ = strings.Cut(language, "-")
+		if language != "" {
+			languages = append(languages, strings.ToLower(language))
+		}
+	}
+
+	trans, _ := translators.FindTranslator(languages...)
+	return trans
+}
+
+// fieldPath drops the struct name from the namespace, e.g. tasks[0].title.
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+
+	return path
+}
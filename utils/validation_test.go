@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+	"todo/errs"
+)
+
+type testPayload struct {
+	Title    string     `json:"title" validate:"required"`
+	Priority int        `json:"priority" validate:"priority"`
+	DueDate  *time.Time `json:"due_date" validate:"omitempty,notpast"`
+	Items    []struct {
+		Text string `json:"text" validate:"max=3"`
+	} `json:"items" validate:"dive"`
+}
+
+func TestValidationError(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	payload := testPayload{Priority: 4, DueDate: &past}
+	payload.Items = append(payload.Items, struct {
+		Text string `json:"text" validate:"max=3"`
+	}{Text: "long"})
+
+	r := httptest.NewRequest(http.MethodPost, "/tasks", nil)
+	e := ValidationError(r, Validate.Struct(payload))
+
+	want := []errs.FieldError{
+		{Field: "title", Rule: "required", Message: "title is a required field"},
+		{Field: "priority", Rule: "priority", Message: "priority must be 1 (low), 2 (medium) or 3 (high)"},
+		{Field: "due_date", Rule: "notpast", Message: "due_date must not be in the past"},
+		{Field: "items[0].text", Rule: "max", Param: "3", Message: "text must be a maximum of 3 characters in length"},
+	}
+	if e.Status != http.StatusBadRequest || !reflect.DeepEqual(e.Fields, want) {
+		t.Errorf("ValidationError() = %d %+v", e.Status, e.Fields)
+	}
+
+	future := time.Now().Add(time.Hour)
+	if err := Validate.Struct(testPayload{Title: "x", Priority: 2, DueDate: &future}); err != nil {
+		t.Errorf("valid payload failed: %v", err)
+	}
+}
+
+func TestValidationErrorTranslated(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/tasks", nil)
+	r.Header.Set("Accept-Language", "de-DE, es;q=0.8, en;q=0.5")
+
+	e := ValidationError(r, Validate.Struct(testPayload{Title: "x", Priority: 0}))
+	if len(e.Fields) != 1 || e.Fields[0].Message != "priority debe ser 1 (baja), 2 (media) o 3 (alta)" {
+		t.Errorf("unexpected fields: %+v", e.Fields)
+	}
+}